@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"github.com/kibu-sh/kibu/internal/codegen"
+	"github.com/kibu-sh/kibu/internal/toolchain/kiburoutes"
 	"github.com/spf13/cobra"
 	"os"
 	"path/filepath"
@@ -12,7 +13,9 @@ type BuildCmd struct {
 }
 
 type NewBuildCmdParams struct {
-	loadConfig configLoaderFunc
+	loadConfig         configLoaderFunc
+	BuildTypeScriptCmd BuildTypeScriptCmd
+	BuildOpenAPICmd    BuildOpenAPICmd
 }
 
 func NewBuildCmd(params NewBuildCmdParams) (cmd BuildCmd) {
@@ -22,6 +25,9 @@ func NewBuildCmd(params NewBuildCmdParams) (cmd BuildCmd) {
 		Long:  `build code`,
 		RunE:  newBuildRunE(params),
 	}
+
+	cmd.AddCommand(params.BuildTypeScriptCmd.Command)
+	cmd.AddCommand(params.BuildOpenAPICmd.Command)
 	return
 }
 
@@ -37,6 +43,11 @@ func newBuildRunE(params NewBuildCmdParams) RunE {
 			return
 		}
 
+		// fail before generating handlers that would collide at runtime
+		if err = kiburoutes.Check(cwd, args); err != nil {
+			return
+		}
+
 		err = codegen.Generate(codegen.GenerateParams{
 			Dir:       cwd,
 			Patterns:  args,
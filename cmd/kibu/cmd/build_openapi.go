@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"github.com/kibu-sh/kibu/internal/toolchain/kibuopenapi"
+	"github.com/spf13/cobra"
+	"os"
+	"path/filepath"
+)
+
+type BuildOpenAPICmd struct {
+	*cobra.Command
+}
+
+type NewBuildOpenAPICmdParams struct {
+	loadConfig configLoaderFunc
+}
+
+func NewBuildOpenAPICmd(params NewBuildOpenAPICmdParams) (cmd BuildOpenAPICmd) {
+	cmd.Command = &cobra.Command{
+		Use:   "openapi [packages]",
+		Short: "generate an openapi document for http services",
+		Long:  `generate an openapi document for http services into code_gen.openapi_output_dir`,
+		RunE:  newBuildOpenAPIRunE(params),
+	}
+	return
+}
+
+func newBuildOpenAPIRunE(params NewBuildOpenAPICmdParams) RunE {
+	return func(cmd *cobra.Command, args []string) (err error) {
+		config, err := params.loadConfig()
+		if err != nil {
+			return
+		}
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			return
+		}
+
+		if len(args) == 0 {
+			args = []string{"./..."}
+		}
+
+		return kibuopenapi.Generate(cwd, filepath.Join(config.Root(), config.CodeGen.OpenAPIDir()), args)
+	}
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"github.com/kibu-sh/kibu/internal/toolchain/kibuts"
+	"github.com/spf13/cobra"
+	"os"
+	"path/filepath"
+)
+
+type BuildTypeScriptCmd struct {
+	*cobra.Command
+}
+
+type NewBuildTypeScriptCmdParams struct {
+	loadConfig configLoaderFunc
+}
+
+func NewBuildTypeScriptCmd(params NewBuildTypeScriptCmdParams) (cmd BuildTypeScriptCmd) {
+	cmd.Command = &cobra.Command{
+		Use:   "typescript [packages]",
+		Short: "generate typescript types and clients for http services",
+		Long:  `generate typescript types and clients for http services into code_gen.typescript_output_dir`,
+		RunE:  newBuildTypeScriptRunE(params),
+	}
+	return
+}
+
+func newBuildTypeScriptRunE(params NewBuildTypeScriptCmdParams) RunE {
+	return func(cmd *cobra.Command, args []string) (err error) {
+		config, err := params.loadConfig()
+		if err != nil {
+			return
+		}
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			return
+		}
+
+		if len(args) == 0 {
+			args = []string{"./..."}
+		}
+
+		return kibuts.Generate(cwd, filepath.Join(config.Root(), config.CodeGen.TypeScriptDir()), args)
+	}
+}
@@ -80,4 +80,13 @@ var (
 		Persistent:  true,
 		Required:    true,
 	}
+
+	// Routes Flags
+
+	RoutesAddr = cli.Flag[string]{
+		Long:        "addr",
+		Short:       "a",
+		Description: "The base URL of the admin server of the running binary to list routes from",
+		Default:     "http://127.0.0.1:6388",
+	}
 )
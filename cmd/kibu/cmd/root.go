@@ -18,6 +18,7 @@ type RootCmdParams struct {
 	BuildCmd   BuildCmd
 	MigrateCmd MigrateCmd
 	DevCmd     DevCmd
+	RoutesCmd  RoutesCmd
 }
 
 func NewRootCmd(params RootCmdParams) (root RootCmd) {
@@ -35,6 +36,7 @@ func NewRootCmd(params RootCmdParams) (root RootCmd) {
 	root.AddCommand(params.ConfigCmd.Command)
 	root.AddCommand(params.MigrateCmd.Command)
 	root.AddCommand(params.BuildCmd.Command)
+	root.AddCommand(params.RoutesCmd.Command)
 
 	return
 }
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/kibu-sh/kibu/cmd/kibu/cmd/cliflags"
+	"github.com/kibu-sh/kibu/pkg/appcontext"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/kibu-sh/kibu/pkg/transport/httpx"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"io"
+	"net/http"
+	"strings"
+	"text/tabwriter"
+)
+
+type RoutesCmd struct {
+	*cobra.Command
+}
+
+func NewRoutesCmd() (cmd RoutesCmd) {
+	cmd.Command = &cobra.Command{
+		Use:   "routes",
+		Short: "list the operations served by a running binary",
+		Long:  `list the operations registered by generated controllers, as reported by the admin server of a running binary at --addr`,
+		RunE:  newRoutesRunE(),
+	}
+	_ = cliflags.RoutesAddr.BindToCommand(cmd.Command)
+	return
+}
+
+func newRoutesRunE() RunE {
+	return func(cmd *cobra.Command, args []string) (err error) {
+		services, err := fetchRoutes(strings.TrimSuffix(cliflags.RoutesAddr.Value(), "/") + httpx.RoutesPath)
+		if err != nil {
+			return
+		}
+
+		return printRoutes(cmd.OutOrStdout(), services)
+	}
+}
+
+func fetchRoutes(url string) (services []transport.ServiceInfo, err error) {
+	req, err := http.NewRequestWithContext(appcontext.Context(), http.MethodGet, url, nil)
+	if err != nil {
+		return
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		err = errors.Wrapf(err, "failed to reach %s, is the server running?", url)
+		return
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		err = errors.Errorf("unexpected status %s from %s", res.Status, url)
+		return
+	}
+
+	err = json.NewDecoder(res.Body).Decode(&services)
+	return
+}
+
+func printRoutes(out io.Writer, services []transport.ServiceInfo) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "METHODS\tPATH\tOPERATION\tTRANSPORT\tREQUEST\tRESPONSE\tMIDDLEWARE\tPERMISSIONS")
+	for _, svc := range services {
+		for _, op := range svc.Operations {
+			via := op.Transport
+			if op.Stream != "" {
+				via = fmt.Sprintf("%s+%s", via, op.Stream)
+			}
+
+			tags := append([]string{}, op.Tags...)
+			if op.Public {
+				tags = append(tags, "public")
+			}
+
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				orDash(strings.Join(op.Methods, ",")),
+				orDash(op.Path),
+				op.ID,
+				orDash(via),
+				orDash(op.Request),
+				orDash(op.Response),
+				orDash(strings.Join(tags, ",")),
+				orDash(strings.Join(op.Permissions, ",")),
+			)
+		}
+	}
+	return w.Flush()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -56,9 +56,19 @@ func InitCLI() (RootCmd, error) {
 		ConfigCopyCmd: configCopyCmd,
 	}
 	configCmd := NewConfigCmd(configCmdParams)
-	newBuildCmdParams := NewBuildCmdParams{
+	newBuildTypeScriptCmdParams := NewBuildTypeScriptCmdParams{
+		loadConfig: cmdConfigLoaderFunc,
+	}
+	buildTypeScriptCmd := NewBuildTypeScriptCmd(newBuildTypeScriptCmdParams)
+	newBuildOpenAPICmdParams := NewBuildOpenAPICmdParams{
 		loadConfig: cmdConfigLoaderFunc,
 	}
+	buildOpenAPICmd := NewBuildOpenAPICmd(newBuildOpenAPICmdParams)
+	newBuildCmdParams := NewBuildCmdParams{
+		loadConfig:         cmdConfigLoaderFunc,
+		BuildTypeScriptCmd: buildTypeScriptCmd,
+		BuildOpenAPICmd:    buildOpenAPICmd,
+	}
 	buildCmd := NewBuildCmd(newBuildCmdParams)
 	newMigrateUpCmdParams := NewMigrateUpCmdParams{}
 	migrateUpCmd := NewMigrateUpCmd(newMigrateUpCmdParams)
@@ -75,11 +85,13 @@ func InitCLI() (RootCmd, error) {
 		DevUpCmd: devUpCmd,
 	}
 	devCmd := NewDevCmd(devCmdParams)
+	routesCmd := NewRoutesCmd()
 	rootCmdParams := RootCmdParams{
 		ConfigCmd:  configCmd,
 		BuildCmd:   buildCmd,
 		MigrateCmd: migrateCmd,
 		DevCmd:     devCmd,
+		RoutesCmd:  routesCmd,
 	}
 	rootCmd := NewRootCmd(rootCmdParams)
 	return rootCmd, nil
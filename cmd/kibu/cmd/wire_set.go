@@ -27,6 +27,8 @@ var wireSet = wire.NewSet(
 	NewDevCmd,
 	NewDevUpCmd,
 	NewBuildCmd,
+	NewBuildTypeScriptCmd,
+	NewBuildOpenAPICmd,
 	NewConfigCmd,
 	NewConfigGetCmd,
 	NewConfigSetCmd,
@@ -36,11 +38,14 @@ var wireSet = wire.NewSet(
 	NewMigrateCmd,
 	NewMigrateUpCmd,
 	NewMigrateDownCmd,
+	NewRoutesCmd,
 
 	wire.Struct(new(RootCmdParams), "*"),
 	wire.Struct(new(DevCmdParams), "*"),
 	wire.Struct(new(ConfigCmdParams), "*"),
 	wire.Struct(new(NewBuildCmdParams), "*"),
+	wire.Struct(new(NewBuildTypeScriptCmdParams), "*"),
+	wire.Struct(new(NewBuildOpenAPICmdParams), "*"),
 	wire.Struct(new(NewDevUpCmdParams), "*"),
 	wire.Struct(new(NewConfigGetCmdParams), "*"),
 	wire.Struct(new(NewConfigSetCmdParams), "*"),
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"github.com/dave/jennifer/jen"
 	"github.com/kibu-sh/kibu/internal/toolchain/kibumod"
+	"github.com/kibu-sh/kibu/internal/toolchain/kiburoutes"
 	"github.com/kibu-sh/kibu/internal/toolchain/modspecv2"
 	"golang.org/x/tools/go/analysis"
 	"reflect"
@@ -22,7 +23,7 @@ var Analyzer = &analysis.Analyzer{
 	Run:              run,
 	ResultType:       resultType,
 	RunDespiteErrors: true,
-	Requires:         []*analysis.Analyzer{kibumod.Analyzer},
+	Requires:         []*analysis.Analyzer{kibumod.Analyzer, kiburoutes.Analyzer},
 }
 
 var missingPackageError = errors.New("missing result of kibumod analyzer")
@@ -37,6 +38,12 @@ func run(pass *analysis.Pass) (any, error) {
 		return nil, nil
 	}
 
+	for _, endpoint := range modspecv2.HTTPEndpoints(pkg, pass.TypesInfo) {
+		for _, diagnostic := range modspecv2.ValidateHTTPEndpoint(endpoint) {
+			pass.Report(diagnostic)
+		}
+	}
+
 	genFile := modspecv2.NewJenFileFromPackage(pass.Pkg)
 	result := modspecv2.NewPackageArtifact(genFile, pass, "")
 
@@ -50,6 +57,9 @@ func run(pass *analysis.Pass) (any, error) {
 		buildWorkflowControllers,
 		buildActivitiesControllers,
 		buildServiceControllers,
+		buildServiceHTTPClients,
+		buildServiceGRPCControllers,
+		buildServiceGRPCClients,
 		buildWorkerController,
 	)
 
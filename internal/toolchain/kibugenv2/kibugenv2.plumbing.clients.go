@@ -0,0 +1,209 @@
+package kibugenv2
+
+import (
+	"github.com/dave/jennifer/jen"
+	"github.com/kibu-sh/kibu/internal/toolchain/modspecv2"
+	"go/ast"
+)
+
+// buildServiceHTTPClients generates a client for every //kibu:service that implements the service interface over HTTP
+// this allows the same interface to be satisfied by a local implementation or a remote one
+//
+//	type ServiceHTTPClient struct {
+//		client *request.Client
+//	}
+func buildServiceHTTPClients(f *jen.File, pkg *modspecv2.Package) {
+	for _, svc := range pkg.Services {
+		if !svc.Decorators.Some(isKibuService) {
+			continue
+		}
+
+		name := suffixHTTPClient(svc.Name)
+		f.Commentf("%s implements %s by calling its endpoints over HTTP", name, svc.Name)
+		f.Type().Id(name).Struct(
+			jen.Id("client").Op("*").Qual(kibuRequestImportName, "Client"),
+		)
+
+		f.Commentf("New%s returns a client for the service hosted at the base URL of client", name)
+		f.Commentf("errors returned by the service are decoded as httpx.DefaultJSONError")
+		f.Func().Id("New" + name).
+			Params(jen.Id("client").Op("*").Qual(kibuRequestImportName, "Client")).
+			Params(jen.Op("*").Id(name)).
+			Block(
+				jen.Return(jen.Op("&").Id(name).Values(jen.Dict{
+					jen.Id("client"): jen.Id("client").Dot("WithErrorDecoder").Call(
+						jen.Qual(kibuRequestImportName, "JSONErrorDecoder").
+							Index(jen.Qual(kibuHttpxImportName, "DefaultJSONError")),
+					),
+				})),
+			)
+
+		for _, op := range svc.Operations {
+			if op == nil {
+				continue
+			}
+			if op.IsStream() {
+				f.Add(buildServiceHTTPClientStreamMethod(pkg, svc, op))
+				continue
+			}
+			if op.IsChannel() {
+				f.Add(buildServiceHTTPClientChannelMethod(pkg, svc, op))
+				continue
+			}
+			f.Add(buildServiceHTTPClientMethod(pkg, svc, op))
+		}
+	}
+}
+
+// buildServiceHTTPClientMethod generates a single operation of the client
+//
+//	func (c *ServiceHTTPClient) WatchAccount(ctx context.Context, req WatchAccountRequest) (res WatchAccountResponse, err error) {
+//		rc, err := httpx.NewClientRequest(c.client, "POST", "/billingv1/WatchAccount", req)
+//		if err != nil {
+//			return
+//		}
+//		err = rc.DoAsJSON(ctx, &res)
+//		return
+//	}
+//
+// operations declared with transport=ws make the call over a WebSocket with wsx.Call instead
+func buildServiceHTTPClientMethod(pkg *modspecv2.Package, svc *modspecv2.Service, op *modspecv2.Operation) jen.Code {
+	route := op.HTTPRoute(pkg)
+	req := paramAtIndex(op.Params, 1)
+
+	return jen.Func().Params(jen.Id("c").Op("*").Id(suffixHTTPClient(svc.Name))).Id(op.Name).
+		ParamsFunc(func(g *jen.Group) {
+			g.Add(namedStdContextParam())
+			if req.IsPresent() {
+				g.Id("req").Add(paramToExp(req))
+			}
+		}).
+		Params(
+			jen.Id("res").Add(paramToExp(paramAtIndex(op.Results, 0))),
+			jen.Id("err").Error(),
+		).
+		BlockFunc(func(g *jen.Group) {
+			g.List(jen.Id("rc"), jen.Id("err")).Op(":=").Qual(kibuHttpxImportName, "NewClientRequest").CallFunc(func(g *jen.Group) {
+				g.Id("c").Dot("client")
+				g.Lit(route.Method())
+				g.Lit(route.Path)
+				if req.IsPresent() {
+					g.Id("req")
+				} else {
+					g.Nil()
+				}
+			})
+			g.If(jen.Err().Op("!=").Nil()).Block(jen.Return())
+			if op.IsWebSocket() {
+				g.Err().Op("=").Qual(kibuWsxImportName, "Call").Call(jen.Id("ctx"), jen.Id("rc"), jen.Id("req"), jen.Op("&").Id("res"))
+			} else {
+				g.Err().Op("=").Id("rc").Dot("DoAsJSON").Call(jen.Id("ctx"), jen.Op("&").Id("res"))
+			}
+			g.Return()
+		})
+}
+
+// buildServiceHTTPClientStreamMethod generates a //kibu:service:method stream=sse operation of the client
+// events received from the server are forwarded to the sink supplied by the caller
+//
+//	func (c *ServiceHTTPClient) WatchEvents(ctx context.Context, req WatchEventsRequest, sink transport.StreamSink[AccountEvent]) (err error) {
+//		rc, err := httpx.NewClientRequest(c.client, "GET", "/billingv1/WatchEvents", req)
+//		if err != nil {
+//			return
+//		}
+//		err = httpx.DoSSEStream(ctx, rc, sink)
+//		return
+//	}
+func buildServiceHTTPClientStreamMethod(pkg *modspecv2.Package, svc *modspecv2.Service, op *modspecv2.Operation) jen.Code {
+	route := op.HTTPRoute(pkg)
+	req := paramAtIndex(op.Params, 1)
+
+	return jen.Func().Params(jen.Id("c").Op("*").Id(suffixHTTPClient(svc.Name))).Id(op.Name).
+		Params(
+			namedStdContextParam(),
+			jen.Id("req").Add(paramToExp(req)),
+			jen.Id("sink").Add(streamSinkParamToExp(paramAtIndex(op.Params, 2))),
+		).
+		Params(jen.Id("err").Error()).
+		BlockFunc(func(g *jen.Group) {
+			g.List(jen.Id("rc"), jen.Id("err")).Op(":=").Qual(kibuHttpxImportName, "NewClientRequest").Call(
+				jen.Id("c").Dot("client"),
+				jen.Lit(route.Method()),
+				jen.Lit(route.Path),
+				jen.Id("req"),
+			)
+			g.If(jen.Err().Op("!=").Nil()).Block(jen.Return())
+			g.Err().Op("=").Qual(kibuHttpxImportName, "DoSSEStream").Call(jen.Id("ctx"), jen.Id("rc"), jen.Id("sink"))
+			g.Return()
+		})
+}
+
+// streamSinkParamToExp rebuilds the transport.StreamSink parameter of a stream operation
+// the sink is always qualified with the transport import path regardless of how the spec file aliased it
+//
+//	sink transport.StreamSink[AccountEvent] → transport.StreamSink[AccountEvent]
+func streamSinkParamToExp(param optionalParam) jen.Code {
+	sink := jen.Qual(kibuTransportImportName, "StreamSink")
+	if param.IsAbsent() {
+		return sink.Types(jen.Any())
+	}
+
+	index, ok := param.MustGet().Field.Type.(*ast.IndexExpr)
+	if !ok {
+		return sink.Types(jen.Any())
+	}
+	return sink.Types(exprToJen(index.Index))
+}
+
+// buildServiceHTTPClientChannelMethod generates a //kibu:service:method transport=ws operation that accepts a transport.Channel
+// messages the caller's channel yields from Receive are sent to the server
+// and messages sent by the server are passed to its Send until either side closes
+//
+//	func (c *ServiceHTTPClient) Chat(ctx context.Context, req ChatRequest, ch transport.Channel[ChatMessage, ChatReply]) (err error) {
+//		rc, err := httpx.NewClientRequest(c.client, "GET", "/chatv1/Chat", req)
+//		if err != nil {
+//			return
+//		}
+//		err = httpx.DoWebSocket(ctx, rc, ch)
+//		return
+//	}
+func buildServiceHTTPClientChannelMethod(pkg *modspecv2.Package, svc *modspecv2.Service, op *modspecv2.Operation) jen.Code {
+	route := op.HTTPRoute(pkg)
+	req := paramAtIndex(op.Params, 1)
+
+	return jen.Func().Params(jen.Id("c").Op("*").Id(suffixHTTPClient(svc.Name))).Id(op.Name).
+		Params(
+			namedStdContextParam(),
+			jen.Id("req").Add(paramToExp(req)),
+			jen.Id("ch").Add(channelParamToExp(paramAtIndex(op.Params, 2))),
+		).
+		Params(jen.Id("err").Error()).
+		BlockFunc(func(g *jen.Group) {
+			g.List(jen.Id("rc"), jen.Id("err")).Op(":=").Qual(kibuHttpxImportName, "NewClientRequest").Call(
+				jen.Id("c").Dot("client"),
+				jen.Lit(route.Method()),
+				jen.Lit(route.Path),
+				jen.Id("req"),
+			)
+			g.If(jen.Err().Op("!=").Nil()).Block(jen.Return())
+			g.Err().Op("=").Qual(kibuHttpxImportName, "DoWebSocket").Call(jen.Id("ctx"), jen.Id("rc"), jen.Id("ch"))
+			g.Return()
+		})
+}
+
+// channelParamToExp rebuilds the transport.Channel parameter of a channel operation
+// the channel is always qualified with the transport import path regardless of how the spec file aliased it
+//
+//	ch transport.Channel[ChatMessage, ChatReply] → transport.Channel[ChatMessage, ChatReply]
+func channelParamToExp(param optionalParam) jen.Code {
+	channel := jen.Qual(kibuTransportImportName, "Channel")
+	if param.IsAbsent() {
+		return channel.Types(jen.Any(), jen.Any())
+	}
+
+	index, ok := param.MustGet().Field.Type.(*ast.IndexListExpr)
+	if !ok || len(index.Indices) != 2 {
+		return channel.Types(jen.Any(), jen.Any())
+	}
+	return channel.Types(exprToJen(index.Indices[0]), exprToJen(index.Indices[1]))
+}
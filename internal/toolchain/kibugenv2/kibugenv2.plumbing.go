@@ -8,7 +8,6 @@ import (
 	"github.com/samber/lo"
 	"github.com/samber/mo"
 	"go/ast"
-	"net/http"
 	"unicode"
 )
 
@@ -27,7 +26,12 @@ const (
 	kibuTransportImportName    = "github.com/kibu-sh/kibu/pkg/transport"
 	kibuTemporalImportName     = "github.com/kibu-sh/kibu/pkg/transport/temporal"
 	kibuHttpxImportName        = "github.com/kibu-sh/kibu/pkg/transport/httpx"
+	kibuGrpcxImportName        = "github.com/kibu-sh/kibu/pkg/transport/grpcx"
 	kibuMiddlewareImportName   = "github.com/kibu-sh/kibu/pkg/transport/middleware"
+	kibuRequestImportName      = "github.com/kibu-sh/kibu/pkg/request"
+	kibuValidationImportName   = "github.com/kibu-sh/kibu/pkg/transport/validation"
+	kibuWsxImportName          = "github.com/kibu-sh/kibu/pkg/transport/wsx"
+	grpcImportName             = "google.golang.org/grpc"
 	temporalActivityImportName = "go.temporal.io/sdk/activity"
 	temporalClientImportName   = "go.temporal.io/sdk/client"
 	temporalWorkerImportName   = "go.temporal.io/sdk/worker"
@@ -128,6 +132,16 @@ func buildPkgCompilerAssertions(f *jen.File, pkg *modspecv2.Package) {
 			f.Add(compilerAssertionToInterface(
 				suffixClient(svc.Name), firstToLower(suffixClient(svc.Name))))
 		}
+
+		if svc.Decorators.Some(isKibuService) {
+			f.Add(compilerAssertionToInterface(
+				svc.Name, suffixHTTPClient(svc.Name)))
+		}
+
+		if svc.IsGRPCService() {
+			f.Add(compilerAssertionToInterface(
+				svc.Name, suffixGRPCClient(svc.Name)))
+		}
 	}
 	return
 }
@@ -141,11 +155,11 @@ func operationConstName(svc *modspecv2.Service, op *modspecv2.Operation) string
 }
 
 func svcConstLiteral(pkg *modspecv2.Package, svc *modspecv2.Service) string {
-	return fmt.Sprintf("%s.%s", pkg.Name, svc.Name)
+	return modspecv2.ServiceID(pkg, svc)
 }
 
 func operationConstLiteral(pkg *modspecv2.Package, svc *modspecv2.Service, op *modspecv2.Operation) string {
-	return fmt.Sprintf("%s.%s.%s", pkg.Name, svc.Name, op.Name)
+	return modspecv2.OperationID(pkg, svc, op)
 }
 
 // buildPkgConstants a set of constant references for later code
@@ -415,49 +429,6 @@ func compilerAssertionToInterface(iface, impl string) *jen.Statement {
 		Params(ptrExpr(impl)).
 		Parens(jen.Nil())
 }
-func buildServiceControllers(f *jen.File, pkg *modspecv2.Package) {
-	for _, svc := range pkg.Services {
-		if !svc.Decorators.Some(isKibuService) {
-			continue
-		}
-
-		f.Comment("//kibu:provider group=HandlerFactory import=github.com/kibu-sh/kibu/pkg/transport/httpx")
-		f.Type().Id(suffixController(svc.Name)).Struct(
-			jen.Id("Service").Id(svc.Name),
-		)
-
-		f.Func().Params(
-			jen.Id("svc").Op("*").Id(suffixController(svc.Name)),
-		).Id("HTTPHandlerFactory").Params(jen.Id("_").Op("*").Qual(kibuMiddlewareImportName, "Registry")).Params(
-			jen.Index().Op("*").Qual(kibuHttpxImportName, "Handler"),
-		).BlockFunc(func(g *jen.Group) {
-			g.ReturnFunc(func(g *jen.Group) {
-				g.Index().Op("*").Qual(kibuHttpxImportName, "Handler").CustomFunc(modspecv2.MultiLineCurly(), func(g *jen.Group) {
-					for _, op := range svc.Operations {
-						methodDecorator, _ := op.Decorators.Find(isKibuServiceMethod)
-
-						// TODO: warn on analysis pass that there's a duplicate path detected
-						// 	this is due to multiple Service interfaces defined in the same Package
-						path, _ := methodDecorator.Options.GetOne("path",
-							fmt.Sprintf("/%s/%s", pkg.Name, op.Name))
-
-						// TODO: support more than one method per service call
-						//  although this usually should be POST since JSON serialization will be most common
-						method, _ := methodDecorator.Options.GetOne("method",
-							http.MethodPost)
-
-						g.Id("httpx").Dot("NewHandler").
-							Call(jen.Lit(path),
-								jen.Qual(kibuTransportImportName, "NewEndpoint").
-									Call(jen.Id("svc").Dot("Service").Dot(op.Name)),
-							).Dot("WithMethods").Call(jen.Lit(method))
-
-					}
-				})
-			})
-		})
-	}
-}
 
 func buildActivitiesControllers(f *jen.File, pkg *modspecv2.Package) {
 	for _, svc := range pkg.Services {
@@ -487,3 +458,15 @@ func buildActivitiesControllers(f *jen.File, pkg *modspecv2.Package) {
 		})
 	}
 }
+
+func suffixHTTPClient(name string) string {
+	return firstToUpper(fmt.Sprintf("%sHTTPClient", name))
+}
+
+func suffixGRPCClient(name string) string {
+	return firstToUpper(fmt.Sprintf("%sGRPCClient", name))
+}
+
+func suffixGRPCController(name string) string {
+	return firstToUpper(fmt.Sprintf("%sGRPCController", name))
+}
@@ -0,0 +1,149 @@
+package kibugenv2
+
+import (
+	"github.com/dave/jennifer/jen"
+	"github.com/kibu-sh/kibu/internal/toolchain/modspecv2"
+)
+
+// buildServiceGRPCControllers generates a controller serving the unary operations of a //kibu:service grpc
+// operations are adapted from the same endpoints and middleware as their HTTP handlers
+//
+//	//kibu:provider group=ServiceFactory import=github.com/kibu-sh/kibu/pkg/transport/grpcx
+//	type ServiceGRPCController struct {
+//		Service Service
+//	}
+func buildServiceGRPCControllers(f *jen.File, pkg *modspecv2.Package) {
+	for _, svc := range pkg.Services {
+		if !svc.IsGRPCService() {
+			continue
+		}
+
+		name := suffixGRPCController(svc.Name)
+		f.Comment("//kibu:provider group=ServiceFactory import=github.com/kibu-sh/kibu/pkg/transport/grpcx")
+		f.Type().Id(name).Struct(controllerFields(svc)...)
+
+		f.Func().Params(
+			jen.Id("svc").Op("*").Id(name),
+		).Id("GRPCServiceFactory").Params(jen.Id("middlewareReg").Op("*").Qual(kibuMiddlewareImportName, "Registry")).Params(
+			jen.Op("*").Qual(kibuGrpcxImportName, "Service"),
+		).BlockFunc(func(g *jen.Group) {
+			g.Return(jen.Qual(kibuGrpcxImportName, "NewService").CustomFunc(modspecv2.MultiLineParen(), func(g *jen.Group) {
+				g.Id(svcConstName(svc))
+				for _, op := range svc.Operations {
+					if op == nil || !op.IsUnary() {
+						continue
+					}
+
+					endpoint := jen.Qual(kibuTransportImportName, "NewEndpoint").
+						Call(jen.Id("svc").Dot("Service").Dot(op.Name)).
+						Dot("WithValidator").Call(jen.Qual(kibuValidationImportName, "Default"))
+
+					endpoint = withEndpointOptions(endpoint, svc, op)
+
+					g.Qual(kibuGrpcxImportName, "NewMethod").Call(
+						jen.Lit(op.Name),
+						endpoint.Dot("WithMiddleware").CustomFunc(modspecv2.MultiLineParen(), func(g *jen.Group) {
+							g.Add(middlewareRegistryGet(svc, op)).Op("...")
+						}),
+					)
+				}
+			}))
+		})
+	}
+}
+
+// buildServiceGRPCClients generates a client for every //kibu:service grpc that implements the service interface over gRPC
+// operations that can't be served over gRPC return an error instead of calling the service
+//
+//	type ServiceGRPCClient struct {
+//		conn grpc.ClientConnInterface
+//	}
+func buildServiceGRPCClients(f *jen.File, pkg *modspecv2.Package) {
+	for _, svc := range pkg.Services {
+		if !svc.IsGRPCService() {
+			continue
+		}
+
+		name := suffixGRPCClient(svc.Name)
+		f.Commentf("%s implements %s by calling its methods over gRPC", name, svc.Name)
+		f.Type().Id(name).Struct(
+			jen.Id("conn").Qual(grpcImportName, "ClientConnInterface"),
+		)
+
+		f.Commentf("New%s returns a client for the service served by the gRPC server at the other end of conn", name)
+		f.Commentf("errors returned by the service are decoded as httpx.DefaultJSONError")
+		f.Func().Id("New" + name).
+			Params(jen.Id("conn").Qual(grpcImportName, "ClientConnInterface")).
+			Params(jen.Op("*").Id(name)).
+			Block(
+				jen.Return(jen.Op("&").Id(name).Values(jen.Dict{
+					jen.Id("conn"): jen.Id("conn"),
+				})),
+			)
+
+		for _, op := range svc.Operations {
+			if op == nil {
+				continue
+			}
+			f.Add(buildServiceGRPCClientMethod(pkg, svc, op))
+		}
+	}
+}
+
+// buildServiceGRPCClientMethod generates a single operation of the client
+//
+//	func (c *ServiceGRPCClient) GetAccount(ctx context.Context, req GetAccountRequest) (res Account, err error) {
+//		err = grpcx.Invoke(ctx, c.conn, "/billingv1.Service/GetAccount", req, &res)
+//		return
+//	}
+//
+// stream and channel operations return transport.ErrStreamingNotSupported and transport.ErrChannelsNotSupported
+func buildServiceGRPCClientMethod(pkg *modspecv2.Package, svc *modspecv2.Service, op *modspecv2.Operation) jen.Code {
+	req := paramAtIndex(op.Params, 1)
+	method := jen.Func().Params(jen.Id("c").Op("*").Id(suffixGRPCClient(svc.Name))).Id(op.Name)
+
+	switch {
+	case op.IsStream():
+		return method.Params(
+			namedStdContextParam(),
+			jen.Id("req").Add(paramToExp(req)),
+			jen.Id("sink").Add(streamSinkParamToExp(paramAtIndex(op.Params, 2))),
+		).Params(jen.Id("err").Error()).Block(
+			jen.Return(jen.Qual(kibuTransportImportName, "ErrStreamingNotSupported")),
+		)
+	case op.IsChannel():
+		return method.Params(
+			namedStdContextParam(),
+			jen.Id("req").Add(paramToExp(req)),
+			jen.Id("ch").Add(channelParamToExp(paramAtIndex(op.Params, 2))),
+		).Params(jen.Id("err").Error()).Block(
+			jen.Return(jen.Qual(kibuTransportImportName, "ErrChannelsNotSupported")),
+		)
+	}
+
+	return method.
+		ParamsFunc(func(g *jen.Group) {
+			g.Add(namedStdContextParam())
+			if req.IsPresent() {
+				g.Id("req").Add(paramToExp(req))
+			}
+		}).
+		Params(
+			jen.Id("res").Add(paramToExp(paramAtIndex(op.Results, 0))),
+			jen.Id("err").Error(),
+		).
+		BlockFunc(func(g *jen.Group) {
+			g.Err().Op("=").Qual(kibuGrpcxImportName, "Invoke").CallFunc(func(g *jen.Group) {
+				g.Id("ctx")
+				g.Id("c").Dot("conn")
+				g.Lit(modspecv2.GRPCMethod(pkg, svc, op))
+				if req.IsPresent() {
+					g.Id("req")
+				} else {
+					g.Nil()
+				}
+				g.Op("&").Id("res")
+			})
+			g.Return()
+		})
+}
@@ -0,0 +1,351 @@
+package kibugenv2
+
+import (
+	"github.com/dave/jennifer/jen"
+	"github.com/kibu-sh/kibu/internal/toolchain/kibugenv2/decorators"
+	"github.com/kibu-sh/kibu/internal/toolchain/modspecv2"
+	"github.com/samber/lo"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+	"time"
+)
+
+const (
+	middlewareOptionKey = "middleware"
+	publicOptionKey     = "public"
+)
+
+// middlewareParams mirrors middleware.GetParams for a single service operation
+type middlewareParams struct {
+	Tags        []string
+	ExcludeAuth bool
+}
+
+// publicOption reports whether the public option is enabled and whether it was declared at all
+//
+//	//kibu:service public        → true, true
+//	//kibu:service public=false  → false, true
+//	//kibu:service               → false, false
+func publicOption(opts *decorators.OptionList) (public bool, declared bool) {
+	if !opts.Has(publicOptionKey) {
+		return false, false
+	}
+	val, _ := opts.GetOne(publicOptionKey, "true")
+	return val != "false", true
+}
+
+// resolveMiddlewareParams merges the middleware options of a //kibu:service:method over its //kibu:service
+// tags are accumulated (service first), while a public option on the method overrides the service
+func resolveMiddlewareParams(svc *modspecv2.Service, op *modspecv2.Operation) (params middlewareParams) {
+	svcOpts := svc.ServiceOptions()
+	opOpts := op.ServiceMethodOptions()
+
+	svcTags, _ := svcOpts.GetAll(middlewareOptionKey, nil)
+	opTags, _ := opOpts.GetAll(middlewareOptionKey, nil)
+	params.Tags = lo.Uniq(lo.Compact(append(append([]string{}, svcTags...), opTags...)))
+
+	params.ExcludeAuth, _ = publicOption(svcOpts)
+	if public, declared := publicOption(opOpts); declared {
+		params.ExcludeAuth = public
+	}
+	return
+}
+
+// middlewareRegistryGet builds a call to the middleware registry for the given operation
+//
+//	middlewareReg.Get(middleware.GetParams{ExcludeAuth: true, Tags: []string{"audit"}})
+func middlewareRegistryGet(svc *modspecv2.Service, op *modspecv2.Operation) jen.Code {
+	params := resolveMiddlewareParams(svc, op)
+	return jen.Id("middlewareReg").Dot("Get").Call(
+		jen.Qual(kibuMiddlewareImportName, "GetParams").Values(jen.DictFunc(func(d jen.Dict) {
+			d[jen.Id("ExcludeAuth")] = jen.Lit(params.ExcludeAuth)
+			if len(params.Tags) > 0 {
+				d[jen.Id("Tags")] = jen.Index().String().ValuesFunc(func(g *jen.Group) {
+					for _, tag := range params.Tags {
+						g.Lit(tag)
+					}
+				})
+			}
+		})),
+	)
+}
+
+func buildServiceControllers(f *jen.File, pkg *modspecv2.Package) {
+	for _, svc := range pkg.Services {
+		if !svc.Decorators.Some(isKibuService) {
+			continue
+		}
+
+		f.Comment("//kibu:provider group=HandlerFactory import=github.com/kibu-sh/kibu/pkg/transport/httpx")
+		f.Type().Id(suffixController(svc.Name)).Struct(controllerFields(svc)...)
+
+		f.Func().Params(
+			jen.Id("svc").Op("*").Id(suffixController(svc.Name)),
+		).Id("HTTPHandlerFactory").Params(jen.Id("middlewareReg").Op("*").Qual(kibuMiddlewareImportName, "Registry")).Params(
+			jen.Index().Op("*").Qual(kibuHttpxImportName, "Handler"),
+		).BlockFunc(func(g *jen.Group) {
+			g.ReturnFunc(func(g *jen.Group) {
+				g.Index().Op("*").Qual(kibuHttpxImportName, "Handler").CustomFunc(modspecv2.MultiLineCurly(), func(g *jen.Group) {
+					for _, op := range svc.Operations {
+						// duplicate routes are reported by the kiburoutes analyzer
+						route := op.HTTPRoute(pkg)
+
+						handler := jen.Id("httpx").Dot("NewHandler").
+							Call(jen.Lit(route.Path), endpointHandler(svc, op)).Dot("WithMethods").CallFunc(func(g *jen.Group) {
+							for _, method := range route.Methods {
+								g.Lit(method)
+							}
+						}).Dot("WithOperation").Call(jen.Id(operationConstName(svc, op)))
+
+						// malformed limits are reported by modspecv2.ValidateHTTPEndpoint
+						if limits, _ := modspecv2.ResolveHTTPLimits(svc, op); !limits.IsZero() {
+							handler = handler.Dot("WithLimits").Call(httpxLimits(limits))
+						}
+						g.Add(handler)
+					}
+				})
+			})
+		})
+
+		f.Func().Params(
+			jen.Id("svc").Op("*").Id(suffixController(svc.Name)),
+		).Id("RegisterService").Params(
+			jen.Id("reg").Qual(kibuTransportImportName, "Registry"),
+			jen.Id("middlewareReg").Op("*").Qual(kibuMiddlewareImportName, "Registry"),
+		).BlockFunc(func(g *jen.Group) {
+			g.Id("service").Op(":=").Qual(kibuTransportImportName, "NewService").Call(jen.Id(svcConstName(svc)))
+			g.Id("service").Dot("WithOperations").CustomFunc(modspecv2.MultiLineParen(), func(g *jen.Group) {
+				for _, op := range svc.Operations {
+					g.Qual(kibuTransportImportName, "NewOperationWithInfo").Call(
+						operationInfo(pkg, svc, op),
+						endpointHandler(svc, op),
+					)
+				}
+			})
+			g.Id("service").Dot("Register").Call(jen.Id("reg"))
+		})
+	}
+}
+
+// httpxLimits builds the httpx.Limits of an operation
+//
+//	httpx.Limits{MaxBodyBytes: 1048576, Timeout: 5 * time.Second}
+func httpxLimits(limits modspecv2.HTTPLimits) jen.Code {
+	return jen.Qual(kibuHttpxImportName, "Limits").Values(jen.DictFunc(func(d jen.Dict) {
+		if limits.Timeout > 0 {
+			d[jen.Id("Timeout")] = durationExpr(limits.Timeout)
+		}
+		if limits.MaxBodyBytes > 0 {
+			d[jen.Id("MaxBodyBytes")] = jen.Lit(int(limits.MaxBodyBytes))
+		}
+		if limits.MaxHeaderBytes > 0 {
+			d[jen.Id("MaxHeaderBytes")] = jen.Lit(int(limits.MaxHeaderBytes))
+		}
+	}))
+}
+
+// durationExpr prints a duration in the largest unit that represents it exactly
+//
+//	90s → 90 * time.Second
+//	1500ms → 1500 * time.Millisecond
+func durationExpr(d time.Duration) jen.Code {
+	units := []struct {
+		name string
+		unit time.Duration
+	}{
+		{"Hour", time.Hour},
+		{"Minute", time.Minute},
+		{"Second", time.Second},
+		{"Millisecond", time.Millisecond},
+		{"Microsecond", time.Microsecond},
+	}
+
+	for _, u := range units {
+		if d%u.unit == 0 {
+			return jen.Lit(int(d/u.unit)).Op("*").Qual(timeImportName, u.name)
+		}
+	}
+	return jen.Qual(timeImportName, "Duration").Call(jen.Lit(int64(d)))
+}
+
+// controllerFields are the fields of the controllers of svc, wire injects all of them
+// the Authorizer is only required by services with an operation that declares a permission,
+// and Idempotency by services with an idempotent operation
+//
+//	Service     Service
+//	Authorizer  transport.Authorizer
+//	Idempotency transport.Idempotency
+func controllerFields(svc *modspecv2.Service) []jen.Code {
+	fields := []jen.Code{jen.Id("Service").Id(svc.Name)}
+	if someOperation(svc, hasPermissions) {
+		fields = append(fields, jen.Id("Authorizer").Qual(kibuTransportImportName, "Authorizer"))
+	}
+	if someOperation(svc, isIdempotent) {
+		fields = append(fields, jen.Id("Idempotency").Qual(kibuTransportImportName, "Idempotency"))
+	}
+	return fields
+}
+
+// someOperation reports whether any operation of svc matches
+func someOperation(svc *modspecv2.Service, match func(svc *modspecv2.Service, op *modspecv2.Operation) bool) bool {
+	for _, op := range svc.Operations {
+		if op != nil && match(svc, op) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasPermissions(svc *modspecv2.Service, op *modspecv2.Operation) bool {
+	return len(modspecv2.ResolvePermissions(svc, op)) > 0
+}
+
+// isIdempotent reports whether responses of op are replayed, only unary HTTP operations can be
+func isIdempotent(svc *modspecv2.Service, op *modspecv2.Operation) bool {
+	return op.IsUnary() && !op.IsWebSocket() && modspecv2.ResolveIdempotent(svc, op)
+}
+
+// withEndpointOptions applies the decorator options of an operation that are checked by the endpoint itself
+//
+//	transport.NewEndpoint(svc.Service.CreateInvoice).WithAuthorization(...).WithIdempotency(svc.Idempotency)
+func withEndpointOptions(endpoint *jen.Statement, svc *modspecv2.Service, op *modspecv2.Operation) *jen.Statement {
+	if permissions := modspecv2.ResolvePermissions(svc, op); len(permissions) > 0 {
+		endpoint = endpoint.Dot("WithAuthorization").Call(endpointAuthorization(svc, op, permissions))
+	}
+	if isIdempotent(svc, op) {
+		endpoint = endpoint.Dot("WithIdempotency").Call(jen.Id("svc").Dot("Idempotency"))
+	}
+	return endpoint
+}
+
+// endpointAuthorization checks the permissions of an operation with the authorizer injected into the controller
+//
+//	transport.NewAuthorization(svc.Authorizer, serviceGetAccountName, "billing.read")
+func endpointAuthorization(svc *modspecv2.Service, op *modspecv2.Operation, permissions []string) jen.Code {
+	return jen.Qual(kibuTransportImportName, "NewAuthorization").CallFunc(func(g *jen.Group) {
+		g.Id("svc").Dot("Authorizer")
+		g.Id(operationConstName(svc, op))
+		for _, permission := range permissions {
+			g.Lit(permission)
+		}
+	})
+}
+
+// operationInfo builds the transport.OperationInfo registered for an operation
+//
+//	transport.OperationInfo{ID: serviceGetAccountName, Path: "/accounts/{id}", Methods: []string{"GET"}, ...}
+func operationInfo(pkg *modspecv2.Package, svc *modspecv2.Service, op *modspecv2.Operation) jen.Code {
+	route := op.HTTPRoute(pkg)
+	params := resolveMiddlewareParams(svc, op)
+	return jen.Qual(kibuTransportImportName, "OperationInfo").Values(jen.DictFunc(func(d jen.Dict) {
+		d[jen.Id("ID")] = jen.Id(operationConstName(svc, op))
+		d[jen.Id("Path")] = jen.Lit(route.Path)
+		d[jen.Id("Methods")] = jen.Index().String().ValuesFunc(func(g *jen.Group) {
+			for _, method := range route.Methods {
+				g.Lit(method)
+			}
+		})
+		d[jen.Id("Transport")] = jen.Lit(op.Transport())
+		if op.IsStream() {
+			d[jen.Id("Stream")] = jen.Lit(op.Stream())
+		}
+		if req := paramAtIndex(op.Params, 1); req.IsPresent() {
+			d[jen.Id("Request")] = jen.Lit(qualifiedTypeName(pkg, req.MustGet().Field.Type))
+		}
+		if res, ok := operationResponseParam(op); ok {
+			d[jen.Id("Response")] = jen.Lit(qualifiedTypeName(pkg, res.Field.Type))
+		}
+		if len(params.Tags) > 0 {
+			d[jen.Id("Tags")] = jen.Index().String().ValuesFunc(func(g *jen.Group) {
+				for _, tag := range params.Tags {
+					g.Lit(tag)
+				}
+			})
+		}
+		if params.ExcludeAuth {
+			d[jen.Id("Public")] = jen.True()
+		}
+		if permissions := modspecv2.ResolvePermissions(svc, op); len(permissions) > 0 {
+			d[jen.Id("Permissions")] = jen.Index().String().ValuesFunc(func(g *jen.Group) {
+				for _, permission := range permissions {
+					g.Lit(permission)
+				}
+			})
+		}
+	}))
+}
+
+// operationResponseParam returns what an operation sends back to its caller
+// streams and channels report their transport.StreamSink or transport.Channel parameter
+func operationResponseParam(op *modspecv2.Operation) (modspecv2.Type, bool) {
+	if !op.IsUnary() {
+		return paramAtIndex(op.Params, 2).Get()
+	}
+	if len(op.Results) < 2 {
+		return modspecv2.Type{}, false
+	}
+	return paramAtIndex(op.Results, 0).Get()
+}
+
+// qualifiedTypeName prints a type expression with the types of the package qualified by its name
+//
+//	GetAccountRequest → billingv1.GetAccountRequest
+//	transport.StreamSink[AccountEvent] → transport.StreamSink[billingv1.AccountEvent]
+func qualifiedTypeName(pkg *modspecv2.Package, expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if token.IsExported(e.Name) {
+			return pkg.Name + "." + e.Name
+		}
+	case *ast.StarExpr:
+		return "*" + qualifiedTypeName(pkg, e.X)
+	case *ast.ArrayType:
+		if e.Len != nil {
+			return "[" + types.ExprString(e.Len) + "]" + qualifiedTypeName(pkg, e.Elt)
+		}
+		return "[]" + qualifiedTypeName(pkg, e.Elt)
+	case *ast.MapType:
+		return "map[" + qualifiedTypeName(pkg, e.Key) + "]" + qualifiedTypeName(pkg, e.Value)
+	case *ast.IndexExpr:
+		return qualifiedTypeName(pkg, e.X) + "[" + qualifiedTypeName(pkg, e.Index) + "]"
+	case *ast.IndexListExpr:
+		args := lo.Map(e.Indices, func(arg ast.Expr, _ int) string {
+			return qualifiedTypeName(pkg, arg)
+		})
+		return qualifiedTypeName(pkg, e.X) + "[" + strings.Join(args, ", ") + "]"
+	}
+	return types.ExprString(expr)
+}
+
+// endpointHandler builds the transport handler matching the shape of an operation
+//
+//	//kibu:service:method stream=sse → transport.NewStreamEndpoint
+//	//kibu:service:method transport=ws with a transport.Channel → transport.NewChannelEndpoint
+//	//kibu:service:method transport=ws → wsx.NewHandler(transport.NewEndpoint)
+//
+// requests are validated by validation.Default and middleware of WebSocket operations runs once per connection
+func endpointHandler(svc *modspecv2.Service, op *modspecv2.Operation) jen.Code {
+	constructor := "NewEndpoint"
+	switch {
+	case op.IsStream():
+		constructor = "NewStreamEndpoint"
+	case op.IsChannel():
+		constructor = "NewChannelEndpoint"
+	}
+
+	endpoint := jen.Qual(kibuTransportImportName, constructor).
+		Call(jen.Id("svc").Dot("Service").Dot(op.Name)).
+		Dot("WithValidator").Call(jen.Qual(kibuValidationImportName, "Default"))
+
+	endpoint = withEndpointOptions(endpoint, svc, op)
+
+	if op.IsWebSocket() && !op.IsChannel() {
+		endpoint = jen.Qual(kibuWsxImportName, "NewHandler").Call(endpoint)
+	}
+
+	return endpoint.Dot("WithMiddleware").CustomFunc(modspecv2.MultiLineParen(), func(g *jen.Group) {
+		g.Add(middlewareRegistryGet(svc, op)).Op("...")
+	})
+}
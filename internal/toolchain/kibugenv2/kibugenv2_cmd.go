@@ -3,6 +3,7 @@ package kibugenv2
 import (
 	"errors"
 	"flag"
+	"fmt"
 	"github.com/kibu-sh/kibu/internal/toolchain/modspecv2"
 	"github.com/kibu-sh/kibu/internal/toolchain/pipeline"
 	"golang.org/x/tools/go/analysis"
@@ -24,7 +25,9 @@ func Main() (int, error) {
 		return 1, errors.Join(err, errors.New("failed to parse flags"))
 	}
 
+	store := pipeline.NewDiagnosticStore()
 	cfg := pipeline.ConfigDefaults().
+		WithFactStore(store).
 		WithDir(root).
 		WithPatterns(fset.Args()).
 		WithAnalyzers([]*analysis.Analyzer{Analyzer})
@@ -34,6 +37,13 @@ func Main() (int, error) {
 		return 1, errors.Join(err, errors.New("failed to run pipeline"))
 	}
 
+	if diagnostics := store.Diagnostics(); len(diagnostics) > 0 {
+		for _, diagnostic := range diagnostics {
+			fmt.Fprintln(os.Stderr, pipeline.FormatDiagnostic(pkgs[0].Fset, diagnostic))
+		}
+		return 1, errors.New("invalid kibu definitions")
+	}
+
 	artifacts := modspecv2.GatherResults[modspecv2.Artifact](results)
 	_, err = modspecv2.SaveArtifacts(pkgs[0].Module, artifacts)
 	if err != nil {
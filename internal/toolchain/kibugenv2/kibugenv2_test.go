@@ -1,11 +1,12 @@
 package kibugenv2
 
 import (
+	"fmt"
 	"github.com/kibu-sh/kibu/internal/toolchain/modspecv2"
 	"github.com/kibu-sh/kibu/internal/toolchain/pipeline"
+	"github.com/kibu-sh/kibu/internal/toolchain/testutil"
 	"github.com/rogpeppe/go-internal/testscript"
 	"golang.org/x/tools/go/analysis"
-	"path/filepath"
 	"testing"
 )
 
@@ -16,48 +17,41 @@ import (
 //	})
 //}
 
-func ResolveDir(t *testing.T, rel string) string {
-	t.Helper()
-	abs, err := filepath.Abs(rel)
-	if err != nil {
-		t.Fatal(err)
-	}
-	return abs
-}
-
 func TestGenerator(t *testing.T) {
-	testdata := ResolveDir(t, "testdata")
-	scripts := filepath.Join(testdata, "scripts")
-	//srcfiles := filepath.Join(testdata, "src")
-
-	testscript.Run(t, testscript.Params{
-		Dir: scripts,
-		//TestWork: true,
-		Setup: func(env *testscript.Env) error {
-			// inject application env
-			//env.Vars = append(env.Vars, getGoEnv(t)...)
-			//env.Setenv("GOWORK", "")
-			//env.Setenv("GOMOD", "")
-			//return os.CopyFS(env.WorkDir, os.DirFS(srcfiles))
-			return nil
-		},
-		Cmds: map[string]func(ts *testscript.TestScript, neg bool, args []string){
-			"kibugenv2": func(ts *testscript.TestScript, neg bool, args []string) {
-				root := args[0]
-				patterns := args[1:]
-
-				cfg := pipeline.ConfigDefaults().
-					WithDir(root).
-					WithPatterns(patterns).
-					WithAnalyzers([]*analysis.Analyzer{Analyzer})
-
-				results, pkgs, err := pipeline.Run(cfg)
-				ts.Check(err)
-
-				artifacts := modspecv2.GatherResults[modspecv2.Artifact](results)
-				_, err = modspecv2.SaveArtifacts(pkgs[0].Module, artifacts)
-				ts.Check(err)
-			},
+	testutil.RunScripts(t, map[string]testutil.Cmd{
+		"kibugenv2": func(ts *testscript.TestScript, neg bool, args []string) {
+			root := args[0]
+			patterns := args[1:]
+
+			store := pipeline.NewDiagnosticStore()
+			cfg := pipeline.ConfigDefaults().
+				WithFactStore(store).
+				WithDir(root).
+				WithPatterns(patterns).
+				WithAnalyzers([]*analysis.Analyzer{Analyzer})
+
+			results, pkgs, err := pipeline.Run(cfg)
+			ts.Check(err)
+
+			diagnostics := store.Diagnostics()
+			for _, diagnostic := range diagnostics {
+				_, _ = fmt.Fprintln(ts.Stdout(), pipeline.FormatDiagnostic(pkgs[0].Fset, diagnostic))
+			}
+
+			if neg {
+				if len(diagnostics) == 0 {
+					ts.Fatalf("expected diagnostics")
+				}
+				return
+			}
+
+			if len(diagnostics) > 0 {
+				ts.Fatalf("unexpected diagnostics")
+			}
+
+			artifacts := modspecv2.GatherResults[modspecv2.Artifact](results)
+			_, err = modspecv2.SaveArtifacts(pkgs[0].Module, artifacts)
+			ts.Check(err)
 		},
 	})
 }
@@ -0,0 +1,282 @@
+package kibuopenapi
+
+import (
+	"fmt"
+	"github.com/kibu-sh/kibu/internal/toolchain/modspecv2"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	base "github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+	"github.com/samber/lo"
+	"gopkg.in/yaml.v3"
+	"io"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	openAPIVersion    = "3.1.0"
+	jsonContentType   = "application/json"
+	streamContentType = "text/event-stream"
+	errorSchemaName   = "httpx.DefaultJSONError"
+	defaultAPIVersion = "0.0.0"
+)
+
+var _ modspecv2.Artifact = (*Module)(nil)
+
+// Module is a single OpenAPI document describing every //kibu:service in a go module
+type Module struct {
+	doc *v3.Document
+	out string
+}
+
+func (m *Module) Document() *v3.Document {
+	return m.doc
+}
+
+func (m *Module) Render(w io.Writer) error {
+	data, err := m.doc.Render()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func (m *Module) OutputPath() string {
+	return filepath.Join(m.out, "openapi/openapi.yaml")
+}
+
+// BuildModule merges the results of every analyzed package into a single document
+func BuildModule(outDir string, modulePath string, specs []*PackageSpec) *Module {
+	doc := &v3.Document{
+		Version: openAPIVersion,
+		Info: &base.Info{
+			Title:   modulePath,
+			Version: defaultAPIVersion,
+		},
+		Paths: &v3.Paths{
+			PathItems: orderedmap.New[string, *v3.PathItem](),
+		},
+		Components: &v3.Components{
+			Schemas: orderedmap.New[string, *base.SchemaProxy](),
+		},
+	}
+
+	docs := make(modspecv2.Docs)
+	var endpoints []*modspecv2.HTTPEndpoint
+	for _, spec := range specs {
+		endpoints = append(endpoints, spec.Endpoints...)
+		docs.Merge(spec.Docs)
+	}
+
+	sort.SliceStable(endpoints, func(i, j int) bool {
+		return endpoints[i].ID < endpoints[j].ID
+	})
+
+	sb := newSchemaBuilder(doc.Components.Schemas, docs)
+	doc.Components.Schemas.Set(errorSchemaName, errorSchema())
+
+	for _, endpoint := range endpoints {
+		addTag(doc, endpoint)
+		addOperation(doc, sb, endpoint)
+	}
+
+	return &Module{
+		doc: doc,
+		out: outDir,
+	}
+}
+
+// addTag groups operations by the service that declares them
+func addTag(doc *v3.Document, endpoint *modspecv2.HTTPEndpoint) {
+	name := modspecv2.ServiceID(endpoint.Package, endpoint.Service)
+	if lo.ContainsBy(doc.Tags, func(tag *base.Tag) bool { return tag.Name == name }) {
+		return
+	}
+	doc.Tags = append(doc.Tags, &base.Tag{
+		Name:        name,
+		Description: modspecv2.DocText(endpoint.Service.Doc),
+	})
+}
+
+// addOperation registers an operation for every method of an endpoint
+// the first method keeps the endpoint id, the others are suffixed to keep operation ids unique
+//
+//	billingv1.Service.GetAccount, billingv1.Service.GetAccount.HEAD
+func addOperation(doc *v3.Document, sb *schemaBuilder, endpoint *modspecv2.HTTPEndpoint) {
+	pathItem, ok := doc.Paths.PathItems.Get(endpoint.Route.Path)
+	if !ok {
+		pathItem = &v3.PathItem{}
+		doc.Paths.PathItems.Set(endpoint.Route.Path, pathItem)
+	}
+
+	for i, method := range endpoint.Route.Methods {
+		operationID := endpoint.ID
+		if i > 0 {
+			operationID = fmt.Sprintf("%s.%s", endpoint.ID, method)
+		}
+		setOperation(pathItem, method, buildOperation(sb, endpoint, method, operationID))
+	}
+}
+
+func buildOperation(sb *schemaBuilder, endpoint *modspecv2.HTTPEndpoint, method string, operationID string) *v3.Operation {
+	description := modspecv2.DocText(endpoint.Operation.Doc)
+	operation := &v3.Operation{
+		Tags:        []string{modspecv2.ServiceID(endpoint.Package, endpoint.Service)},
+		OperationId: operationID,
+		Summary:     summary(description),
+		Responses:   buildResponses(sb, endpoint),
+	}
+
+	// single line comments are fully described by the summary
+	if operation.Summary != description {
+		operation.Description = description
+	}
+
+	if endpoint.Request != nil {
+		operation.Parameters = sb.Parameters(endpoint.Request)
+		if hasBodyByMethod(method) && sb.HasBody(endpoint.Request) {
+			operation.RequestBody = &v3.RequestBody{
+				Required: lo.ToPtr(true),
+				Content:  jsonContent(sb.Schema(endpoint.Request)),
+			}
+		}
+	}
+	return operation
+}
+
+func setOperation(pathItem *v3.PathItem, method string, operation *v3.Operation) {
+	switch strings.ToUpper(method) {
+	case http.MethodGet:
+		pathItem.Get = operation
+	case http.MethodPut:
+		pathItem.Put = operation
+	case http.MethodPatch:
+		pathItem.Patch = operation
+	case http.MethodDelete:
+		pathItem.Delete = operation
+	case http.MethodHead:
+		pathItem.Head = operation
+	case http.MethodOptions:
+		pathItem.Options = operation
+	case http.MethodTrace:
+		pathItem.Trace = operation
+	default:
+		pathItem.Post = operation
+	}
+}
+
+func buildResponses(sb *schemaBuilder, endpoint *modspecv2.HTTPEndpoint) *v3.Responses {
+	responses := &v3.Responses{
+		Codes: orderedmap.New[string, *v3.Response](),
+		Default: &v3.Response{
+			Description: "Error",
+			Content:     jsonContent(base.CreateSchemaProxyRef("#/components/schemas/" + errorSchemaName)),
+		},
+	}
+
+	if endpoint.Transport == modspecv2.TransportWebSocket {
+		// messages exchanged after the upgrade can't be described by OpenAPI
+		responses.Codes.Set("101", &v3.Response{
+			Description: "Switching Protocols",
+		})
+		return responses
+	}
+
+	if endpoint.Response == nil {
+		responses.Codes.Set("204", &v3.Response{
+			Description: "No Content",
+		})
+		return responses
+	}
+
+	if endpoint.Stream != "" {
+		// every server-sent event carries a single JSON encoded event in its data field
+		responses.Codes.Set("200", &v3.Response{
+			Description: "Event Stream",
+			Content:     streamContent(sb.Schema(endpoint.Response)),
+		})
+		return responses
+	}
+
+	responses.Codes.Set("200", &v3.Response{
+		Description: "OK",
+		Content:     jsonContent(sb.Schema(endpoint.Response)),
+	})
+	return responses
+}
+
+// errorSchema describes httpx.DefaultJSONError, the body written by the default error encoder
+func errorSchema() *base.SchemaProxy {
+	props := orderedmap.New[string, *base.SchemaProxy]()
+	props.Set("message", base.CreateSchemaProxy(&base.Schema{Type: []string{"string"}}))
+	props.Set("status", base.CreateSchemaProxy(&base.Schema{Type: []string{"integer"}, Format: "int64"}))
+	props.Set("errors", base.CreateSchemaProxy(&base.Schema{
+		Type:  []string{"array"},
+		Items: &base.DynamicValue[*base.SchemaProxy, bool]{A: fieldErrorSchema()},
+	}))
+	return base.CreateSchemaProxy(&base.Schema{
+		Title:      "DefaultJSONError",
+		Type:       []string{"object"},
+		Properties: props,
+		Required:   []string{"message", "status"},
+	})
+}
+
+// fieldErrorSchema describes transport.FieldError, listed by binding (400) and validation (422) errors
+func fieldErrorSchema() *base.SchemaProxy {
+	props := orderedmap.New[string, *base.SchemaProxy]()
+	props.Set("field", base.CreateSchemaProxy(&base.Schema{Type: []string{"string"}}))
+	props.Set("location", base.CreateSchemaProxy(&base.Schema{
+		Type: []string{"string"},
+		Enum: lo.Map([]string{
+			transport.LocationPath,
+			transport.LocationQuery,
+			transport.LocationHeader,
+			transport.LocationCookie,
+			transport.LocationBody,
+		}, func(loc string, _ int) *yaml.Node {
+			return &yaml.Node{Kind: yaml.ScalarNode, Value: loc}
+		}),
+	}))
+	props.Set("reason", base.CreateSchemaProxy(&base.Schema{Type: []string{"string"}}))
+	return base.CreateSchemaProxy(&base.Schema{
+		Title:      "FieldError",
+		Type:       []string{"object"},
+		Properties: props,
+		Required:   []string{"reason"},
+	})
+}
+
+func jsonContent(schema *base.SchemaProxy) *orderedmap.Map[string, *v3.MediaType] {
+	content := orderedmap.New[string, *v3.MediaType]()
+	content.Set(jsonContentType, &v3.MediaType{
+		Schema: schema,
+	})
+	return content
+}
+
+func streamContent(schema *base.SchemaProxy) *orderedmap.Map[string, *v3.MediaType] {
+	content := orderedmap.New[string, *v3.MediaType]()
+	content.Set(streamContentType, &v3.MediaType{
+		Schema: schema,
+	})
+	return content
+}
+
+func hasBodyByMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		return true
+	}
+	return false
+}
+
+// summary returns the first line of a doc comment
+func summary(doc string) string {
+	line, _, _ := strings.Cut(doc, "\n")
+	return line
+}
@@ -0,0 +1,51 @@
+package kibuopenapi
+
+import (
+	"errors"
+	"github.com/kibu-sh/kibu/internal/toolchain/kibumod"
+	"github.com/kibu-sh/kibu/internal/toolchain/modspecv2"
+	"golang.org/x/tools/go/analysis"
+	"reflect"
+)
+
+// PackageSpec is the result of analyzing a single package
+// the specs of every package in a module are merged into a single document by BuildModule
+type PackageSpec struct {
+	Endpoints []*modspecv2.HTTPEndpoint
+	Docs      modspecv2.Docs
+}
+
+var resultType = reflect.TypeOf((*PackageSpec)(nil))
+
+func FromPass(pass *analysis.Pass) (*PackageSpec, bool) {
+	result, ok := pass.ResultOf[Analyzer].(*PackageSpec)
+	return result, ok
+}
+
+var Analyzer = &analysis.Analyzer{
+	Name:             "kibuopenapi",
+	Doc:              "Analyzes kibu service definitions and describes them as an OpenAPI document",
+	Run:              run,
+	ResultType:       resultType,
+	RunDespiteErrors: true,
+	Requires:         []*analysis.Analyzer{kibumod.Analyzer},
+}
+
+var missingPackageError = errors.New("missing result of kibumod analyzer")
+
+func run(pass *analysis.Pass) (any, error) {
+	pkg, ok := kibumod.FromPass(pass)
+	if !ok {
+		return nil, missingPackageError
+	}
+
+	endpoints := modspecv2.HTTPEndpoints(pkg, pass.TypesInfo)
+	if len(endpoints) == 0 {
+		return nil, nil
+	}
+
+	return &PackageSpec{
+		Endpoints: endpoints,
+		Docs:      modspecv2.CollectDocs(pass.Files),
+	}, nil
+}
@@ -0,0 +1,348 @@
+package kibuopenapi
+
+import (
+	"fmt"
+	"github.com/kibu-sh/kibu/internal/toolchain/modspecv2"
+	base "github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+	"github.com/samber/lo"
+	"go/constant"
+	"go/types"
+	"gopkg.in/yaml.v3"
+	"strings"
+)
+
+// wellKnownSchemas maps types that serialize to JSON primitives instead of their underlying Go type
+var wellKnownSchemas = map[string]func() *base.Schema{
+	"time.Time": func() *base.Schema {
+		return &base.Schema{Type: []string{"string"}, Format: "date-time"}
+	},
+	"time.Duration": func() *base.Schema {
+		return &base.Schema{Type: []string{"integer"}, Format: "int64"}
+	},
+	"encoding/json.RawMessage": func() *base.Schema {
+		return &base.Schema{}
+	},
+	"github.com/google/uuid.UUID": func() *base.Schema {
+		return &base.Schema{Type: []string{"string"}, Format: "uuid"}
+	},
+	"github.com/google/uuid.NullUUID": func() *base.Schema {
+		return &base.Schema{Type: []string{"string", "null"}, Format: "uuid"}
+	},
+	"github.com/shopspring/decimal.Decimal": func() *base.Schema {
+		return &base.Schema{Type: []string{"string"}, Format: "decimal"}
+	},
+	"github.com/shopspring/decimal.NullDecimal": func() *base.Schema {
+		return &base.Schema{Type: []string{"string", "null"}, Format: "decimal"}
+	},
+}
+
+// schemaBuilder converts go/types into OpenAPI schemas
+// named struct and enum types are registered once as components and referenced everywhere else
+type schemaBuilder struct {
+	schemas *orderedmap.Map[string, *base.SchemaProxy]
+	docs    modspecv2.Docs
+	names   map[string]*types.TypeName
+}
+
+func newSchemaBuilder(schemas *orderedmap.Map[string, *base.SchemaProxy], docs modspecv2.Docs) *schemaBuilder {
+	return &schemaBuilder{
+		schemas: schemas,
+		docs:    docs,
+		names:   make(map[string]*types.TypeName),
+	}
+}
+
+// componentName returns a unique component name for a named type
+//
+//	github.com/example/module/billingv1.Account → billingv1.Account
+//
+// the import path is used when two packages share the same name
+func (sb *schemaBuilder) componentName(obj *types.TypeName) string {
+	name := fmt.Sprintf("%s.%s", obj.Pkg().Name(), obj.Name())
+	if existing, ok := sb.names[name]; ok && existing != obj {
+		name = strings.ReplaceAll(fmt.Sprintf("%s.%s", obj.Pkg().Path(), obj.Name()), "/", ".")
+	}
+	sb.names[name] = obj
+	return name
+}
+
+func (sb *schemaBuilder) component(obj *types.TypeName, build func() *base.Schema) *base.SchemaProxy {
+	name := sb.componentName(obj)
+	ref := base.CreateSchemaProxyRef(fmt.Sprintf("#/components/schemas/%s", name))
+	if _, ok := sb.schemas.Get(name); ok {
+		return ref
+	}
+
+	// reserve the name before building to support recursive types
+	sb.schemas.Set(name, nil)
+	schema := build()
+	schema.Title = obj.Name()
+	schema.Description = modspecv2.DocText(sb.docs[obj.Pos()])
+	sb.schemas.Set(name, base.CreateSchemaProxy(schema))
+	return ref
+}
+
+// Schema returns the schema of a type used in a JSON body
+func (sb *schemaBuilder) Schema(ty types.Type) *base.SchemaProxy {
+	if build, ok := wellKnownSchemas[ty.String()]; ok {
+		return base.CreateSchemaProxy(build())
+	}
+
+	switch t := ty.(type) {
+	case *types.Pointer:
+		return nullable(sb.Schema(t.Elem()))
+	case *types.Alias:
+		return sb.Schema(types.Unalias(t))
+	case *types.Named:
+		return sb.namedSchema(t)
+	}
+
+	return base.CreateSchemaProxy(sb.underlyingSchema(ty))
+}
+
+func (sb *schemaBuilder) namedSchema(named *types.Named) *base.SchemaProxy {
+	obj := named.Obj()
+	if obj.Pkg() == nil {
+		// predeclared types such as error
+		return base.CreateSchemaProxy(sb.underlyingSchema(named.Underlying()))
+	}
+
+	switch named.Underlying().(type) {
+	case *types.Struct:
+		return sb.component(obj, func() *base.Schema {
+			return sb.structSchema(named.Underlying().(*types.Struct))
+		})
+	case *types.Basic:
+		if values := enumValues(named); len(values) > 0 {
+			return sb.component(obj, func() *base.Schema {
+				schema := sb.underlyingSchema(named.Underlying())
+				schema.Enum = values
+				return schema
+			})
+		}
+	}
+
+	return base.CreateSchemaProxy(sb.underlyingSchema(named.Underlying()))
+}
+
+func (sb *schemaBuilder) underlyingSchema(ty types.Type) *base.Schema {
+	switch t := ty.(type) {
+	case *types.Basic:
+		return basicSchema(t)
+	case *types.Slice:
+		if isByte(t.Elem()) {
+			return &base.Schema{Type: []string{"string"}, Format: "byte"}
+		}
+		return &base.Schema{
+			Type:  []string{"array", "null"},
+			Items: &base.DynamicValue[*base.SchemaProxy, bool]{A: sb.Schema(t.Elem())},
+		}
+	case *types.Array:
+		return &base.Schema{
+			Type:     []string{"array"},
+			Items:    &base.DynamicValue[*base.SchemaProxy, bool]{A: sb.Schema(t.Elem())},
+			MinItems: lo.ToPtr(t.Len()),
+			MaxItems: lo.ToPtr(t.Len()),
+		}
+	case *types.Map:
+		return &base.Schema{
+			Type:                 []string{"object", "null"},
+			AdditionalProperties: &base.DynamicValue[*base.SchemaProxy, bool]{A: sb.Schema(t.Elem())},
+		}
+	case *types.Struct:
+		return sb.structSchema(t)
+	}
+
+	// interfaces and anything else that can hold an arbitrary JSON value
+	return &base.Schema{}
+}
+
+func basicSchema(t *types.Basic) *base.Schema {
+	switch {
+	case t.Info()&types.IsBoolean != 0:
+		return &base.Schema{Type: []string{"boolean"}}
+	case t.Info()&types.IsInteger != 0:
+		schema := &base.Schema{Type: []string{"integer"}}
+		switch t.Kind() {
+		case types.Int32, types.Uint32, types.Int16, types.Uint16, types.Int8, types.Uint8:
+			schema.Format = "int32"
+		default:
+			schema.Format = "int64"
+		}
+		return schema
+	case t.Info()&types.IsFloat != 0:
+		schema := &base.Schema{Type: []string{"number"}, Format: "double"}
+		if t.Kind() == types.Float32 {
+			schema.Format = "float"
+		}
+		return schema
+	case t.Info()&types.IsString != 0:
+		return &base.Schema{Type: []string{"string"}}
+	}
+	return &base.Schema{}
+}
+
+// structSchema describes the JSON body of a struct
+// fields bound from path, query, header or cookie values are excluded
+// embedded structs without a json name are flattened like encoding/json does
+func (sb *schemaBuilder) structSchema(st *types.Struct) *base.Schema {
+	schema := &base.Schema{
+		Type:       []string{"object"},
+		Properties: orderedmap.New[string, *base.SchemaProxy](),
+	}
+	sb.addStructFields(schema, st)
+	return schema
+}
+
+func (sb *schemaBuilder) addStructFields(schema *base.Schema, st *types.Struct) {
+	for _, field := range modspecv2.StructFields(st) {
+		if field.IsParam() {
+			continue
+		}
+
+		name, omitempty, ok := field.JSONName()
+		if !ok {
+			continue
+		}
+
+		if field.IsFlattened() {
+			embedded, _ := modspecv2.DerefStruct(field.Var.Type())
+			sb.addStructFields(schema, embedded)
+			continue
+		}
+
+		prop := sb.Schema(field.Var.Type())
+		if doc := modspecv2.DocText(sb.docs[field.Var.Pos()]); doc != "" {
+			prop = describe(prop, doc)
+		}
+
+		schema.Properties.Set(name, prop)
+		if !omitempty && !isPointer(field.Var.Type()) {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// Parameters returns the path, query, header and cookie parameters bound from a request type
+func (sb *schemaBuilder) Parameters(ty types.Type) (params []*v3.Parameter) {
+	st, ok := modspecv2.DerefStruct(ty)
+	if !ok {
+		return
+	}
+
+	for _, field := range modspecv2.StructFields(st) {
+		if field.Var.Embedded() && !field.IsParam() {
+			if _, ok := modspecv2.DerefStruct(field.Var.Type()); ok {
+				params = append(params, sb.Parameters(field.Var.Type())...)
+				continue
+			}
+		}
+
+		for _, in := range modspecv2.ParamLocations {
+			name, ok := field.TagName(in)
+			if !ok {
+				continue
+			}
+
+			params = append(params, &v3.Parameter{
+				Name:        name,
+				In:          in,
+				Description: modspecv2.DocText(sb.docs[field.Var.Pos()]),
+				Required:    lo.ToPtr(in == "path" || field.IsRequired()),
+				Schema:      sb.Schema(field.Var.Type()),
+			})
+		}
+	}
+	return
+}
+
+// HasBody reports whether any field of the request type is decoded from the request body
+func (sb *schemaBuilder) HasBody(ty types.Type) bool {
+	st, ok := modspecv2.DerefStruct(ty)
+	if !ok {
+		return ty != nil
+	}
+
+	for _, field := range modspecv2.StructFields(st) {
+		if field.IsParam() {
+			continue
+		}
+		if _, _, ok := field.JSONName(); !ok {
+			continue
+		}
+		if field.IsFlattened() && !sb.HasBody(field.Var.Type()) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// enumValues describes the constants of a named type as enum values
+func enumValues(named *types.Named) (values []*yaml.Node) {
+	for _, c := range modspecv2.EnumConsts(named) {
+		node := &yaml.Node{Kind: yaml.ScalarNode}
+		switch c.Val().Kind() {
+		case constant.String:
+			node.Tag = "!!str"
+			node.Value = constant.StringVal(c.Val())
+		case constant.Int:
+			node.Tag = "!!int"
+			node.Value = c.Val().ExactString()
+		case constant.Float:
+			node.Tag = "!!float"
+			node.Value = c.Val().ExactString()
+		case constant.Bool:
+			node.Tag = "!!bool"
+			node.Value = c.Val().ExactString()
+		default:
+			continue
+		}
+		values = append(values, node)
+	}
+	return
+}
+
+// nullable allows null in place of the given schema
+// OpenAPI 3.1 drops the nullable keyword in favor of JSON schema type arrays
+func nullable(proxy *base.SchemaProxy) *base.SchemaProxy {
+	if proxy.IsReference() {
+		return base.CreateSchemaProxy(&base.Schema{
+			OneOf: []*base.SchemaProxy{
+				proxy,
+				base.CreateSchemaProxy(&base.Schema{Type: []string{"null"}}),
+			},
+		})
+	}
+
+	schema := proxy.Schema()
+	if len(schema.Type) > 0 && !lo.Contains(schema.Type, "null") {
+		schema.Type = append(schema.Type, "null")
+	}
+	return proxy
+}
+
+// describe attaches a description to a schema
+// references can't carry sibling keywords in every tool, so they are wrapped in allOf
+func describe(proxy *base.SchemaProxy, doc string) *base.SchemaProxy {
+	if proxy.IsReference() {
+		return base.CreateSchemaProxy(&base.Schema{
+			AllOf:       []*base.SchemaProxy{proxy},
+			Description: doc,
+		})
+	}
+	proxy.Schema().Description = doc
+	return proxy
+}
+
+func isPointer(ty types.Type) bool {
+	_, ok := ty.(*types.Pointer)
+	return ok
+}
+
+func isByte(ty types.Type) bool {
+	basic, ok := ty.(*types.Basic)
+	return ok && basic.Kind() == types.Byte
+}
@@ -0,0 +1,12 @@
+package kibuopenapi
+
+import (
+	"github.com/kibu-sh/kibu/internal/toolchain/testutil"
+	"testing"
+)
+
+func TestGenerator(t *testing.T) {
+	testutil.RunScripts(t, map[string]testutil.Cmd{
+		"kibuopenapi": testutil.GeneratorCmd("kibuopenapi", Generate),
+	})
+}
@@ -0,0 +1,15 @@
+package main
+
+import (
+	"fmt"
+	"github.com/kibu-sh/kibu/internal/toolchain/kiburoutes"
+	"os"
+)
+
+func main() {
+	code, err := kiburoutes.Main()
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%v\n", err)
+	}
+	os.Exit(code)
+}
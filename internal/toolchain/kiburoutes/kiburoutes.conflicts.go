@@ -0,0 +1,88 @@
+package kiburoutes
+
+import (
+	"github.com/kibu-sh/kibu/pkg/transport/pathparam"
+	"strings"
+)
+
+type segmentKind int
+
+const (
+	segmentLiteral segmentKind = iota
+	segmentParam
+	segmentWildcard
+)
+
+type segment struct {
+	kind  segmentKind
+	value string
+}
+
+// parseSegments splits a path into segments understood by both gin and the stdlib mux
+// parameter names are dropped since routers match on the shape of a path
+// a trailing slash is kept as an empty literal segment, /accounts/ and /accounts are different routes
+//
+//	/accounts/{id}/files/{path...} → [accounts, param, files, wildcard]
+//	/accounts/:id/files/*path      → [accounts, param, files, wildcard]
+func parseSegments(path string) (segments []segment) {
+	parts := strings.Split(strings.TrimPrefix(strings.TrimSuffix(path, "{$}"), "/"), "/")
+	for i, part := range parts {
+		switch {
+		case part == "" && i < len(parts)-1:
+			continue
+		case part == "" && len(parts) == 1:
+			continue
+		case strings.HasPrefix(part, "*"), strings.HasPrefix(part, "{") && strings.HasSuffix(part, "...}"):
+			segments = append(segments, segment{kind: segmentWildcard})
+		case pathparam.Pattern.MatchString(part):
+			segments = append(segments, segment{kind: segmentParam})
+		default:
+			segments = append(segments, segment{kind: segmentLiteral, value: part})
+		}
+	}
+	return
+}
+
+// Conflicts reports whether two routes can match the same request
+// without one of them being strictly more specific than the other
+//
+//	GET /accounts/{id} and GET /accounts/:account_id conflict
+//	GET /accounts/new  and GET /accounts/{id} don't, the literal route takes precedence
+//	GET /{kind}/new    and GET /accounts/{id} conflict, neither is more specific
+func Conflicts(a, b Route) bool {
+	if a.Method != b.Method {
+		return false
+	}
+
+	sa, sb := parseSegments(a.Path), parseSegments(b.Path)
+	aMoreSpecific, bMoreSpecific := false, false
+
+	for i := 0; ; i++ {
+		switch {
+		case i == len(sa) && i == len(sb):
+			return aMoreSpecific == bMoreSpecific
+		case i < len(sa) && sa[i].kind == segmentWildcard:
+			if i < len(sb) && sb[i].kind == segmentWildcard {
+				return aMoreSpecific == bMoreSpecific
+			}
+			// b matches a subset of the remaining paths of a
+			return aMoreSpecific
+		case i < len(sb) && sb[i].kind == segmentWildcard:
+			return bMoreSpecific
+		case i == len(sa) || i == len(sb):
+			return false
+		}
+
+		x, y := sa[i], sb[i]
+		switch {
+		case x.kind == segmentLiteral && y.kind == segmentLiteral:
+			if x.value != y.value {
+				return false
+			}
+		case x.kind == segmentLiteral:
+			aMoreSpecific = true
+		case y.kind == segmentLiteral:
+			bMoreSpecific = true
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package kiburoutes
+
+import (
+	"errors"
+	"fmt"
+	"github.com/kibu-sh/kibu/internal/toolchain/kibumod"
+	"github.com/kibu-sh/kibu/internal/toolchain/modspecv2"
+	"go/token"
+	"golang.org/x/tools/go/analysis"
+	"reflect"
+)
+
+// Route is a single method and path registered by a //kibu:service:method
+type Route struct {
+	ID       string
+	Method   string
+	Path     string
+	Pos      token.Pos
+	Position string
+}
+
+func (r Route) String() string {
+	return fmt.Sprintf("%s %s (%s)", r.Method, r.Path, r.ID)
+}
+
+// RoutesFact is exported for every package declaring a //kibu:service
+// packages analyzed later compare their routes against the facts of the packages before them
+type RoutesFact struct {
+	Routes []Route
+}
+
+func (*RoutesFact) AFact() {}
+
+func (f *RoutesFact) String() string {
+	return fmt.Sprintf("%d routes", len(f.Routes))
+}
+
+var resultType = reflect.TypeOf((*RoutesFact)(nil))
+
+func FromPass(pass *analysis.Pass) (*RoutesFact, bool) {
+	result, ok := pass.ResultOf[Analyzer].(*RoutesFact)
+	return result, ok
+}
+
+var Analyzer = &analysis.Analyzer{
+	Name:             "kiburoutes",
+	Doc:              "Detects conflicting routes between kibu services of a module",
+	Run:              run,
+	ResultType:       resultType,
+	RunDespiteErrors: true,
+	Requires:         []*analysis.Analyzer{kibumod.Analyzer},
+	FactTypes:        []analysis.Fact{new(RoutesFact)},
+}
+
+var missingPackageError = errors.New("missing result of kibumod analyzer")
+
+func run(pass *analysis.Pass) (any, error) {
+	pkg, ok := kibumod.FromPass(pass)
+	if !ok {
+		return nil, missingPackageError
+	}
+
+	fact := &RoutesFact{}
+	for _, endpoint := range modspecv2.HTTPEndpoints(pkg, pass.TypesInfo) {
+		pos := endpoint.Operation.Method.Pos()
+		for _, method := range endpoint.Route.Methods {
+			fact.Routes = append(fact.Routes, Route{
+				ID:       endpoint.ID,
+				Method:   method,
+				Path:     endpoint.Route.Path,
+				Pos:      pos,
+				Position: pass.Fset.Position(pos).String(),
+			})
+		}
+	}
+
+	if len(fact.Routes) == 0 {
+		return fact, nil
+	}
+
+	// routes registered by previously analyzed packages
+	var existing []Route
+	for _, pkgFact := range pass.AllPackageFacts() {
+		if other, ok := pkgFact.Fact.(*RoutesFact); ok && pkgFact.Package != pass.Pkg {
+			existing = append(existing, other.Routes...)
+		}
+	}
+
+	for i, route := range fact.Routes {
+		for _, other := range append(existing, fact.Routes[:i]...) {
+			if Conflicts(route, other) {
+				pass.Reportf(route.Pos, "route %s at %s conflicts with %s at %s",
+					route, route.Position, other, other.Position)
+			}
+		}
+	}
+
+	pass.ExportPackageFact(fact)
+	return fact, nil
+}
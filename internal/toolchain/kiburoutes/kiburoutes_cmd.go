@@ -0,0 +1,59 @@
+package kiburoutes
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/kibu-sh/kibu/internal/toolchain/pipeline"
+	"golang.org/x/tools/go/analysis"
+	"os"
+)
+
+var ErrRouteConflict = errors.New("conflicting routes")
+
+func Main() (int, error) {
+	var root string
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return 1, errors.Join(err, errors.New("failed to get current working directory"))
+	}
+
+	fset := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	fset.StringVar(&root, "cwd", cwd, "current working directory")
+
+	if err = fset.Parse(os.Args[1:]); err != nil {
+		return 1, errors.Join(err, errors.New("failed to parse flags"))
+	}
+
+	if err = Check(root, fset.Args()); err != nil {
+		return 1, err
+	}
+	return 0, nil
+}
+
+// Check analyzes the packages matching patterns and returns an error listing every conflicting route
+func Check(root string, patterns []string) error {
+	store := pipeline.NewDiagnosticStore()
+	cfg := pipeline.ConfigDefaults().
+		WithFactStore(store).
+		WithDir(root).
+		WithPatterns(patterns).
+		WithAnalyzers([]*analysis.Analyzer{Analyzer})
+
+	_, pkgs, err := pipeline.Run(cfg)
+	if err != nil {
+		return errors.Join(err, errors.New("failed to run pipeline"))
+	}
+
+	diagnostics := store.Diagnostics()
+	if len(diagnostics) == 0 {
+		return nil
+	}
+
+	errs := []error{ErrRouteConflict}
+	for _, diagnostic := range diagnostics {
+		errs = append(errs, fmt.Errorf("%s", pipeline.FormatDiagnostic(pkgs[0].Fset, diagnostic)))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,63 @@
+package kiburoutes
+
+import (
+	"fmt"
+	"github.com/rogpeppe/go-internal/testscript"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+)
+
+func TestConflicts(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		conflict bool
+	}{
+		{"GET /accounts/{id}", "GET /accounts/{id}", true},
+		{"GET /accounts/{id}", "GET /accounts/:account_id", true},
+		{"GET /accounts/{id}", "POST /accounts/{id}", false},
+		{"GET /accounts/new", "GET /accounts/{id}", false},
+		{"GET /{kind}/new", "GET /accounts/{id}", true},
+		{"GET /accounts/{id}", "GET /accounts/{id}/invoices", false},
+		{"GET /files/*path", "GET /files/{path...}", true},
+		{"GET /files/*path", "GET /files/{id}", false},
+		{"GET /{kind}/*path", "GET /files/{id}", false},
+		{"GET /accounts/*path", "GET /{kind}/new", true},
+		{"GET /accounts/{$}", "GET /accounts/", true},
+		{"GET /accounts/{id}/", "GET /accounts/{id}", false},
+		{"GET /", "GET /{$}", true},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s|%s", test.a, test.b), func(t *testing.T) {
+			a, b := parseRoute(test.a), parseRoute(test.b)
+			require.Equal(t, test.conflict, Conflicts(a, b))
+			require.Equal(t, test.conflict, Conflicts(b, a))
+		})
+	}
+}
+
+func parseRoute(s string) (r Route) {
+	_, _ = fmt.Sscanf(s, "%s %s", &r.Method, &r.Path)
+	return
+}
+
+func TestAnalyzer(t *testing.T) {
+	scripts, err := filepath.Abs(filepath.Join("testdata", "scripts"))
+	require.NoError(t, err)
+
+	testscript.Run(t, testscript.Params{
+		Dir: scripts,
+		Cmds: map[string]func(ts *testscript.TestScript, neg bool, args []string){
+			"kiburoutes": func(ts *testscript.TestScript, neg bool, args []string) {
+				err := Check(args[0], args[1:])
+				if err != nil {
+					_, _ = fmt.Fprintln(ts.Stdout(), err)
+				}
+				if neg != (err != nil) {
+					ts.Fatalf("unexpected result: %v", err)
+				}
+			},
+		},
+	})
+}
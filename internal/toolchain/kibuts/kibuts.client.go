@@ -0,0 +1,213 @@
+package kibuts
+
+import (
+	"fmt"
+	"github.com/kibu-sh/kibu/internal/toolchain/modspecv2"
+	"github.com/kibu-sh/kibu/pkg/transport/pathparam"
+	"go/types"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// requestParam is a request field bound outside the body
+type requestParam struct {
+	In       string
+	Name     string
+	Accessor string
+}
+
+// buildServiceClients declares a client class for every service in the file of its package
+// cookies are not sent because browsers don't allow scripts to set the Cookie header
+//
+//	export class ServiceHTTPClient {
+//	  constructor(private readonly client: kibu.Client) {}
+//	}
+func buildServiceClients(m *module, endpoints []*modspecv2.HTTPEndpoint) {
+	type serviceKey struct {
+		pkg string
+		svc string
+	}
+
+	var order []serviceKey
+	grouped := make(map[serviceKey][]*modspecv2.HTTPEndpoint)
+	for _, endpoint := range endpoints {
+		key := serviceKey{endpoint.Package.GoPkg.Path(), endpoint.Service.Name}
+		if _, ok := grouped[key]; !ok {
+			order = append(order, key)
+		}
+		grouped[key] = append(grouped[key], endpoint)
+	}
+
+	for _, key := range order {
+		group := grouped[key]
+		svc := group[0].Service
+		f := m.file(group[0].Package.GoPkg)
+		f.runtime = true
+
+		name := svc.Name + "HTTPClient"
+		f.names[name] = true
+		decl := new(string)
+		f.decls = append(f.decls, decl)
+
+		var b strings.Builder
+		writeDoc(&b, "", modspecv2.DocText(svc.Doc))
+		fmt.Fprintf(&b, "export class %s {\n", name)
+		fmt.Fprintf(&b, "  private readonly client: %s.Client;\n\n", runtimeImportName)
+		fmt.Fprintf(&b, "  constructor(client: %s.Client) {\n", runtimeImportName)
+		b.WriteString("    this.client = client;\n")
+		b.WriteString("  }\n")
+
+		for _, endpoint := range group {
+			b.WriteString("\n")
+			m.writeClientMethod(&b, f, endpoint)
+		}
+
+		b.WriteString("}\n")
+		*decl = b.String()
+	}
+}
+
+func (m *module) writeClientMethod(b *strings.Builder, f *packageFile, endpoint *modspecv2.HTTPEndpoint) {
+	res := "void"
+	if endpoint.Response != nil {
+		res = m.typeExpr(f, endpoint.Response)
+	}
+
+	var params []string
+	if endpoint.Request != nil {
+		params = append(params, fmt.Sprintf("req: %s", m.typeExpr(f, endpoint.Request)))
+	}
+
+	reqParams := requestParams(endpoint.Request, "req")
+
+	writeDoc(b, "  ", modspecv2.DocText(endpoint.Operation.Doc))
+	isWebSocket := endpoint.Transport == modspecv2.TransportWebSocket
+	switch {
+	case endpoint.Stream != "":
+		// stream operations yield events as they arrive instead of resolving a single response
+		params = append(params, fmt.Sprintf("opts?: %s.StreamOptions", runtimeImportName))
+		fmt.Fprintf(b, "  %s(%s): AsyncGenerator<%s.StreamEvent<%s>> {\n", firstToLower(endpoint.Operation.Name), strings.Join(params, ", "), runtimeImportName, res)
+		fmt.Fprintf(b, "    return this.client.stream<%s>({\n", res)
+	case isWebSocket && endpoint.Message != nil:
+		// channel operations resolve once the connection is open and exchange messages until either side closes it
+		msg := m.typeExpr(f, endpoint.Message)
+		params = append(params, fmt.Sprintf("opts?: %s.CallOptions", runtimeImportName))
+		fmt.Fprintf(b, "  %s(%s): Promise<%s.Channel<%s, %s>> {\n", firstToLower(endpoint.Operation.Name), strings.Join(params, ", "), runtimeImportName, msg, res)
+		fmt.Fprintf(b, "    return this.client.connect<%s, %s>({\n", msg, res)
+	case isWebSocket:
+		params = append(params, fmt.Sprintf("opts?: %s.CallOptions", runtimeImportName))
+		fmt.Fprintf(b, "  %s(%s): Promise<%s> {\n", firstToLower(endpoint.Operation.Name), strings.Join(params, ", "), res)
+		fmt.Fprintf(b, "    return this.client.callOverSocket<%s>({\n", res)
+	default:
+		params = append(params, fmt.Sprintf("opts?: %s.CallOptions", runtimeImportName))
+		fmt.Fprintf(b, "  %s(%s): Promise<%s> {\n", firstToLower(endpoint.Operation.Name), strings.Join(params, ", "), res)
+		fmt.Fprintf(b, "    return this.client.call<%s>({\n", res)
+	}
+	fmt.Fprintf(b, "      method: %q,\n", endpoint.Route.Method())
+	fmt.Fprintf(b, "      path: %s,\n", pathExpr(endpoint.Route.Path, reqParams))
+
+	// request option keys of the runtime by param location
+	for _, loc := range [][2]string{{"query", "query"}, {"header", "headers"}} {
+		var values []string
+		for _, param := range reqParams {
+			if param.In == loc[0] {
+				values = append(values, fmt.Sprintf("%s: %s", propertyName(param.Name), param.Accessor))
+			}
+		}
+		if len(values) > 0 {
+			fmt.Fprintf(b, "      %s: { %s },\n", loc[1], strings.Join(values, ", "))
+		}
+	}
+
+	// transport=ws operations without a channel send the request as the body of a single message
+	if endpoint.Request != nil && (hasBodyByMethod(endpoint.Route.Method()) || (isWebSocket && endpoint.Message == nil)) {
+		b.WriteString("      body: req,\n")
+	}
+
+	b.WriteString("    }, opts);\n")
+	b.WriteString("  }\n")
+}
+
+// requestParams collects the path, query and header values of a request type
+// fields of embedded structs are promoted the same way encoding/json flattens them
+func requestParams(ty types.Type, receiver string) (params []requestParam) {
+	st, ok := modspecv2.DerefStruct(ty)
+	if !ok {
+		return
+	}
+
+	for _, field := range modspecv2.StructFields(st) {
+		prop, _, ok := property(field)
+		if !ok {
+			continue
+		}
+
+		if field.IsFlattened() && !field.IsParam() {
+			params = append(params, requestParams(field.Var.Type(), receiver)...)
+			continue
+		}
+
+		for _, in := range modspecv2.ParamLocations {
+			name, ok := field.TagName(in)
+			if !ok || in == "cookie" {
+				continue
+			}
+
+			params = append(params, requestParam{
+				In:       in,
+				Name:     name,
+				Accessor: accessor(receiver, prop),
+			})
+		}
+	}
+	return
+}
+
+// pathExpr returns a string or template literal expanding the path parameters of a route
+//
+//	/accounts/{id} → `/accounts/${kibu.pathParam(req.ID)}`
+func pathExpr(path string, params []requestParam) string {
+	expanded := false
+	literal := pathparam.Replace(path, func(match string, pathParam pathparam.Param) string {
+		if pathParam.Anchor {
+			return ""
+		}
+
+		for _, param := range params {
+			if param.In == "path" && param.Name == pathParam.Name {
+				expanded = true
+				return fmt.Sprintf("${%s.pathParam(%s)}", runtimeImportName, param.Accessor)
+			}
+		}
+		return match
+	})
+
+	if !expanded {
+		return strconv.Quote(literal)
+	}
+	return "`" + strings.ReplaceAll(literal, "`", "\\`") + "`"
+}
+
+func accessor(receiver, prop string) string {
+	name := propertyName(prop)
+	if strings.HasPrefix(name, `"`) {
+		return fmt.Sprintf("%s[%s]", receiver, name)
+	}
+	return fmt.Sprintf("%s.%s", receiver, name)
+}
+
+func hasBodyByMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		return true
+	}
+	return false
+}
+
+func firstToLower(name string) string {
+	r := []rune(name)
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
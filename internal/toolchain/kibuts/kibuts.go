@@ -0,0 +1,46 @@
+package kibuts
+
+import (
+	"errors"
+	"github.com/kibu-sh/kibu/internal/toolchain/kibumod"
+	"github.com/kibu-sh/kibu/internal/toolchain/modspecv2"
+	"golang.org/x/tools/go/analysis"
+	"reflect"
+)
+
+// PackageSpec is the result of analyzing a single package
+// packages without endpoints still contribute docs for types referenced by other packages
+type PackageSpec struct {
+	Endpoints []*modspecv2.HTTPEndpoint
+	Docs      modspecv2.Docs
+}
+
+var resultType = reflect.TypeOf((*PackageSpec)(nil))
+
+func FromPass(pass *analysis.Pass) (*PackageSpec, bool) {
+	result, ok := pass.ResultOf[Analyzer].(*PackageSpec)
+	return result, ok
+}
+
+var Analyzer = &analysis.Analyzer{
+	Name:             "kibuts",
+	Doc:              "Analyzes kibu service definitions and generates TypeScript types and clients",
+	Run:              run,
+	ResultType:       resultType,
+	RunDespiteErrors: true,
+	Requires:         []*analysis.Analyzer{kibumod.Analyzer},
+}
+
+var missingPackageError = errors.New("missing result of kibumod analyzer")
+
+func run(pass *analysis.Pass) (any, error) {
+	pkg, ok := kibumod.FromPass(pass)
+	if !ok {
+		return nil, missingPackageError
+	}
+
+	return &PackageSpec{
+		Endpoints: modspecv2.HTTPEndpoints(pkg, pass.TypesInfo),
+		Docs:      modspecv2.CollectDocs(pass.Files),
+	}, nil
+}
@@ -0,0 +1,188 @@
+package kibuts
+
+import (
+	_ "embed"
+	"fmt"
+	"github.com/kibu-sh/kibu/internal/toolchain/modspecv2"
+	"go/types"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+//go:embed runtime.ts
+var runtimeSource []byte
+
+const (
+	runtimeFileName   = "kibu"
+	runtimeImportName = "kibu"
+	headerComment     = "// Code generated by kibu. DO NOT EDIT.\n"
+)
+
+var _ modspecv2.Artifact = (*File)(nil)
+
+// File is a single TypeScript source file written relative to the module root
+type File struct {
+	path    string
+	content []byte
+}
+
+func (f *File) Render(w io.Writer) error {
+	_, err := w.Write(f.content)
+	return err
+}
+
+func (f *File) OutputPath() string {
+	return f.path
+}
+
+// module collects the TypeScript declarations of every go package referenced by a service
+// types are emitted in a file mirroring the path of their go package
+//
+//	github.com/example/module/billingv1 → <out>/billingv1.ts
+type module struct {
+	outDir     string
+	modulePath string
+	docs       modspecv2.Docs
+	files      map[string]*packageFile
+	order      []string
+}
+
+func newModule(outDir, modulePath string, docs modspecv2.Docs) *module {
+	return &module{
+		outDir:     outDir,
+		modulePath: modulePath,
+		docs:       docs,
+		files:      make(map[string]*packageFile),
+	}
+}
+
+// packageFile holds the declarations of a single go package
+type packageFile struct {
+	pkg      *types.Package
+	relPath  string
+	decls    []*string
+	declared map[*types.TypeName]string
+	names    map[string]bool
+	imports  map[string]map[string]bool
+	runtime  bool
+}
+
+func (m *module) isLocal(pkg *types.Package) bool {
+	return pkg != nil && (pkg.Path() == m.modulePath || strings.HasPrefix(pkg.Path(), m.modulePath+"/"))
+}
+
+// file returns the declarations of a go package, creating them on first use
+func (m *module) file(pkg *types.Package) *packageFile {
+	if f, ok := m.files[pkg.Path()]; ok {
+		return f
+	}
+
+	rel := strings.TrimPrefix(strings.TrimPrefix(pkg.Path(), m.modulePath), "/")
+	if rel == "" {
+		rel = pkg.Name()
+	}
+
+	f := &packageFile{
+		pkg:      pkg,
+		relPath:  rel,
+		declared: make(map[*types.TypeName]string),
+		names:    make(map[string]bool),
+		imports:  make(map[string]map[string]bool),
+	}
+	m.files[pkg.Path()] = f
+	m.order = append(m.order, pkg.Path())
+	return f
+}
+
+// importPath returns the relative import of other from f
+//
+//	services/billingv1 → common → ../common
+func (f *packageFile) importPath(otherRelPath string) string {
+	rel, _ := filepath.Rel(path.Dir(f.relPath), otherRelPath)
+	rel = filepath.ToSlash(rel)
+	if !strings.HasPrefix(rel, ".") {
+		rel = "./" + rel
+	}
+	return rel
+}
+
+func (f *packageFile) addImport(other *packageFile, name string) {
+	if other == f {
+		return
+	}
+	if f.imports[other.relPath] == nil {
+		f.imports[other.relPath] = make(map[string]bool)
+	}
+	f.imports[other.relPath][name] = true
+}
+
+func (m *module) render(f *packageFile) []byte {
+	var b strings.Builder
+	b.WriteString(headerComment)
+
+	if f.runtime || len(f.imports) > 0 {
+		b.WriteString("\n")
+	}
+
+	if f.runtime {
+		fmt.Fprintf(&b, "import * as %s from %q;\n", runtimeImportName, f.importPath(runtimeFileName))
+	}
+
+	imports := make([]string, 0, len(f.imports))
+	for rel := range f.imports {
+		imports = append(imports, rel)
+	}
+	sort.Strings(imports)
+
+	for _, rel := range imports {
+		names := make([]string, 0, len(f.imports[rel]))
+		for name := range f.imports[rel] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Fprintf(&b, "import type { %s } from %q;\n", strings.Join(names, ", "), f.importPath(rel))
+	}
+
+	for _, decl := range f.decls {
+		b.WriteString("\n")
+		b.WriteString(*decl)
+	}
+	return []byte(b.String())
+}
+
+// BuildModule generates a TypeScript file for every go package referenced by an endpoint
+// and a shared runtime containing the fetch based client
+func BuildModule(outDir string, modulePath string, specs []*PackageSpec) []modspecv2.Artifact {
+	docs := make(modspecv2.Docs)
+	var endpoints []*modspecv2.HTTPEndpoint
+	for _, spec := range specs {
+		endpoints = append(endpoints, spec.Endpoints...)
+		docs.Merge(spec.Docs)
+	}
+
+	sort.SliceStable(endpoints, func(i, j int) bool {
+		return endpoints[i].ID < endpoints[j].ID
+	})
+
+	m := newModule(outDir, modulePath, docs)
+	buildServiceClients(m, endpoints)
+
+	artifacts := []modspecv2.Artifact{
+		&File{
+			path:    filepath.Join(outDir, runtimeFileName+".ts"),
+			content: runtimeSource,
+		},
+	}
+
+	for _, pkgPath := range m.order {
+		f := m.files[pkgPath]
+		artifacts = append(artifacts, &File{
+			path:    filepath.Join(outDir, f.relPath+".ts"),
+			content: m.render(f),
+		})
+	}
+	return artifacts
+}
@@ -0,0 +1,246 @@
+package kibuts
+
+import (
+	"fmt"
+	"github.com/kibu-sh/kibu/internal/toolchain/modspecv2"
+	"go/constant"
+	"go/types"
+	"strconv"
+	"strings"
+)
+
+// wellKnownTypes maps types that serialize to JSON primitives instead of their underlying Go type
+var wellKnownTypes = map[string]string{
+	"time.Time":                                 "string",
+	"time.Duration":                             "number",
+	"encoding/json.RawMessage":                  "unknown",
+	"github.com/google/uuid.UUID":               "string",
+	"github.com/google/uuid.NullUUID":           "string | null",
+	"github.com/shopspring/decimal.Decimal":     "string",
+	"github.com/shopspring/decimal.NullDecimal": "string | null",
+}
+
+// typeExpr returns the TypeScript type of ty as referenced from f
+// named types declared in the module are emitted once and imported where needed
+// named types from other modules are inlined
+func (m *module) typeExpr(f *packageFile, ty types.Type) string {
+	return m.typeExprVisiting(f, ty, map[types.Type]bool{})
+}
+
+func (m *module) typeExprVisiting(f *packageFile, ty types.Type, visiting map[types.Type]bool) string {
+	if ts, ok := wellKnownTypes[ty.String()]; ok {
+		return ts
+	}
+
+	switch t := ty.(type) {
+	case *types.Pointer:
+		return nullable(m.typeExprVisiting(f, t.Elem(), visiting))
+	case *types.Alias:
+		return m.typeExprVisiting(f, types.Unalias(t), visiting)
+	case *types.Named:
+		if m.isLocal(t.Obj().Pkg()) && t.TypeArgs().Len() == 0 {
+			return m.reference(f, t)
+		}
+
+		// external types are inlined, recursive ones can't be expressed without a declaration
+		if visiting[t] {
+			return "unknown"
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+		return m.typeExprVisiting(f, t.Underlying(), visiting)
+	case *types.Basic:
+		return basicType(t)
+	case *types.Slice:
+		if isByte(t.Elem()) {
+			return "string"
+		}
+		return nullable(fmt.Sprintf("Array<%s>", m.typeExprVisiting(f, t.Elem(), visiting)))
+	case *types.Array:
+		return fmt.Sprintf("Array<%s>", m.typeExprVisiting(f, t.Elem(), visiting))
+	case *types.Map:
+		return nullable(fmt.Sprintf("Record<string, %s>", m.typeExprVisiting(f, t.Elem(), visiting)))
+	case *types.Struct:
+		return m.inlineStruct(f, t, visiting)
+	}
+
+	// interfaces and anything else that can hold an arbitrary JSON value
+	return "unknown"
+}
+
+func basicType(t *types.Basic) string {
+	switch {
+	case t.Info()&types.IsBoolean != 0:
+		return "boolean"
+	case t.Info()&types.IsNumeric != 0:
+		return "number"
+	case t.Info()&types.IsString != 0:
+		return "string"
+	}
+	return "unknown"
+}
+
+// reference declares a named type in the file of its package and imports it into f
+func (m *module) reference(f *packageFile, named *types.Named) string {
+	obj := named.Obj()
+	owner := m.file(obj.Pkg())
+	name, ok := owner.declared[obj]
+	if !ok {
+		name = m.declare(owner, named)
+	}
+	f.addImport(owner, name)
+	return name
+}
+
+// declare emits a named type into its package file
+func (m *module) declare(f *packageFile, named *types.Named) string {
+	obj := named.Obj()
+	name := obj.Name()
+	for i := 2; f.names[name]; i++ {
+		name = fmt.Sprintf("%s%d", obj.Name(), i)
+	}
+
+	// reserve the name and position before building to support recursive types
+	decl := new(string)
+	f.names[name] = true
+	f.declared[obj] = name
+	f.decls = append(f.decls, decl)
+
+	var b strings.Builder
+	writeDoc(&b, "", modspecv2.DocText(m.docs[obj.Pos()]))
+
+	switch underlying := named.Underlying().(type) {
+	case *types.Struct:
+		fmt.Fprintf(&b, "export interface %s {\n", name)
+		m.writeFields(&b, f, underlying, "  ", map[types.Type]bool{})
+		b.WriteString("}\n")
+	default:
+		expr := enumUnion(named)
+		if expr == "" {
+			expr = m.typeExpr(f, underlying)
+		}
+		fmt.Fprintf(&b, "export type %s = %s;\n", name, expr)
+	}
+
+	*decl = b.String()
+	return name
+}
+
+func (m *module) inlineStruct(f *packageFile, st *types.Struct, visiting map[types.Type]bool) string {
+	var b strings.Builder
+	b.WriteString("{ ")
+	m.writeInlineFields(&b, f, st, visiting)
+	b.WriteString("}")
+	return b.String()
+}
+
+func (m *module) writeInlineFields(b *strings.Builder, f *packageFile, st *types.Struct, visiting map[types.Type]bool) {
+	for _, field := range modspecv2.StructFields(st) {
+		name, omitempty, ok := property(field)
+		if !ok {
+			continue
+		}
+		if field.IsFlattened() {
+			embedded, _ := modspecv2.DerefStruct(field.Var.Type())
+			m.writeInlineFields(b, f, embedded, visiting)
+			continue
+		}
+		fmt.Fprintf(b, "%s%s: %s; ", propertyName(name), optional(omitempty), m.typeExprVisiting(f, field.Var.Type(), visiting))
+	}
+}
+
+// writeFields writes the properties of a struct as they are encoded by encoding/json
+func (m *module) writeFields(b *strings.Builder, f *packageFile, st *types.Struct, indent string, visiting map[types.Type]bool) {
+	for _, field := range modspecv2.StructFields(st) {
+		name, omitempty, ok := property(field)
+		if !ok {
+			continue
+		}
+
+		if field.IsFlattened() {
+			embedded, _ := modspecv2.DerefStruct(field.Var.Type())
+			m.writeFields(b, f, embedded, indent, visiting)
+			continue
+		}
+
+		writeDoc(b, indent, modspecv2.DocText(m.docs[field.Var.Pos()]))
+		fmt.Fprintf(b, "%s%s%s: %s;\n", indent, propertyName(name), optional(omitempty), m.typeExprVisiting(f, field.Var.Type(), visiting))
+	}
+}
+
+// property returns the name of a field in the generated interface
+// params excluded from the body with json:"-" keep their go name so the client can still bind them
+func property(field *modspecv2.StructField) (name string, omitempty bool, ok bool) {
+	if name, omitempty, ok = field.JSONName(); ok {
+		return
+	}
+	if field.IsParam() {
+		return field.Var.Name(), false, true
+	}
+	return
+}
+
+// enumUnion returns a union of the literal values of a named type's constants
+//
+//	"active" | "closed"
+func enumUnion(named *types.Named) string {
+	var values []string
+	for _, c := range modspecv2.EnumConsts(named) {
+		switch c.Val().Kind() {
+		case constant.String:
+			values = append(values, strconv.Quote(constant.StringVal(c.Val())))
+		case constant.Int, constant.Float, constant.Bool:
+			values = append(values, c.Val().ExactString())
+		}
+	}
+	return strings.Join(values, " | ")
+}
+
+func writeDoc(b *strings.Builder, indent string, doc string) {
+	if doc == "" {
+		return
+	}
+
+	doc = strings.ReplaceAll(doc, "*/", "*\\/")
+	lines := strings.Split(doc, "\n")
+	if len(lines) == 1 {
+		fmt.Fprintf(b, "%s/** %s */\n", indent, lines[0])
+		return
+	}
+
+	fmt.Fprintf(b, "%s/**\n", indent)
+	for _, line := range lines {
+		fmt.Fprintf(b, "%s%s\n", indent, strings.TrimRight(" * "+line, " "))
+	}
+	fmt.Fprintf(b, "%s */\n", indent)
+}
+
+func nullable(expr string) string {
+	if strings.HasSuffix(expr, "| null") {
+		return expr
+	}
+	return expr + " | null"
+}
+
+func optional(omitempty bool) string {
+	if omitempty {
+		return "?"
+	}
+	return ""
+}
+
+// propertyName quotes json names that aren't valid identifiers
+func propertyName(name string) string {
+	for i, r := range name {
+		if r == '_' || r == '$' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (i > 0 && r >= '0' && r <= '9') {
+			continue
+		}
+		return strconv.Quote(name)
+	}
+	return name
+}
+
+func isByte(ty types.Type) bool {
+	basic, ok := ty.(*types.Basic)
+	return ok && basic.Kind() == types.Byte
+}
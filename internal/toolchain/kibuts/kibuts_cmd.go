@@ -0,0 +1,68 @@
+package kibuts
+
+import (
+	"errors"
+	"flag"
+	"github.com/kibu-sh/kibu/internal/toolchain/modspecv2"
+	"github.com/kibu-sh/kibu/internal/toolchain/pipeline"
+	"golang.org/x/tools/go/analysis"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func Main() (int, error) {
+	var root string
+	var genDir string
+	var patterns []string
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return 1, errors.Join(err, errors.New("failed to get current working directory"))
+	}
+
+	fset := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	fset.StringVar(&root, "cwd", cwd, "current working directory")
+	fset.StringVar(&genDir, "out", "gen/typescript", "output directory")
+
+	err = fset.Parse(os.Args[1:])
+	if err != nil {
+		return 1, errors.Join(err, errors.New("failed to parse flags"))
+	}
+
+	patterns = fset.Args()
+
+	if !filepath.IsAbs(genDir) {
+		genDir = filepath.Clean(filepath.Join(root, genDir))
+	}
+
+	if err = Generate(root, genDir, patterns); err != nil {
+		return 1, err
+	}
+	return 0, nil
+}
+
+// Generate analyzes the packages matching patterns and writes TypeScript types and clients to genDir
+// genDir must be an absolute path inside the go module
+func Generate(root string, genDir string, patterns []string) error {
+	cfg := pipeline.ConfigDefaults().
+		WithDir(root).
+		WithPatterns(patterns).
+		WithAnalyzers([]*analysis.Analyzer{Analyzer})
+
+	results, pkgs, err := pipeline.Run(cfg)
+	if err != nil {
+		return errors.Join(err, errors.New("failed to run pipeline"))
+	}
+
+	module := pkgs[0].Module
+	outPrefix := strings.TrimPrefix(genDir, module.Dir)
+	specs := modspecv2.GatherResults[*PackageSpec](results)
+	artifacts := BuildModule(outPrefix, module.Path, specs)
+
+	_, err = modspecv2.SaveArtifacts(module, artifacts)
+	if err != nil {
+		return errors.Join(err, errors.New("failed to save artifacts"))
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+package kibuts
+
+import (
+	"github.com/kibu-sh/kibu/internal/toolchain/testutil"
+	"testing"
+)
+
+func TestGenerator(t *testing.T) {
+	testutil.RunScripts(t, map[string]testutil.Cmd{
+		"kibuts": testutil.GeneratorCmd("kibuts", Generate),
+	})
+}
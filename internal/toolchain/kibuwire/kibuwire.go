@@ -12,6 +12,7 @@ import (
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
 	"golang.org/x/tools/go/ast/inspector"
+	"io"
 	"path/filepath"
 	"reflect"
 )
@@ -281,6 +282,10 @@ func (k *Module) File() *jen.File {
 	return k.file
 }
 
+func (k *Module) Render(w io.Writer) error {
+	return k.file.Render(w)
+}
+
 func (k *Module) OutputPath() string {
 	return filepath.Join(k.out, "kibuwire/kibuwire.gen.go")
 }
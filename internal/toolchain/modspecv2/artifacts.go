@@ -44,7 +44,7 @@ func saveArtifact(moduleRoot string, artifact Artifact) (string, error) {
 		_ = file.Close()
 	}(file)
 
-	return filename, artifact.File().Render(file)
+	return filename, artifact.Render(file)
 }
 
 func RelPathFromPass(pass *analysis.Pass) string {
@@ -0,0 +1,75 @@
+package modspecv2
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// Docs holds doc comments of type and struct field declarations keyed by the position of their identifier
+// this allows generators working with go/types to describe their output with the comments found in the AST
+type Docs map[token.Pos]string
+
+// CollectDocs gathers the doc comments of all type specs and struct fields in files
+func CollectDocs(files []*ast.File) Docs {
+	docs := make(Docs)
+	for _, file := range files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch node := n.(type) {
+			case *ast.GenDecl:
+				docs.addTypeSpecs(node)
+			case *ast.StructType:
+				docs.addFields(node)
+			}
+			return true
+		})
+	}
+	return docs
+}
+
+// Merge copies all docs from other into d
+func (d Docs) Merge(other Docs) {
+	for pos, text := range other {
+		d[pos] = text
+	}
+}
+
+func (d Docs) addTypeSpecs(decl *ast.GenDecl) {
+	for _, spec := range decl.Specs {
+		ts, ok := spec.(*ast.TypeSpec)
+		if !ok {
+			continue
+		}
+
+		doc := ts.Doc
+		if doc == nil && len(decl.Specs) == 1 {
+			doc = decl.Doc
+		}
+
+		if text := doc.Text(); text != "" {
+			d[ts.Name.Pos()] = text
+		}
+	}
+}
+
+func (d Docs) addFields(st *ast.StructType) {
+	for _, field := range st.Fields.List {
+		doc := field.Doc
+		if doc == nil {
+			doc = field.Comment
+		}
+
+		text := doc.Text()
+		if text == "" {
+			continue
+		}
+
+		for _, name := range field.Names {
+			d[name.Pos()] = text
+		}
+
+		// embedded fields use the position of the type expression
+		if len(field.Names) == 0 {
+			d[field.Type.Pos()] = text
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package modspecv2
+
+import (
+	"github.com/fatih/structtag"
+	"github.com/samber/lo"
+	"go/types"
+	"sort"
+)
+
+// ParamLocations are the struct tags used by the httpx decoder chain to bind values outside the request body
+// the order matches httpx.DefaultDecoderChain
+var ParamLocations = []string{"path", "query", "header", "cookie"}
+
+// StructField is a struct field with its parsed tags
+type StructField struct {
+	Var  *types.Var
+	Tags *structtag.Tags
+}
+
+// StructFields returns the exported and embedded fields of a struct
+func StructFields(st *types.Struct) (result []*StructField) {
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		if !field.Exported() && !field.Embedded() {
+			continue
+		}
+
+		tags, err := structtag.Parse(st.Tag(i))
+		if err != nil {
+			tags = &structtag.Tags{}
+		}
+
+		result = append(result, &StructField{
+			Var:  field,
+			Tags: tags,
+		})
+	}
+	return
+}
+
+// TagName returns the name of the field for the given tag key
+// fields without the tag, or tagged with "-", are not bound
+func (f *StructField) TagName(key string) (string, bool) {
+	tag, err := f.Tags.Get(key)
+	if err != nil || tag.Name == "-" {
+		return "", false
+	}
+	if tag.Name == "" {
+		return f.Var.Name(), true
+	}
+	return tag.Name, true
+}
+
+// IsParam reports whether the field is bound from outside the request body
+func (f *StructField) IsParam() bool {
+	return lo.SomeBy(ParamLocations, func(in string) bool {
+		_, ok := f.TagName(in)
+		return ok
+	})
+}
+
+// HasJSONName reports whether the field has an explicit json name
+func (f *StructField) HasJSONName() bool {
+	tag, err := f.Tags.Get("json")
+	return err == nil && tag.Name != ""
+}
+
+// IsFlattened reports whether encoding/json promotes the fields of an embedded struct
+func (f *StructField) IsFlattened() bool {
+	if !f.Var.Embedded() || f.HasJSONName() {
+		return false
+	}
+	_, ok := DerefStruct(f.Var.Type())
+	return ok
+}
+
+// JSONName mirrors the field naming rules of encoding/json
+func (f *StructField) JSONName() (name string, omitempty bool, ok bool) {
+	tag, err := f.Tags.Get("json")
+	if err != nil {
+		return f.Var.Name(), false, f.Var.Exported() || f.Var.Embedded()
+	}
+	if tag.Name == "-" && len(tag.Options) == 0 {
+		return "", false, false
+	}
+	name = lo.Ternary(tag.Name != "", tag.Name, f.Var.Name())
+	return name, tag.HasOption("omitempty"), true
+}
+
+// IsRequired reports whether the field is marked as required for validation
+//
+//	validate:"required" binding:"required"
+func (f *StructField) IsRequired() bool {
+	return lo.SomeBy([]string{"validate", "binding"}, func(key string) bool {
+		tag, err := f.Tags.Get(key)
+		return err == nil && (tag.Name == "required" || tag.HasOption("required"))
+	})
+}
+
+// EnumConsts returns the constants declared in the package of a named type in source order
+//
+//	type Status string
+//	const StatusActive Status = "active"
+func EnumConsts(named *types.Named) (consts []*types.Const) {
+	if named.Obj().Pkg() == nil {
+		return
+	}
+
+	scope := named.Obj().Pkg().Scope()
+	for _, name := range scope.Names() {
+		c, ok := scope.Lookup(name).(*types.Const)
+		if ok && types.Identical(c.Type(), named) {
+			consts = append(consts, c)
+		}
+	}
+
+	sort.SliceStable(consts, func(i, j int) bool {
+		return consts[i].Pos() < consts[j].Pos()
+	})
+	return
+}
+
+// DerefStruct returns the struct of a type or a pointer to it
+func DerefStruct(ty types.Type) (*types.Struct, bool) {
+	if ty == nil {
+		return nil, false
+	}
+	if ptr, ok := ty.(*types.Pointer); ok {
+		ty = ptr.Elem()
+	}
+	st, ok := ty.Underlying().(*types.Struct)
+	return st, ok
+}
@@ -0,0 +1,21 @@
+package modspecv2
+
+import "github.com/kibu-sh/kibu/internal/toolchain/kibugenv2/decorators"
+
+// IdempotentOptionKey is the option of //kibu:service and //kibu:service:method that replays the responses of repeated requests
+const IdempotentOptionKey = "idempotent"
+
+// ResolveIdempotent reports whether an operation replays the response of requests repeated with the same Idempotency-Key
+// the option of a //kibu:service:method overrides the one of its //kibu:service
+//
+//	//kibu:service:method path=/invoices method=POST idempotent
+//	//kibu:service:method path=/invoices/preview method=POST idempotent=false
+func ResolveIdempotent(svc *Service, op *Operation) bool {
+	for _, opts := range []*decorators.OptionList{op.ServiceMethodOptions(), svc.ServiceOptions()} {
+		if opts.Has(IdempotentOptionKey) {
+			val, _ := opts.GetOne(IdempotentOptionKey, "true")
+			return val != "false"
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package modspecv2
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Options of //kibu:service and //kibu:service:method that limit requests
+const (
+	TimeoutOptionKey   = "timeout"
+	MaxBodyOptionKey   = "max_body"
+	MaxHeaderOptionKey = "max_header"
+)
+
+// HTTPLimits mirrors httpx.Limits for a single operation
+// zero values are unlimited
+type HTTPLimits struct {
+	Timeout        time.Duration
+	MaxBodyBytes   int64
+	MaxHeaderBytes int64
+}
+
+// IsZero reports whether the operation declares no limits
+func (l HTTPLimits) IsZero() bool {
+	return l == HTTPLimits{}
+}
+
+// byteUnits are the units accepted by ParseByteSize keyed by their lower case name
+var byteUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"kb":  1000,
+	"mb":  1000 * 1000,
+	"gb":  1000 * 1000 * 1000,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+}
+
+// ParseByteSize parses a positive size with an optional decimal or binary unit
+//
+//	512 → 512
+//	16KiB → 16384
+//	1MB → 1000000
+func ParseByteSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	end := strings.IndexFunc(s, func(r rune) bool {
+		return r < '0' || r > '9'
+	})
+	if end < 0 {
+		end = len(s)
+	}
+
+	n, err := strconv.ParseInt(s[:end], 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("expected a positive size such as 512KiB")
+	}
+
+	unit, ok := byteUnits[strings.ToLower(strings.TrimSpace(s[end:]))]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q, expected B, KB, MB, GB, KiB, MiB or GiB", s[end:])
+	}
+	return n * unit, nil
+}
+
+// ResolveHTTPLimits merges the limits of a //kibu:service:method over its //kibu:service
+// malformed options are reported and left unlimited
+// the timeout of a service only applies to its unary operations, streams and WebSockets outlive any request deadline
+//
+//	//kibu:service:method timeout=5s max_body=1MiB max_header=16KiB
+func ResolveHTTPLimits(svc *Service, op *Operation) (limits HTTPLimits, errs []error) {
+	lookup := func(key string) (string, bool) {
+		if val, ok := op.ServiceMethodOptions().GetOne(key, ""); ok {
+			return val, true
+		}
+		return svc.ServiceOptions().GetOne(key, "")
+	}
+
+	if val, ok := op.ServiceMethodOptions().GetOne(TimeoutOptionKey, ""); ok && !op.IsUnary() {
+		errs = append(errs, fmt.Errorf("%s can't be set on stream or WebSocket operations, they would be closed after %s", TimeoutOptionKey, val))
+	} else if val, ok := lookup(TimeoutOptionKey); ok && op.IsUnary() {
+		timeout, err := time.ParseDuration(val)
+		switch {
+		case err != nil, timeout <= 0:
+			errs = append(errs, fmt.Errorf("invalid %s %q, expected a positive duration such as 5s", TimeoutOptionKey, val))
+		default:
+			limits.Timeout = timeout
+		}
+	}
+
+	sizes := []struct {
+		key    string
+		target *int64
+	}{
+		{MaxBodyOptionKey, &limits.MaxBodyBytes},
+		{MaxHeaderOptionKey, &limits.MaxHeaderBytes},
+	}
+
+	for _, size := range sizes {
+		val, ok := lookup(size.key)
+		if !ok {
+			continue
+		}
+
+		n, err := ParseByteSize(val)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s %q: %v", size.key, val, err))
+			continue
+		}
+		*size.target = n
+	}
+	return
+}
@@ -6,12 +6,14 @@ import (
 	"go/ast"
 	"go/types"
 	"golang.org/x/tools/go/analysis"
+	"io"
 	"path/filepath"
 )
 
 type Artifact interface {
-	// File returns a generated jen.File that will be written to disk
-	File() *jen.File
+	// Render writes the contents of the artifact to w
+	// go source artifacts render their jen.File, other artifacts (i.e. yaml, typescript) write raw bytes
+	Render(w io.Writer) error
 
 	// OutputPath returns a string to a file and its extension relative to the module root
 	// i.e., example.com/foo/bar/baz.go -> foo/bar/baz.gen.go
@@ -28,6 +30,10 @@ func (p *PackageArtifact) File() *jen.File {
 	return p.file
 }
 
+func (p *PackageArtifact) Render(w io.Writer) error {
+	return p.file.Render(w)
+}
+
 func (p *PackageArtifact) OutputPath() string {
 	return filepath.Join(RelPathFromPass(p.pass), GenGoExt(p.pass.Pkg.Name()+p.ext))
 }
@@ -0,0 +1,31 @@
+package modspecv2
+
+import (
+	"slices"
+	"strings"
+)
+
+// PermissionOptionKey is the option of //kibu:service and //kibu:service:method listing the permissions an operation requires
+const PermissionOptionKey = "permission"
+
+// ResolvePermissions returns the permissions required by an operation
+// the permissions of a //kibu:service:method replace the ones of its //kibu:service
+// the option can be repeated or hold a comma separated list
+//
+//	//kibu:service:method permission=billing.read,billing.write
+func ResolvePermissions(svc *Service, op *Operation) (permissions []string) {
+	values, ok := op.ServiceMethodOptions().GetAll(PermissionOptionKey, nil)
+	if !ok {
+		values, _ = svc.ServiceOptions().GetAll(PermissionOptionKey, nil)
+	}
+
+	for _, value := range values {
+		for _, permission := range strings.Split(value, ",") {
+			permission = strings.TrimSpace(permission)
+			if permission != "" && !slices.Contains(permissions, permission) {
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+	return
+}
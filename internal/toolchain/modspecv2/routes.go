@@ -0,0 +1,123 @@
+package modspecv2
+
+import (
+	"fmt"
+	"github.com/kibu-sh/kibu/pkg/transport/pathparam"
+	"github.com/samber/lo"
+	"go/types"
+	"golang.org/x/tools/go/analysis"
+	"net/http"
+	"sort"
+)
+
+// knownMethods are the methods accepted by the method option of //kibu:service:method
+var knownMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+	http.MethodTrace,
+}
+
+// PathFields returns the go names of the request fields bound by a path tag keyed by the tag name
+// fields of embedded structs are included the same way the httpx decoder promotes them
+func PathFields(ty types.Type) map[string]string {
+	fields := make(map[string]string)
+	collectPathFields(ty, fields)
+	return fields
+}
+
+func collectPathFields(ty types.Type, fields map[string]string) {
+	st, ok := DerefStruct(ty)
+	if !ok {
+		return
+	}
+
+	for _, field := range StructFields(st) {
+		if name, ok := field.TagName("path"); ok {
+			fields[name] = field.Var.Name()
+			continue
+		}
+		if field.Var.Embedded() {
+			collectPathFields(field.Var.Type(), fields)
+		}
+	}
+}
+
+// ValidateHTTPEndpoint checks the route of an endpoint against its request type
+// every path parameter must be bound to exactly one path tagged field, and every path tagged field must appear in the path
+func ValidateHTTPEndpoint(endpoint *HTTPEndpoint) (diagnostics []analysis.Diagnostic) {
+	pos := endpoint.Operation.Method.Pos()
+	report := func(format string, args ...any) {
+		diagnostics = append(diagnostics, analysis.Diagnostic{
+			Pos:      pos,
+			Category: "kibu",
+			Message:  fmt.Sprintf("%s: %s", endpoint.ID, fmt.Sprintf(format, args...)),
+		})
+	}
+
+	for _, method := range endpoint.Route.Methods {
+		if !lo.Contains(knownMethods, method) {
+			report("unknown http method %q", method)
+		}
+	}
+
+	if endpoint.Stream != "" {
+		if endpoint.Stream != StreamSSE {
+			report("unknown stream %q, expected %q", endpoint.Stream, StreamSSE)
+		}
+		if endpoint.Response == nil {
+			report("stream operations must accept a transport.StreamSink after the request")
+		}
+	}
+
+	if endpoint.Transport != TransportHTTP && endpoint.Transport != TransportWebSocket {
+		report("unknown transport %q, expected %q or %q", endpoint.Transport, TransportHTTP, TransportWebSocket)
+	}
+	if endpoint.Operation.IsChannel() && endpoint.Transport != TransportWebSocket {
+		report("operations accepting a transport.Channel must be declared with transport=%s", TransportWebSocket)
+	}
+	if endpoint.Operation.IsChannel() && endpoint.Message == nil {
+		report("could not resolve the message types of transport.Channel")
+	}
+	if endpoint.Stream != "" && endpoint.Transport == TransportWebSocket {
+		report("stream operations can't be served over transport=%s", TransportWebSocket)
+	}
+
+	if ResolveIdempotent(endpoint.Service, endpoint.Operation) && (!endpoint.Operation.IsUnary() || endpoint.Transport == TransportWebSocket) {
+		report("%s operations must be unary http operations, streams and WebSockets can't be replayed", IdempotentOptionKey)
+	}
+
+	_, limitErrs := ResolveHTTPLimits(endpoint.Service, endpoint.Operation)
+	for _, err := range limitErrs {
+		report("%v", err)
+	}
+
+	fields := PathFields(endpoint.Request)
+	params := pathparam.Names(endpoint.Route.Path)
+
+	seen := make(map[string]bool)
+	for _, param := range params {
+		if seen[param] {
+			report("path parameter {%s} is declared more than once in %s", param, endpoint.Route.Path)
+			continue
+		}
+		seen[param] = true
+
+		if _, ok := fields[param]; !ok {
+			report("path parameter {%s} in %s has no request field tagged path:%q", param, endpoint.Route.Path, param)
+		}
+	}
+
+	names := lo.Keys(fields)
+	sort.Strings(names)
+	for _, name := range names {
+		if !seen[name] {
+			report("request field %s is tagged path:%q but %s has no {%s} parameter", fields[name], name, endpoint.Route.Path, name)
+		}
+	}
+	return
+}
@@ -0,0 +1,351 @@
+package modspecv2
+
+import (
+	"fmt"
+	"github.com/kibu-sh/kibu/internal/toolchain/kibugenv2/decorators"
+	"github.com/samber/lo"
+	"go/ast"
+	"go/types"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var (
+	IsKibuService       = decorators.HasKey("kibu", "service")
+	IsKibuServiceMethod = decorators.HasKey("kibu", "service", "method")
+)
+
+const (
+	// TransportHTTP is the default transport of a //kibu:service:method
+	TransportHTTP = "http"
+
+	// TransportWebSocket is the value of the transport option that serves an operation over a WebSocket
+	//
+	//	//kibu:service:method transport=ws
+	TransportWebSocket = "ws"
+)
+
+// StreamSSE is the value of the stream option that exposes an operation as server-sent events
+//
+//	//kibu:service:method stream=sse
+const StreamSSE = "sse"
+
+// HTTPRoute describes how a //kibu:service operation is exposed over HTTP
+type HTTPRoute struct {
+	Path    string
+	Methods []string
+}
+
+// FindOptions returns the options of the first decorator matching filter
+// an empty list is returned when the decorator is missing, so callers never deal with nil options
+func FindOptions(list decorators.List, filter decorators.FilterFunc) *decorators.OptionList {
+	line, ok := list.Find(filter)
+	if !ok || line.Options == nil {
+		return decorators.NewOptionList()
+	}
+	return line.Options
+}
+
+// IsHTTPService reports whether the service is declared with //kibu:service
+func (svc *Service) IsHTTPService() bool {
+	return svc.Decorators.Some(IsKibuService)
+}
+
+// IsGRPCService reports whether a //kibu:service is also served as a gRPC service
+// the option is a boolean, values that aren't true (i.e. grpc=false) leave the service out of gRPC
+//
+//	//kibu:service grpc
+//	//kibu:service grpc=true
+func (svc *Service) IsGRPCService() bool {
+	opts := svc.ServiceOptions()
+	if !svc.IsHTTPService() || !opts.Has("grpc") {
+		return false
+	}
+
+	val, _ := opts.GetOne("grpc", "true")
+	enabled, err := strconv.ParseBool(val)
+	return err == nil && enabled
+}
+
+// ServiceOptions returns the options of the //kibu:service decorator
+func (svc *Service) ServiceOptions() *decorators.OptionList {
+	return FindOptions(svc.Decorators, IsKibuService)
+}
+
+// ServiceMethodOptions returns the options of the //kibu:service:method decorator
+func (op *Operation) ServiceMethodOptions() *decorators.OptionList {
+	return FindOptions(op.Decorators, IsKibuServiceMethod)
+}
+
+// ServiceID returns the fully qualified name of a service
+//
+//	billingv1.Service
+func ServiceID(pkg *Package, svc *Service) string {
+	return fmt.Sprintf("%s.%s", pkg.Name, svc.Name)
+}
+
+// GRPCMethod returns the full name of the gRPC method serving an operation
+//
+//	/billingv1.Service/GetAccount
+func GRPCMethod(pkg *Package, svc *Service, op *Operation) string {
+	return fmt.Sprintf("/%s/%s", ServiceID(pkg, svc), op.Name)
+}
+
+// OperationID returns the fully qualified name of an operation
+// it is shared by generated constants, temporal registrations and api documents
+//
+//	billingv1.Service.WatchAccount
+func OperationID(pkg *Package, svc *Service, op *Operation) string {
+	return fmt.Sprintf("%s.%s", ServiceID(pkg, svc), op.Name)
+}
+
+// HTTPRoute resolves the route of a //kibu:service:method
+// the path defaults to /<package>/<operation> and the methods default to POST (GET for streams)
+//
+//	//kibu:service:method path=/accounts/{id} method=GET,HEAD
+func (op *Operation) HTTPRoute(pkg *Package) HTTPRoute {
+	opts := op.ServiceMethodOptions()
+	path, _ := opts.GetOne("path", fmt.Sprintf("/%s/%s", pkg.Name, op.Name))
+	methods, _ := opts.GetAll("method", nil)
+
+	route := HTTPRoute{Path: path}
+	for _, method := range methods {
+		method = strings.ToUpper(strings.TrimSpace(method))
+		if method != "" && !lo.Contains(route.Methods, method) {
+			route.Methods = append(route.Methods, method)
+		}
+	}
+
+	if len(route.Methods) == 0 && (op.IsStream() || op.IsWebSocket()) {
+		// EventSource clients and WebSocket handshakes can only issue GET requests
+		route.Methods = []string{http.MethodGet}
+	}
+
+	if len(route.Methods) == 0 {
+		route.Methods = []string{http.MethodPost}
+	}
+	return route
+}
+
+// Stream returns the value of the stream option of a //kibu:service:method
+//
+//	//kibu:service:method stream=sse → sse
+func (op *Operation) Stream() string {
+	stream, _ := op.ServiceMethodOptions().GetOne("stream", "")
+	return strings.ToLower(strings.TrimSpace(stream))
+}
+
+// Transport returns the value of the transport option of a //kibu:service:method, defaulting to http
+//
+//	//kibu:service:method transport=ws → ws
+func (op *Operation) Transport() string {
+	transport, _ := op.ServiceMethodOptions().GetOne("transport", TransportHTTP)
+	return strings.ToLower(strings.TrimSpace(transport))
+}
+
+// IsWebSocket reports whether the operation is served over a WebSocket
+func (op *Operation) IsWebSocket() bool {
+	return op.Transport() == TransportWebSocket
+}
+
+// IsStream reports whether the operation pushes events to a transport.StreamSink instead of returning a response
+func (op *Operation) IsStream() bool {
+	return op.Stream() != ""
+}
+
+// IsUnary reports whether the operation answers a single request with a single response
+// only unary operations are served over gRPC
+func (op *Operation) IsUnary() bool {
+	return !op.IsStream() && !op.IsChannel()
+}
+
+// Method returns the primary method of a route used by generated clients
+func (r HTTPRoute) Method() string {
+	return r.Methods[0]
+}
+
+// RequestType returns the type of the request parameter that follows the context
+//
+//	WatchAccount(ctx context.Context, req WatchAccountRequest) → WatchAccountRequest
+func (op *Operation) RequestType(info *types.Info) (types.Type, bool) {
+	return typeAtIndex(info, op.Params, 1)
+}
+
+// ResponseType returns the type of the first result
+// operations that only return an error have no response
+//
+//	WatchAccount(...) (res WatchAccountResponse, err error) → WatchAccountResponse
+//	DeleteAccount(...) (err error) → nil, false
+func (op *Operation) ResponseType(info *types.Info) (types.Type, bool) {
+	if len(op.Results) < 2 {
+		return nil, false
+	}
+	return typeAtIndex(info, op.Results, 0)
+}
+
+// StreamEventType returns the event type of the transport.StreamSink parameter that follows the request
+//
+//	WatchAccount(ctx context.Context, req WatchAccountRequest, sink transport.StreamSink[AccountEvent]) error → AccountEvent
+func (op *Operation) StreamEventType(pkg *Package, info *types.Info) (types.Type, bool) {
+	args, ok := genericParamTypeArgs(pkg, info, op.Params, 2, "StreamSink")
+	if !ok || len(args) != 1 {
+		return nil, false
+	}
+	return args[0], true
+}
+
+// ChannelTypes returns the message types of the transport.Channel parameter that follows the request
+// in is received from the client and out is sent to it
+//
+//	Chat(ctx context.Context, req ChatRequest, ch transport.Channel[ChatMessage, ChatReply]) error → ChatMessage, ChatReply
+func (op *Operation) ChannelTypes(pkg *Package, info *types.Info) (in types.Type, out types.Type, ok bool) {
+	args, ok := genericParamTypeArgs(pkg, info, op.Params, 2, "Channel")
+	if !ok || len(args) != 2 {
+		return nil, nil, false
+	}
+	return args[0], args[1], true
+}
+
+// IsChannel reports whether the operation accepts a transport.Channel after the request
+func (op *Operation) IsChannel() bool {
+	_, _, ok := genericParamExprs(op.Params, 2, "Channel")
+	return ok
+}
+
+// genericParamTypeArgs resolves the type arguments of a generic parameter named name (i.e. transport.StreamSink[T])
+// parameters are matched syntactically, so arguments resolve even when the transport package fails to type check
+func genericParamTypeArgs(pkg *Package, info *types.Info, list []Type, index int, name string) ([]types.Type, bool) {
+	_, exprs, ok := genericParamExprs(list, index, name)
+	if !ok {
+		return nil, false
+	}
+
+	args := make([]types.Type, 0, len(exprs))
+	for _, expr := range exprs {
+		ty := info.TypeOf(expr)
+		if ident, ok := expr.(*ast.Ident); ok && ty == nil && pkg.GoPkg != nil {
+			// type arguments of an unresolved generic are never recorded, so local types are looked up by name
+			if obj := pkg.GoPkg.Scope().Lookup(ident.Name); obj != nil {
+				ty = obj.Type()
+			}
+		}
+		if ty == nil {
+			return nil, false
+		}
+		args = append(args, ty)
+	}
+	return args, true
+}
+
+// genericParamExprs returns the generic type and the type argument expressions of a parameter
+func genericParamExprs(list []Type, index int, name string) (x ast.Expr, args []ast.Expr, ok bool) {
+	if index < 0 || index >= len(list) || list[index].Field == nil {
+		return nil, nil, false
+	}
+
+	switch expr := list[index].Field.Type.(type) {
+	case *ast.IndexExpr:
+		x, args = expr.X, []ast.Expr{expr.Index}
+	case *ast.IndexListExpr:
+		x, args = expr.X, expr.Indices
+	default:
+		return nil, nil, false
+	}
+
+	switch id := x.(type) {
+	case *ast.SelectorExpr:
+		ok = id.Sel.Name == name
+	case *ast.Ident:
+		ok = id.Name == name
+	}
+	return
+}
+
+func typeAtIndex(info *types.Info, list []Type, index int) (types.Type, bool) {
+	if index < 0 || index >= len(list) || list[index].Field == nil {
+		return nil, false
+	}
+
+	ty := info.TypeOf(list[index].Field.Type)
+	return ty, ty != nil
+}
+
+// DocText strips comment markers and kibu directives from a raw doc comment
+//
+//	"// Service does things\n//\n//kibu:service" → "Service does things"
+func DocText(doc string) string {
+	var lines []string
+	for _, line := range strings.Split(doc, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimPrefix(line, "/*")
+		line = strings.TrimSuffix(line, "*/")
+		if strings.HasPrefix(line, "//") {
+			if decorators.IsDirective(line[2:]) {
+				continue
+			}
+			line = line[2:]
+		}
+		lines = append(lines, strings.TrimSpace(line))
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// HTTPEndpoint is a single //kibu:service:method resolved against its Go types
+type HTTPEndpoint struct {
+	ID        string
+	Package   *Package
+	Service   *Service
+	Operation *Operation
+	Route     HTTPRoute
+	Request   types.Type
+	Response  types.Type
+
+	// Stream is the value of the stream option (i.e. sse), Response then holds the event type
+	Stream string
+
+	// Transport is the value of the transport option (i.e. http, ws)
+	Transport string
+
+	// Message is the type received from clients of a transport.Channel, Response then holds the type sent to them
+	Message types.Type
+}
+
+// HTTPEndpoints returns every operation of a package exposed by a //kibu:service
+func HTTPEndpoints(pkg *Package, info *types.Info) (endpoints []*HTTPEndpoint) {
+	for _, svc := range pkg.Services {
+		if !svc.IsHTTPService() {
+			continue
+		}
+
+		for _, op := range svc.Operations {
+			if op == nil {
+				continue
+			}
+
+			req, _ := op.RequestType(info)
+			res, _ := op.ResponseType(info)
+			var msg types.Type
+			if op.IsStream() {
+				res, _ = op.StreamEventType(pkg, info)
+			}
+			if op.IsChannel() {
+				msg, res, _ = op.ChannelTypes(pkg, info)
+			}
+
+			endpoints = append(endpoints, &HTTPEndpoint{
+				ID:        OperationID(pkg, svc, op),
+				Package:   pkg,
+				Service:   svc,
+				Operation: op,
+				Route:     op.HTTPRoute(pkg),
+				Request:   req,
+				Response:  res,
+				Stream:    op.Stream(),
+				Transport: op.Transport(),
+				Message:   msg,
+			})
+		}
+	}
+	return
+}
@@ -1,8 +1,11 @@
 package pipeline
 
 import (
+	"fmt"
+	"go/token"
 	"go/types"
 	"golang.org/x/tools/go/analysis"
+	"sync"
 )
 
 type FactStore interface {
@@ -95,3 +98,38 @@ func (n NoOpFactStore) AllPackageFacts() []analysis.PackageFact {
 func (n NoOpFactStore) AllObjectFacts() []analysis.ObjectFact {
 	return nil
 }
+
+var _ FactStore = (*DiagnosticStore)(nil)
+
+// DiagnosticStore keeps the diagnostics reported by analyzers and the facts they share in memory
+type DiagnosticStore struct {
+	*MemoryFactStore
+	mtx         sync.Mutex
+	diagnostics []analysis.Diagnostic
+}
+
+func NewDiagnosticStore() *DiagnosticStore {
+	return &DiagnosticStore{
+		MemoryFactStore: NewMemoryFactStore(),
+	}
+}
+
+func (s *DiagnosticStore) Report(diagnostic analysis.Diagnostic) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.diagnostics = append(s.diagnostics, diagnostic)
+}
+
+// Diagnostics returns the diagnostics reported so far in order of arrival
+func (s *DiagnosticStore) Diagnostics() []analysis.Diagnostic {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return append([]analysis.Diagnostic(nil), s.diagnostics...)
+}
+
+// FormatDiagnostic formats a diagnostic the same way go vet does
+//
+//	billingv1/billingv1.go:12:2: path parameter {id} has no request field
+func FormatDiagnostic(fset *token.FileSet, diagnostic analysis.Diagnostic) string {
+	return fmt.Sprintf("%s: %s", fset.Position(diagnostic.Pos), diagnostic.Message)
+}
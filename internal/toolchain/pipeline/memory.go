@@ -0,0 +1,113 @@
+package pipeline
+
+import (
+	"go/types"
+	"golang.org/x/tools/go/analysis"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// packageBinder is implemented by stores that scope package facts to the package of the current pass
+type packageBinder interface {
+	bindPackage(pkg *types.Package)
+}
+
+type packageFactKey struct {
+	pkg *types.Package
+	typ reflect.Type
+}
+
+type objectFactKey struct {
+	obj types.Object
+	typ reflect.Type
+}
+
+var _ FactStore = (*MemoryFactStore)(nil)
+
+// MemoryFactStore keeps the facts of every package analyzed by a pipeline in memory
+// facts exported by a package are visible to every package analyzed after it, which enables module wide checks
+// packages are analyzed one at a time, so the store is bound to the package of the current pass by NewAnalysisPass
+type MemoryFactStore struct {
+	NoOpFactStore
+	mtx          sync.Mutex
+	pkg          *types.Package
+	packageFacts map[packageFactKey]analysis.Fact
+	objectFacts  map[objectFactKey]analysis.Fact
+}
+
+func NewMemoryFactStore() *MemoryFactStore {
+	return &MemoryFactStore{
+		packageFacts: make(map[packageFactKey]analysis.Fact),
+		objectFacts:  make(map[objectFactKey]analysis.Fact),
+	}
+}
+
+func (s *MemoryFactStore) bindPackage(pkg *types.Package) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.pkg = pkg
+}
+
+func (s *MemoryFactStore) ImportObjectFact(obj types.Object, fact analysis.Fact) bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	stored, ok := s.objectFacts[objectFactKey{obj, reflect.TypeOf(fact)}]
+	if ok {
+		copyFact(fact, stored)
+	}
+	return ok
+}
+
+func (s *MemoryFactStore) ImportPackageFact(pkg *types.Package, fact analysis.Fact) bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	stored, ok := s.packageFacts[packageFactKey{pkg, reflect.TypeOf(fact)}]
+	if ok {
+		copyFact(fact, stored)
+	}
+	return ok
+}
+
+func (s *MemoryFactStore) ExportObjectFact(obj types.Object, fact analysis.Fact) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.objectFacts[objectFactKey{obj, reflect.TypeOf(fact)}] = fact
+}
+
+func (s *MemoryFactStore) ExportPackageFact(fact analysis.Fact) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.packageFacts[packageFactKey{s.pkg, reflect.TypeOf(fact)}] = fact
+}
+
+// AllPackageFacts returns the package facts of every package analyzed so far ordered by package path
+func (s *MemoryFactStore) AllPackageFacts() (facts []analysis.PackageFact) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for key, fact := range s.packageFacts {
+		facts = append(facts, analysis.PackageFact{Package: key.pkg, Fact: fact})
+	}
+	sort.SliceStable(facts, func(i, j int) bool {
+		return facts[i].Package.Path() < facts[j].Package.Path()
+	})
+	return
+}
+
+// AllObjectFacts returns the object facts of every package analyzed so far ordered by position
+func (s *MemoryFactStore) AllObjectFacts() (facts []analysis.ObjectFact) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for key, fact := range s.objectFacts {
+		facts = append(facts, analysis.ObjectFact{Object: key.obj, Fact: fact})
+	}
+	sort.SliceStable(facts, func(i, j int) bool {
+		return facts[i].Object.Pos() < facts[j].Object.Pos()
+	})
+	return
+}
+
+// copyFact copies the value of a stored fact into the pointer supplied by an importer
+func copyFact(dst, src analysis.Fact) {
+	reflect.ValueOf(dst).Elem().Set(reflect.ValueOf(src).Elem())
+}
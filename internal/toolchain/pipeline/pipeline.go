@@ -65,6 +65,10 @@ func NewRunner(analyzers ...*analysis.Analyzer) *Runner {
 }
 
 func NewAnalysisPass(pkg *packages.Package, store FactStore) *analysis.Pass {
+	if binder, ok := store.(packageBinder); ok {
+		binder.bindPackage(pkg.Types)
+	}
+
 	return &analysis.Pass{
 		Fset:              pkg.Fset,
 		Files:             pkg.Syntax,
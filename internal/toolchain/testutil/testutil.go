@@ -0,0 +1,61 @@
+// Package testutil runs the txtar scripts that test the generators of the toolchain
+package testutil
+
+import (
+	"flag"
+	"github.com/rogpeppe/go-internal/testscript"
+	"path/filepath"
+	"testing"
+)
+
+// Cmd is a command available to scripts
+type Cmd = func(ts *testscript.TestScript, neg bool, args []string)
+
+// GenerateFunc writes the artifacts of the packages matching patterns in the module at root to genDir
+type GenerateFunc func(root string, genDir string, patterns []string) error
+
+// ResolveDir returns the absolute path of rel, relative to the package under test
+func ResolveDir(t *testing.T, rel string) string {
+	t.Helper()
+	abs, err := filepath.Abs(rel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return abs
+}
+
+// RunScripts runs every script in the testdata/scripts directory of the package under test with cmds
+func RunScripts(t *testing.T, cmds map[string]Cmd) {
+	testscript.Run(t, testscript.Params{
+		Dir:  filepath.Join(ResolveDir(t, "testdata"), "scripts"),
+		Cmds: cmds,
+	})
+}
+
+// GeneratorCmd runs generate with the flags of the command line of a generator
+//
+//	kibuts -cwd $WORK/src -out $WORK/src/web/gen ./...
+func GeneratorCmd(name string, generate GenerateFunc) Cmd {
+	return func(ts *testscript.TestScript, neg bool, args []string) {
+		var root string
+		var genDir string
+
+		fset := flag.NewFlagSet(name, flag.ContinueOnError)
+		fset.StringVar(&root, "cwd", "", "current working directory")
+		fset.StringVar(&genDir, "out", "", "output directory")
+		ts.Check(fset.Parse(args))
+
+		if !filepath.IsAbs(genDir) {
+			genDir = filepath.Clean(filepath.Join(root, genDir))
+		}
+
+		err := generate(root, genDir, fset.Args())
+		if neg {
+			if err == nil {
+				ts.Fatalf("expected %s to fail", name)
+			}
+			return
+		}
+		ts.Check(err)
+	}
+}
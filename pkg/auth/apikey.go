@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/kibu-sh/kibu/pkg/config"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// DefaultAPIKeyHeader carries the API key of a request
+const DefaultAPIKeyHeader = "X-API-Key"
+
+// ErrUnknownAPIKey is reported when a request carries an API key that isn't in the key set
+var ErrUnknownAPIKey = errors.New("unknown api key")
+
+// APIKey grants a principal to the holder of a key
+// only the sha256 of the key is stored, so the config store never holds the key itself
+//
+//	{"name": "billing-worker", "sha256": "9f86d081884c7d65...", "roles": ["billing.write"]}
+type APIKey struct {
+	// Name is the Subject of the Principal
+	Name   string   `json:"name"`
+	SHA256 string   `json:"sha256"`
+	Roles  []string `json:"roles,omitempty"`
+}
+
+// HashAPIKey returns the hex encoded sha256 of a key, as stored in APIKey.SHA256
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// LoadAPIKeys reads a list of APIKey from the config store
+//
+//	keys, err := auth.LoadAPIKeys(ctx, store, "api_keys")
+func LoadAPIKeys(ctx context.Context, store config.Store, key string) (keys []APIKey, err error) {
+	if _, err = store.GetByKey(ctx, key, &keys); err != nil {
+		err = errors.Wrapf(err, "failed to load api keys from %s", key)
+	}
+	return
+}
+
+var _ Authenticator = (*APIKeyAuthenticator)(nil)
+var _ Challenger = (*APIKeyAuthenticator)(nil)
+
+// APIKeyAuthenticator authenticates the API key of a request header
+type APIKeyAuthenticator struct {
+	header string
+	keys   map[string]APIKey
+}
+
+// NewAPIKeyAuthenticator accepts keys sent in header, DefaultAPIKeyHeader is used when it is empty
+func NewAPIKeyAuthenticator(header string, keys []APIKey) *APIKeyAuthenticator {
+	if header == "" {
+		header = DefaultAPIKeyHeader
+	}
+
+	a := &APIKeyAuthenticator{
+		header: header,
+		keys:   make(map[string]APIKey, len(keys)),
+	}
+	for _, key := range keys {
+		a.keys[strings.ToLower(key.SHA256)] = key
+	}
+	return a
+}
+
+// Challenge implements Challenger
+func (a *APIKeyAuthenticator) Challenge() string {
+	return `APIKey header="` + a.header + `"`
+}
+
+// Authenticate implements Authenticator
+func (a *APIKeyAuthenticator) Authenticate(ctx context.Context, req transport.Request) (*Principal, error) {
+	raw := strings.TrimSpace(req.Headers().Get(a.header))
+	if raw == "" {
+		return nil, ErrNoCredentials
+	}
+
+	key, ok := a.keys[HashAPIKey(raw)]
+	if !ok {
+		return nil, Unauthenticated(ErrUnknownAPIKey)
+	}
+
+	return &Principal{
+		Subject: key.Name,
+		Method:  MethodAPIKey,
+		Roles:   key.Roles,
+	}, nil
+}
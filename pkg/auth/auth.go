@@ -0,0 +1,190 @@
+// Package auth authenticates requests for operations tagged "auth", which is every operation not marked public
+// Authenticators turn the credentials of a request (a JWT bearer token, an API key or a session cookie) into a Principal
+// stored in the request context, so endpoints can read it with FromContext
+//
+//	reg.Register(middleware.RegistryItem{
+//		Tags:       []string{"auth"},
+//		Middleware: auth.Middleware(jwtAuthenticator, apiKeyAuthenticator),
+//	})
+package auth
+
+import (
+	"context"
+	"fmt"
+	"github.com/kibu-sh/kibu/pkg/config"
+	"github.com/kibu-sh/kibu/pkg/ctxutil"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/pkg/errors"
+	"slices"
+	"strings"
+)
+
+// Methods of authentication reported by Principal.Method
+const (
+	MethodJWT     = "jwt"
+	MethodAPIKey  = "api_key"
+	MethodSession = "session"
+)
+
+// ErrNoCredentials is returned by an Authenticator when a request doesn't carry the credentials it checks
+// the next Authenticator is tried, and requests without any credentials are rejected as transport.ErrUnauthenticated
+var ErrNoCredentials = errors.New("no credentials")
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	// Subject identifies the user or client (i.e. the sub claim of a JWT or the name of an API key)
+	Subject string `json:"subject"`
+
+	// Method is the kind of credentials that authenticated the principal (i.e. MethodJWT)
+	Method string `json:"method"`
+
+	// Roles are granted to the principal by its credentials
+	Roles []string `json:"roles,omitempty"`
+
+	// Claims are the raw claims of the credentials (i.e. the payload of a JWT)
+	// they are left out of the JSON of the principal, so they aren't copied into the headers of workflows
+	Claims map[string]any `json:"-"`
+}
+
+// HasRole reports whether the principal was granted role
+func (p *Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+type principalKey struct{}
+
+// PrincipalStore holds the Principal of a request in its context
+var PrincipalStore = ctxutil.NewStore[*Principal, principalKey]()
+
+// PrincipalHeaderKey is the temporal header carrying the Principal of a request
+// into the workflows it starts and their activities, where FromContext loads it again
+const PrincipalHeaderKey = "kibu-principal"
+
+func init() {
+	ctxutil.RegisterStore(ctxutil.Propagators, PrincipalHeaderKey, PrincipalStore)
+}
+
+// FromContext returns the Principal that made the current request
+// it wraps ctxutil.ErrNotFoundInContext for requests to public operations
+// ctx can also be the workflow.Context of a workflow, or the context of an activity, started by the request
+//
+//	func (s *Service) GetAccount(ctx context.Context, req GetAccountRequest) (res GetAccountResponse, err error) {
+//		principal, err := auth.FromContext(ctx)
+//		...
+//	}
+func FromContext(ctx ctxutil.ValueContainer) (*Principal, error) {
+	return PrincipalStore.Load(ctx)
+}
+
+// Authenticator checks one kind of credentials
+// it returns ErrNoCredentials when the request doesn't carry them,
+// and an error wrapping transport.ErrUnauthenticated when they are invalid (see Unauthenticated)
+// other errors are failures of the authenticator itself, such as a key set that can't be fetched
+type Authenticator interface {
+	Authenticate(ctx context.Context, req transport.Request) (*Principal, error)
+}
+
+// AuthenticatorFunc adapts a func to Authenticator
+type AuthenticatorFunc func(ctx context.Context, req transport.Request) (*Principal, error)
+
+func (f AuthenticatorFunc) Authenticate(ctx context.Context, req transport.Request) (*Principal, error) {
+	return f(ctx, req)
+}
+
+// Challenger is implemented by authenticators that tell clients how to authenticate
+// the challenges of every authenticator are sent in the WWW-Authenticate header of 401 responses
+type Challenger interface {
+	Challenge() string
+}
+
+// Unauthenticated reports invalid credentials, the reason is shown to the client
+//
+//	unauthenticated: token is expired
+func Unauthenticated(reason error) error {
+	return fmt.Errorf("%w: %w", transport.ErrUnauthenticated, reason)
+}
+
+// Authenticate tries every authenticator in order and returns the Principal of the first that accepts the credentials of req
+func Authenticate(ctx context.Context, req transport.Request, authenticators ...Authenticator) (*Principal, error) {
+	for _, authenticator := range authenticators {
+		principal, err := authenticator.Authenticate(ctx, req)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return principal, nil
+	}
+	return nil, Unauthenticated(ErrNoCredentials)
+}
+
+// Middleware authenticates requests with authenticators and stores their Principal in the request context
+// requests without valid credentials never reach the endpoint and are answered with 401 Unauthorized by the codec
+func Middleware(authenticators ...Authenticator) transport.Middleware {
+	var challenges []string
+	for _, authenticator := range authenticators {
+		if challenger, ok := authenticator.(Challenger); ok {
+			challenges = append(challenges, challenger.Challenge())
+		}
+	}
+
+	return transport.NewMiddleware(func(tctx transport.Context, next transport.Handler) error {
+		req := tctx.Request()
+		principal, err := Authenticate(req.Context(), req, authenticators...)
+		if err != nil {
+			if errors.Is(err, transport.ErrUnauthenticated) && len(challenges) > 0 {
+				tctx.Response().Headers().Set("WWW-Authenticate", strings.Join(challenges, ", "))
+			}
+			return err
+		}
+
+		req.WithContext(PrincipalStore.Save(req.Context(), principal))
+		return next.Serve(tctx)
+	})
+}
+
+// Config enables the authenticators of the "auth" middleware, it is read from the "auth" key of the config store
+// sessions are looked up by the application, so SessionAuthenticator is registered in code
+//
+//	{
+//	  "jwt": {"jwks_url": "https://example.auth0.com/.well-known/jwks.json", "audience": "billing"},
+//	  "api_keys": {"store_key": "api_keys"},
+//	  "roles": {"admin": ["billing.read", "billing.write"]}
+//	}
+type Config struct {
+	JWT     *JWTConfig    `json:"jwt,omitempty"`
+	APIKeys *APIKeyConfig `json:"api_keys,omitempty"`
+
+	// Roles are granted to the RoleTable built by wireset.NewAuthorizer
+	Roles map[string][]string `json:"roles,omitempty"`
+}
+
+// APIKeyConfig configures an APIKeyAuthenticator
+type APIKeyConfig struct {
+	// Header defaults to DefaultAPIKeyHeader
+	Header string `json:"header"`
+
+	// StoreKey is the key of the config store holding the list of APIKey
+	StoreKey string `json:"store_key"`
+}
+
+// NewAuthenticators builds the authenticators enabled by cfg, JWT bearer tokens are tried first
+func NewAuthenticators(ctx context.Context, store config.Store, cfg Config) (authenticators []Authenticator, err error) {
+	if cfg.JWT != nil {
+		jwtAuthenticator, err := NewJWTAuthenticator(ctx, *cfg.JWT)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, jwtAuthenticator)
+	}
+
+	if cfg.APIKeys != nil {
+		keys, err := LoadAPIKeys(ctx, store, cfg.APIKeys.StoreKey)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, NewAPIKeyAuthenticator(cfg.APIKeys.Header, keys))
+	}
+	return
+}
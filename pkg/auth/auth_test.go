@@ -0,0 +1,263 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/kibu-sh/kibu/pkg/transport/httpx"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestHandler answers with the subject of the authenticated principal
+func newTestHandler(authenticators ...Authenticator) *httpx.Handler {
+	endpoint := transport.NewEndpoint(func(ctx context.Context, req struct{}) (res *Principal, err error) {
+		return FromContext(ctx)
+	}).WithMiddleware(Middleware(authenticators...))
+	return httpx.NewHandler("/", endpoint)
+}
+
+func serve(h *httpx.Handler, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+type testKeySet struct {
+	signer jose.Signer
+	file   string
+}
+
+func newTestKeySet(t *testing.T) testKeySet {
+	return newTestKeySetWithID(t, "test")
+}
+
+func newTestKeySetWithID(t *testing.T, kid string) testKeySet {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	signer, err := jose.NewSigner(jose.SigningKey{
+		Algorithm: jose.RS256,
+		Key:       jose.JSONWebKey{Key: key, KeyID: kid, Algorithm: string(jose.RS256)},
+	}, nil)
+	require.NoError(t, err)
+
+	jwks, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: key.Public(), KeyID: kid, Algorithm: string(jose.RS256), Use: "sig"},
+	}})
+	require.NoError(t, err)
+
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(file, jwks, 0600))
+	return testKeySet{signer: signer, file: file}
+}
+
+func (k testKeySet) sign(t *testing.T, claims jwt.Claims, roles []string) string {
+	token, err := jwt.Signed(k.signer).Claims(claims).Claims(map[string]any{"roles": roles}).Serialize()
+	require.NoError(t, err)
+	return token
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	keys := newTestKeySet(t)
+	authenticator, err := NewJWTAuthenticator(context.Background(), JWTConfig{
+		JWKSFile: keys.file,
+		Issuer:   "https://issuer.test",
+		Audience: "billing",
+	})
+	require.NoError(t, err)
+	h := newTestHandler(authenticator)
+
+	request := func(token string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		return r
+	}
+
+	valid := jwt.Claims{
+		Subject:  "user-1",
+		Issuer:   "https://issuer.test",
+		Audience: jwt.Audience{"billing"},
+		Expiry:   jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}
+
+	t.Run("should store the principal of valid tokens", func(t *testing.T) {
+		w := serve(h, request(keys.sign(t, valid, []string{"billing.write"})))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var principal Principal
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &principal))
+		require.Equal(t, "user-1", principal.Subject)
+		require.Equal(t, MethodJWT, principal.Method)
+		require.Equal(t, []string{"billing.write"}, principal.Roles)
+	})
+
+	t.Run("should reject expired tokens", func(t *testing.T) {
+		expired := valid
+		expired.Expiry = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+
+		w := serve(h, request(keys.sign(t, expired, nil)))
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+		require.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+	})
+
+	t.Run("should reject tokens for another audience", func(t *testing.T) {
+		other := valid
+		other.Audience = jwt.Audience{"payroll"}
+
+		w := serve(h, request(keys.sign(t, other, nil)))
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("should reject tokens signed by another key", func(t *testing.T) {
+		w := serve(h, request(newTestKeySet(t).sign(t, valid, nil)))
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("should reject tokens without an expiry", func(t *testing.T) {
+		eternal := valid
+		eternal.Expiry = nil
+		require.Equal(t, http.StatusUnauthorized, serve(h, request(keys.sign(t, eternal, nil))).Code)
+
+		allowed, err := NewJWTAuthenticator(context.Background(), JWTConfig{
+			JWKSFile:           keys.file,
+			Issuer:             "https://issuer.test",
+			Audience:           "billing",
+			AllowMissingExpiry: true,
+		})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, serve(newTestHandler(allowed), request(keys.sign(t, eternal, nil))).Code)
+	})
+}
+
+func TestJWTAuthenticator_JWKSURL(t *testing.T) {
+	first := newTestKeySetWithID(t, "first")
+	rotated := newTestKeySetWithID(t, "rotated")
+
+	readKeys := func(files ...string) (set jose.JSONWebKeySet) {
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			require.NoError(t, err)
+
+			var keys jose.JSONWebKeySet
+			require.NoError(t, json.Unmarshal(data, &keys))
+			set.Keys = append(set.Keys, keys.Keys...)
+		}
+		return
+	}
+
+	// the server starts serving the rotated key once the authenticator is built, and holds the fetch until release is closed
+	var rotate atomic.Bool
+	fetching := make(chan struct{}, 1)
+	release := make(chan struct{})
+	releaseFetch := sync.OnceFunc(func() { close(release) })
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !rotate.Load() {
+			_ = json.NewEncoder(w).Encode(readKeys(first.file))
+			return
+		}
+		fetching <- struct{}{}
+		<-release
+		_ = json.NewEncoder(w).Encode(readKeys(first.file, rotated.file))
+	}))
+	defer srv.Close()
+	defer releaseFetch()
+
+	authenticator, err := NewJWTAuthenticator(context.Background(), JWTConfig{
+		JWKSURL:         srv.URL,
+		RefreshInterval: time.Nanosecond,
+	})
+	require.NoError(t, err)
+	h := newTestHandler(authenticator)
+	rotate.Store(true)
+
+	request := func(token string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		return r
+	}
+	claims := jwt.Claims{Subject: "user-1", Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+
+	refreshed := make(chan int)
+	go func() {
+		refreshed <- serve(h, request(rotated.sign(t, claims, nil))).Code
+	}()
+	<-fetching
+
+	known := make(chan int)
+	go func() {
+		known <- serve(h, request(first.sign(t, claims, nil))).Code
+	}()
+
+	select {
+	case code := <-known:
+		require.Equal(t, http.StatusOK, code)
+	case <-time.After(5 * time.Second):
+		t.Fatal("a request signed by a known key waited for the key set to be fetched")
+	}
+
+	releaseFetch()
+	require.Equal(t, http.StatusOK, <-refreshed)
+}
+
+func TestAPIKeyAuthenticator(t *testing.T) {
+	h := newTestHandler(NewAPIKeyAuthenticator("", []APIKey{
+		{Name: "billing-worker", SHA256: HashAPIKey("secret"), Roles: []string{"billing.write"}},
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(DefaultAPIKeyHeader, "secret")
+	w := serve(h, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"subject":"billing-worker","method":"api_key","roles":["billing.write"]}`, w.Body.String())
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(DefaultAPIKeyHeader, "guess")
+	w = serve(h, r)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.JSONEq(t, `{"message":"unauthenticated: unknown api key","status":401}`, w.Body.String())
+}
+
+func TestSessionAuthenticator(t *testing.T) {
+	h := newTestHandler(NewSessionAuthenticator("", SessionLookupFunc(func(ctx context.Context, id string) (*Principal, error) {
+		if id != "s1" {
+			return nil, ErrSessionNotFound
+		}
+		return &Principal{Subject: "user-1"}, nil
+	})))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(&http.Cookie{Name: DefaultSessionCookie, Value: "s1"})
+	w := serve(h, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"subject":"user-1","method":"session"}`, w.Body.String())
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(&http.Cookie{Name: DefaultSessionCookie, Value: "expired"})
+	require.Equal(t, http.StatusUnauthorized, serve(h, r).Code)
+}
+
+func TestMiddleware(t *testing.T) {
+	h := newTestHandler(
+		NewAPIKeyAuthenticator("", nil),
+		NewSessionAuthenticator("", SessionLookupFunc(func(ctx context.Context, id string) (*Principal, error) {
+			return nil, ErrSessionNotFound
+		})),
+	)
+
+	w := serve(h, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.Equal(t, `APIKey header="X-API-Key"`, w.Header().Get("WWW-Authenticate"))
+	require.JSONEq(t, `{"message":"unauthenticated: no credentials","status":401}`, w.Body.String())
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"slices"
+	"sync"
+)
+
+// Forbidden reports a caller that isn't allowed to call an operation, the reason is shown to the client
+//
+//	forbidden: missing permission billing.write
+func Forbidden(reason string) error {
+	return fmt.Errorf("%w: %s", transport.ErrForbidden, reason)
+}
+
+var _ transport.Authorizer = (*RoleTable)(nil)
+
+// RoleTable grants permissions to roles, a principal holds the permissions of all of its roles
+// wireset.NewAuthorizer grants the "roles" of the auth config
+//
+//	{"roles": {"admin": ["billing.read", "billing.write"], "viewer": ["billing.read"]}}
+type RoleTable struct {
+	mu    sync.RWMutex
+	roles map[string][]string
+}
+
+// NewRoleTable returns a table that doesn't grant any permission
+func NewRoleTable() *RoleTable {
+	return &RoleTable{roles: map[string][]string{}}
+}
+
+// Grant adds permissions to role
+func (t *RoleTable) Grant(role string, permissions ...string) *RoleTable {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, permission := range permissions {
+		if !slices.Contains(t.roles[role], permission) {
+			t.roles[role] = append(t.roles[role], permission)
+		}
+	}
+	return t
+}
+
+// Can reports whether any of roles was granted permission
+func (t *RoleTable) Can(roles []string, permission string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	for _, role := range roles {
+		if slices.Contains(t.roles[role], permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// Authorize implements transport.Authorizer
+// the principal of the request must hold every permission of the operation
+// requests without a principal (i.e. to public operations) are unauthenticated
+func (t *RoleTable) Authorize(ctx context.Context, check transport.AuthorizationCheck) error {
+	if len(check.Permissions) == 0 {
+		return nil
+	}
+
+	principal, err := FromContext(ctx)
+	if err != nil {
+		return Unauthenticated(ErrNoCredentials)
+	}
+
+	for _, permission := range check.Permissions {
+		if !t.Can(principal.Roles, permission) {
+			return Forbidden("missing permission " + permission)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"context"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/kibu-sh/kibu/pkg/transport/httpx"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRoleTable(t *testing.T) {
+	roles := NewRoleTable().
+		Grant("admin", "billing.read", "billing.write").
+		Grant("viewer", "billing.read")
+
+	require.True(t, roles.Can([]string{"viewer"}, "billing.read"))
+	require.False(t, roles.Can([]string{"viewer"}, "billing.write"))
+	require.True(t, roles.Can([]string{"viewer", "admin"}, "billing.write"))
+	require.False(t, roles.Can(nil, "billing.read"))
+}
+
+func TestAuthorization(t *testing.T) {
+	roles := NewRoleTable().Grant("admin", "billing.write")
+
+	var called bool
+	endpoint := transport.NewEndpoint(func(ctx context.Context, req struct{}) (res struct{}, err error) {
+		called = true
+		return
+	}).
+		WithAuthorization(transport.NewAuthorization(roles, "billing.Service.CreateInvoice", "billing.write")).
+		WithMiddleware(Middleware(NewAPIKeyAuthenticator("", []APIKey{
+			{Name: "admin", SHA256: HashAPIKey("admin"), Roles: []string{"admin"}},
+			{Name: "viewer", SHA256: HashAPIKey("viewer"), Roles: []string{"viewer"}},
+		})))
+	h := httpx.NewHandler("/", endpoint)
+
+	request := func(key string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.Header.Set(DefaultAPIKeyHeader, key)
+		return r
+	}
+
+	t.Run("should call the endpoint when the principal holds the permission", func(t *testing.T) {
+		called = false
+		require.Equal(t, http.StatusOK, serve(h, request("admin")).Code)
+		require.True(t, called)
+	})
+
+	t.Run("should return 403 without reaching the endpoint", func(t *testing.T) {
+		called = false
+		w := serve(h, request("viewer"))
+		require.Equal(t, http.StatusForbidden, w.Code)
+		require.JSONEq(t, `{"message":"forbidden: missing permission billing.write","status":403}`, w.Body.String())
+		require.False(t, called)
+	})
+
+	t.Run("should return 401 for requests without a principal", func(t *testing.T) {
+		called = false
+		h := httpx.NewHandler("/", endpoint.WithMiddleware())
+		require.Equal(t, http.StatusUnauthorized, serve(h, httptest.NewRequest(http.MethodPost, "/", nil)).Code)
+		require.False(t, called)
+	})
+
+	t.Run("should return 403 when no authorizer was injected", func(t *testing.T) {
+		called = false
+		h := httpx.NewHandler("/", endpoint.WithAuthorization(transport.NewAuthorization(nil, "billing.Service.CreateInvoice", "billing.write")))
+		require.Equal(t, http.StatusForbidden, serve(h, request("admin")).Code)
+		require.False(t, called)
+	})
+}
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"context"
+	"github.com/kibu-sh/kibu/pkg/ctxutil"
+	"github.com/stretchr/testify/require"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+	"testing"
+	"time"
+)
+
+// headerMap implements workflow.HeaderWriter
+type headerMap map[string]*commonpb.Payload
+
+func (h headerMap) Set(key string, payload *commonpb.Payload) {
+	h[key] = payload
+}
+
+func TestPrincipalPropagation(t *testing.T) {
+	propagators := ctxutil.Propagators.List()
+	principal := &Principal{
+		Subject: "user-1",
+		Method:  MethodJWT,
+		Roles:   []string{"admin"},
+		Claims:  map[string]any{"sub": "user-1", "email": "user-1@example.com"},
+	}
+
+	// what the temporal client writes when a workflow is started by an authenticated request
+	header := headerMap{}
+	ctx := PrincipalStore.Save(context.Background(), principal)
+	for _, propagator := range propagators {
+		require.NoError(t, propagator.Inject(ctx, header))
+	}
+	require.Contains(t, header, PrincipalHeaderKey)
+	require.NotContains(t, string(header[PrincipalHeaderKey].GetData()), "user-1@example.com",
+		"the raw claims must not be written to the workflow headers")
+
+	activity := func(ctx context.Context) (string, error) {
+		principal, err := FromContext(ctx)
+		if err != nil {
+			return "", err
+		}
+		return principal.Subject, nil
+	}
+
+	wf := func(ctx workflow.Context) (subjects []string, err error) {
+		principal, err := FromContext(ctx)
+		if err != nil {
+			return
+		}
+
+		var subject string
+		ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{StartToCloseTimeout: time.Minute})
+		if err = workflow.ExecuteActivity(ctx, activity).Get(ctx, &subject); err != nil {
+			return
+		}
+		return []string{principal.Subject, subject}, nil
+	}
+
+	var suite testsuite.WorkflowTestSuite
+	suite.SetContextPropagators(propagators)
+	suite.SetHeader(&commonpb.Header{Fields: header})
+
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(activity)
+	env.ExecuteWorkflow(wf)
+	require.NoError(t, env.GetWorkflowError())
+
+	var subjects []string
+	require.NoError(t, env.GetWorkflowResult(&subjects))
+	require.Equal(t, []string{"user-1", "user-1"}, subjects)
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"context"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+// DefaultSessionCookie carries the session id of a request
+const DefaultSessionCookie = "session"
+
+// ErrSessionNotFound is returned by a SessionLookup for unknown or expired sessions
+var ErrSessionNotFound = errors.New("session not found")
+
+// SessionLookup resolves the Principal of a session id (i.e. from a sessions table or a cache)
+type SessionLookup interface {
+	LookupSession(ctx context.Context, id string) (*Principal, error)
+}
+
+// SessionLookupFunc adapts a func to SessionLookup
+type SessionLookupFunc func(ctx context.Context, id string) (*Principal, error)
+
+func (f SessionLookupFunc) LookupSession(ctx context.Context, id string) (*Principal, error) {
+	return f(ctx, id)
+}
+
+var _ Authenticator = (*SessionAuthenticator)(nil)
+
+// SessionAuthenticator authenticates the session cookie of a request
+type SessionAuthenticator struct {
+	cookie   string
+	sessions SessionLookup
+}
+
+// NewSessionAuthenticator looks up the session id of cookie in sessions, DefaultSessionCookie is used when cookie is empty
+func NewSessionAuthenticator(cookie string, sessions SessionLookup) *SessionAuthenticator {
+	if cookie == "" {
+		cookie = DefaultSessionCookie
+	}
+	return &SessionAuthenticator{cookie: cookie, sessions: sessions}
+}
+
+// Authenticate implements Authenticator
+func (a *SessionAuthenticator) Authenticate(ctx context.Context, req transport.Request) (*Principal, error) {
+	cookie, err := req.Cookie(a.cookie)
+	if errors.Is(err, http.ErrNoCookie) || (err == nil && cookie.Value == "") {
+		return nil, ErrNoCredentials
+	}
+	if err != nil {
+		return nil, Unauthenticated(err)
+	}
+
+	principal, err := a.sessions.LookupSession(ctx, cookie.Value)
+	if errors.Is(err, ErrSessionNotFound) {
+		return nil, Unauthenticated(err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if principal.Method == "" {
+		principal.Method = MethodSession
+	}
+	return principal, nil
+}
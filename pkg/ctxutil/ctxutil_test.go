@@ -48,3 +48,22 @@ func TestNewContextLoader(t *testing.T) {
 		require.Equal(t, got.ID, expected.ID)
 	})
 }
+
+func TestPropagatorRegistry(t *testing.T) {
+	type User struct {
+		ID string
+	}
+
+	first := NewPropagator[User]("user", NewStore[User, key1]())
+	second := NewPropagator[User]("user", NewStore[User, key2]())
+	other := NewPropagator[User]("other", NewStore[User, key1]())
+
+	reg := NewPropagatorRegistry().
+		Register("user", first).
+		Register("other", other).
+		Register("user", second)
+
+	require.Len(t, reg.List(), 2)
+	require.Same(t, second, reg.List()[0])
+	require.Same(t, other, reg.List()[1])
+}
@@ -0,0 +1,58 @@
+package ctxutil
+
+import (
+	"go.temporal.io/sdk/workflow"
+	"sync"
+)
+
+// PropagatorRegistry collects the values carried from callers into temporal workflows and their activities
+// every propagator is keyed by the header that carries its value
+type PropagatorRegistry struct {
+	mu          sync.RWMutex
+	keys        []string
+	propagators map[string]workflow.ContextPropagator
+}
+
+// NewPropagatorRegistry returns an empty registry
+func NewPropagatorRegistry() *PropagatorRegistry {
+	return &PropagatorRegistry{propagators: map[string]workflow.ContextPropagator{}}
+}
+
+// Register adds a propagator for the header key, it replaces any propagator registered for the same key
+func (r *PropagatorRegistry) Register(key string, propagator workflow.ContextPropagator) *PropagatorRegistry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.propagators[key]; !ok {
+		r.keys = append(r.keys, key)
+	}
+	r.propagators[key] = propagator
+	return r
+}
+
+// List returns the propagators in the order their keys were first registered
+func (r *PropagatorRegistry) List() (propagators []workflow.ContextPropagator) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, key := range r.keys {
+		propagators = append(propagators, r.propagators[key])
+	}
+	return
+}
+
+// RegisterStore carries the value of a provider (i.e. a Store) through the header key of reg
+// the value is loaded again with the same provider inside workflows and activities
+//
+//	var TenantStore = ctxutil.NewStore[string, tenantKey]()
+//
+//	func init() {
+//		ctxutil.RegisterStore(ctxutil.Propagators, "tenant", TenantStore)
+//	}
+func RegisterStore[T any](reg *PropagatorRegistry, key string, provider Provider[T]) *PropagatorRegistry {
+	return reg.Register(key, NewPropagator[T](key, provider))
+}
+
+// Propagators are installed on the temporal client built by wireset, so every worker of the client uses them
+// packages register the values they carry when they are initialized (i.e. the principal of auth, the request ID of requestctx)
+var Propagators = NewPropagatorRegistry()
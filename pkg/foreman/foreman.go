@@ -18,12 +18,36 @@ type Process struct {
 	StartTimeout time.Duration
 }
 
+// State is a stage in the lifecycle of a Process
+type State string
+
+const (
+	// StateStarting is reported when a process is registered, until it calls ready
+	StateStarting State = "starting"
+
+	// StateReady is reported once a process calls ready
+	StateReady State = "ready"
+
+	// StateStopped is reported once the StartFunc of a process returns without an error
+	StateStopped State = "stopped"
+
+	// StateFailed is reported once the StartFunc of a process returns an error, or it doesn't ready up in time
+	StateFailed State = "failed"
+)
+
+// States lists every State of a Process
+var States = []State{StateStarting, StateReady, StateStopped, StateFailed}
+
+// StateObserver is notified every time a process changes state (i.e. to export it as a metric)
+type StateObserver func(process string, state State)
+
 type Manager struct {
-	tasks    *utils.SyncMap[Process]
-	errGroup *errgroup.Group
-	ctx      context.Context
-	cancel   context.CancelFunc
-	logger   *slog.Logger
+	tasks     *utils.SyncMap[Process]
+	errGroup  *errgroup.Group
+	ctx       context.Context
+	cancel    context.CancelFunc
+	logger    *slog.Logger
+	observers []StateObserver
 }
 
 type Option func(m *Manager)
@@ -40,6 +64,13 @@ func WithLogger(logger *slog.Logger) Option {
 	}
 }
 
+// WithStateObserver notifies observer of the state changes of every process
+func WithStateObserver(observer StateObserver) Option {
+	return func(m *Manager) {
+		m.observers = append(m.observers, observer)
+	}
+}
+
 func NewManager(ctx context.Context, opts ...Option) *Manager {
 	opts = append(DefaultOptions(), opts...)
 	ctx, cancel := context.WithCancel(ctx)
@@ -74,12 +105,20 @@ func (m *Manager) Register(p Process) (err error) {
 	log.Debug(fmt.Sprintf("[kibu.foreman] registering process: %s", p.Name))
 
 	m.tasks.Store(p.Name, &p)
+	m.observe(p.Name, StateStarting)
 	ready := make(chan struct{})
 
 	m.errGroup.Go(func() error {
-		return p.Start(m.ctx, func() {
+		err := p.Start(m.ctx, func() {
+			m.observe(p.Name, StateReady)
 			close(ready)
 		})
+		if err != nil {
+			m.observe(p.Name, StateFailed)
+		} else {
+			m.observe(p.Name, StateStopped)
+		}
+		return err
 	})
 
 	select {
@@ -90,11 +129,18 @@ func (m *Manager) Register(p Process) (err error) {
 		err = errors.Wrapf(m.ctx.Err(), "failed to start proc: %s", p.Name)
 		return
 	case <-time.After(p.StartTimeout):
+		m.observe(p.Name, StateFailed)
 		err = errors.Wrapf(ErrProcessFailedToReadyUp, "proc: %s", p.Name)
 		return
 	}
 }
 
+func (m *Manager) observe(process string, state State) {
+	for _, observer := range m.observers {
+		observer(process, state)
+	}
+}
+
 func (m *Manager) Wait() error {
 	return m.errGroup.Wait()
 }
@@ -3,6 +3,7 @@ package foreman
 import (
 	"context"
 	"github.com/stretchr/testify/require"
+	"sync"
 	"testing"
 	"time"
 )
@@ -32,3 +33,24 @@ func TestManager(t *testing.T) {
 	require.NoError(t, err)
 	require.True(t, stopped)
 }
+
+func TestManager_StateObserver(t *testing.T) {
+	var mu sync.Mutex
+	var states []State
+	manager := NewManager(context.Background(), WithStateObserver(func(process string, state State) {
+		mu.Lock()
+		defer mu.Unlock()
+		states = append(states, state)
+	}))
+
+	err := manager.Register(NewProcess("proc1", func(ctx context.Context, ready func()) error {
+		ready()
+		<-ctx.Done()
+		return nil
+	}))
+	require.NoError(t, err)
+
+	manager.Shutdown()
+	require.NoError(t, manager.Wait())
+	require.Equal(t, []State{StateStarting, StateReady, StateStopped}, states)
+}
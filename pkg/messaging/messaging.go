@@ -31,15 +31,29 @@ type Broker[T any] interface {
 type Event[T any] struct {
 	ID   uuid.UUID
 	Data T
+
+	// Metadata travels with the event next to its data (i.e. the trace context injected by tracing.Topic)
+	Metadata map[string]string
 }
 
 func NewEvent[T any](data T) Event[T] {
 	return Event[T]{
-		ID:   uuid.New(),
-		Data: data,
+		ID:       uuid.New(),
+		Data:     data,
+		Metadata: map[string]string{},
 	}
 }
 
+// EventMetadata implements MetadataCarrier
+func (e Event[T]) EventMetadata() map[string]string {
+	return e.Metadata
+}
+
+// MetadataCarrier is implemented by messages that carry metadata next to their data, such as Event
+type MetadataCarrier interface {
+	EventMetadata() map[string]string
+}
+
 type MessageCloneFunc[T any] func(T) (T, error)
 
 func PassThoughClone[T any](t T) (T, error) {
@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"github.com/kibu-sh/kibu/pkg/foreman"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ProcessStates exports the state of foreman processes, the gauge of the current state of a process is 1 and the others are 0
+//
+//	kibu_foreman_process_state{process="temporal-worker-0",state="ready"} 1
+type ProcessStates struct {
+	states *prometheus.GaugeVec
+}
+
+// NewProcessStates registers the foreman metrics on reg
+//
+//	foreman.NewManager(ctx, foreman.WithStateObserver(states.Observe))
+func NewProcessStates(reg prometheus.Registerer) (*ProcessStates, error) {
+	p := &ProcessStates{
+		states: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Subsystem: "foreman",
+			Name:      "process_state",
+			Help:      "Current state of a foreman process",
+		}, []string{"process", "state"}),
+	}
+	if err := reg.Register(p.states); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Observe implements foreman.StateObserver
+func (p *ProcessStates) Observe(process string, state foreman.State) {
+	for _, s := range foreman.States {
+		value := 0.0
+		if s == state {
+			value = 1
+		}
+		p.states.WithLabelValues(process, string(s)).Set(value)
+	}
+}
@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"context"
+	"github.com/kibu-sh/kibu/pkg/transport/httpx"
+	"github.com/prometheus/client_golang/prometheus"
+	"strconv"
+)
+
+var _ httpx.Observer = (*HTTPObserver)(nil)
+
+// HTTPObserver records the rate, errors and duration of the requests served by httpx handlers, per operation
+//
+//	kibu_http_requests_total{operation, method, code}
+//	kibu_http_request_duration_seconds{operation, method}
+//	kibu_http_response_bytes_total{operation, method}
+type HTTPObserver struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	written  *prometheus.CounterVec
+}
+
+// NewHTTPObserver registers the http metrics on reg
+func NewHTTPObserver(reg prometheus.Registerer) (*HTTPObserver, error) {
+	o := &HTTPObserver{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Requests served, by operation, method and status code",
+		}, []string{"operation", "method", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Time spent serving requests, by operation and method",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation", "method"}),
+		written: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "http",
+			Name:      "response_bytes_total",
+			Help:      "Bytes written to responses, by operation and method",
+		}, []string{"operation", "method"}),
+	}
+
+	for _, collector := range []prometheus.Collector{o.requests, o.duration, o.written} {
+		if err := reg.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	return o, nil
+}
+
+// ObserveRequest implements httpx.Observer
+func (o *HTTPObserver) ObserveRequest(ctx context.Context, stats httpx.RequestStats) {
+	o.requests.WithLabelValues(stats.Operation, stats.Method, strconv.Itoa(stats.StatusCode)).Inc()
+	o.duration.WithLabelValues(stats.Operation, stats.Method).Observe(stats.Duration.Seconds())
+	o.written.WithLabelValues(stats.Operation, stats.Method).Add(float64(stats.BytesWritten))
+}
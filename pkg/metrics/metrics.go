@@ -0,0 +1,35 @@
+// Package metrics exports Prometheus metrics for the endpoints, temporal workers and foreman processes of a kibu app
+// wireset.DefaultSet serves them at Path on the admin listener
+//
+//	kibu_http_requests_total{operation="billing.AccountService.GetAccount",method="GET",code="200"} 42
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+)
+
+// Namespace prefixes every metric of this package
+const Namespace = "kibu"
+
+// Path is where the admin server exposes the metrics of a Registry
+const Path = "/metrics"
+
+// NewRegistry returns a registry with the metrics of the go runtime and the current process
+func NewRegistry() (reg *prometheus.Registry, err error) {
+	reg = prometheus.NewRegistry()
+	if err = reg.Register(collectors.NewGoCollector()); err != nil {
+		return
+	}
+	err = reg.Register(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	return
+}
+
+// NewHandler serves the metrics of reg in the Prometheus exposition format
+func NewHandler(reg *prometheus.Registry) http.Handler {
+	return promhttp.HandlerFor(reg, promhttp.HandlerOpts{
+		Registry: reg,
+	})
+}
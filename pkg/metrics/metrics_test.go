@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"context"
+	"github.com/kibu-sh/kibu/pkg/foreman"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/kibu-sh/kibu/pkg/transport/httpx"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPObserver(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	observer, err := NewHTTPObserver(reg)
+	require.NoError(t, err)
+
+	endpoint := transport.NewEndpoint(func(ctx context.Context, req struct{}) (res map[string]string, err error) {
+		return map[string]string{"hello": "world"}, nil
+	})
+
+	h := httpx.NewHandler("/hello", endpoint).
+		WithOperation("greeter.Service.Hello").
+		WithObserver(observer)
+
+	for range 2 {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/hello", nil))
+	}
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP kibu_http_requests_total Requests served, by operation, method and status code
+# TYPE kibu_http_requests_total counter
+kibu_http_requests_total{code="200",method="GET",operation="greeter.Service.Hello"} 2
+# HELP kibu_http_response_bytes_total Bytes written to responses, by operation and method
+# TYPE kibu_http_response_bytes_total counter
+kibu_http_response_bytes_total{method="GET",operation="greeter.Service.Hello"} 36
+`), "kibu_http_requests_total", "kibu_http_response_bytes_total"))
+
+	require.Equal(t, 1, testutil.CollectAndCount(observer.duration))
+}
+
+func TestTemporalHandler(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	handler := NewTemporalHandler(reg).WithTags(map[string]string{"namespace": "default"})
+
+	handler.WithTags(map[string]string{"task_queue": "billing"}).Counter("temporal_request").Inc(2)
+	// tags the metric wasn't created with are dropped, missing ones are empty
+	handler.WithTags(map[string]string{"operation": "Poll"}).Counter("temporal_request").Inc(1)
+	handler.Gauge("temporal.worker.slots").Update(3)
+	handler.Timer("temporal_activity_execution_latency").Record(time.Second)
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP temporal_request temporal sdk counter temporal_request
+# TYPE temporal_request counter
+temporal_request{namespace="default",task_queue=""} 1
+temporal_request{namespace="default",task_queue="billing"} 2
+# HELP temporal_worker_slots temporal sdk gauge temporal_worker_slots
+# TYPE temporal_worker_slots gauge
+temporal_worker_slots{namespace="default"} 3
+`), "temporal_request", "temporal_worker_slots"))
+
+	count, err := testutil.GatherAndCount(reg, "temporal_activity_execution_latency")
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+}
+
+func TestProcessStates(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	states, err := NewProcessStates(reg)
+	require.NoError(t, err)
+
+	states.Observe("worker", foreman.StateStarting)
+	states.Observe("worker", foreman.StateReady)
+
+	require.Equal(t, 1.0, testutil.ToFloat64(states.states.WithLabelValues("worker", "ready")))
+	require.Equal(t, 0.0, testutil.ToFloat64(states.states.WithLabelValues("worker", "starting")))
+}
@@ -0,0 +1,191 @@
+package metrics
+
+import (
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.temporal.io/sdk/client"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+var _ client.MetricsHandler = (*TemporalHandler)(nil)
+
+// TemporalHandler exports the metrics of the temporal sdk (i.e. temporal_activity_execution_latency)
+// it is set as client.Options.MetricsHandler, and workers built from the client report through it
+//
+// the labels of a metric are the tags it is first recorded with
+// tags missing from later recordings are reported empty, and tags the metric wasn't created with are dropped
+// timers are histograms in seconds
+type TemporalHandler struct {
+	vecs *temporalVecs
+	tags map[string]string
+}
+
+// temporalVecs are shared by the handlers derived with WithTags
+type temporalVecs struct {
+	mu       sync.Mutex
+	reg      prometheus.Registerer
+	counters map[string]*labeledVec[*prometheus.CounterVec]
+	gauges   map[string]*labeledVec[*prometheus.GaugeVec]
+	timers   map[string]*labeledVec[*prometheus.HistogramVec]
+}
+
+type labeledVec[V any] struct {
+	vec    V
+	labels []string
+}
+
+// NewTemporalHandler returns a handler registering the metrics of the temporal sdk on reg as they are recorded
+func NewTemporalHandler(reg prometheus.Registerer) *TemporalHandler {
+	return &TemporalHandler{
+		vecs: &temporalVecs{
+			reg:      reg,
+			counters: map[string]*labeledVec[*prometheus.CounterVec]{},
+			gauges:   map[string]*labeledVec[*prometheus.GaugeVec]{},
+			timers:   map[string]*labeledVec[*prometheus.HistogramVec]{},
+		},
+	}
+}
+
+// WithTags implements client.MetricsHandler
+func (h *TemporalHandler) WithTags(tags map[string]string) client.MetricsHandler {
+	merged := maps.Clone(h.tags)
+	if merged == nil {
+		merged = make(map[string]string, len(tags))
+	}
+	maps.Copy(merged, tags)
+	return &TemporalHandler{vecs: h.vecs, tags: merged}
+}
+
+// Counter implements client.MetricsHandler
+func (h *TemporalHandler) Counter(name string) client.MetricsCounter {
+	h.vecs.mu.Lock()
+	defer h.vecs.mu.Unlock()
+
+	lv, err := resolveVec(h.vecs.reg, h.vecs.counters, name, h.tags, func(name string, labels []string) *prometheus.CounterVec {
+		return prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: "temporal sdk counter " + name}, labels)
+	})
+	if err != nil {
+		return client.MetricsNopHandler.Counter(name)
+	}
+
+	counter := lv.vec.WithLabelValues(labelValues(lv.labels, h.tags)...)
+	return metricsCounterFunc(func(delta int64) {
+		counter.Add(float64(delta))
+	})
+}
+
+// Gauge implements client.MetricsHandler
+func (h *TemporalHandler) Gauge(name string) client.MetricsGauge {
+	h.vecs.mu.Lock()
+	defer h.vecs.mu.Unlock()
+
+	lv, err := resolveVec(h.vecs.reg, h.vecs.gauges, name, h.tags, func(name string, labels []string) *prometheus.GaugeVec {
+		return prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: "temporal sdk gauge " + name}, labels)
+	})
+	if err != nil {
+		return client.MetricsNopHandler.Gauge(name)
+	}
+
+	gauge := lv.vec.WithLabelValues(labelValues(lv.labels, h.tags)...)
+	return metricsGaugeFunc(gauge.Set)
+}
+
+// Timer implements client.MetricsHandler
+func (h *TemporalHandler) Timer(name string) client.MetricsTimer {
+	h.vecs.mu.Lock()
+	defer h.vecs.mu.Unlock()
+
+	lv, err := resolveVec(h.vecs.reg, h.vecs.timers, name, h.tags, func(name string, labels []string) *prometheus.HistogramVec {
+		return prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    name,
+			Help:    "temporal sdk timer " + name + " in seconds",
+			Buckets: prometheus.DefBuckets,
+		}, labels)
+	})
+	if err != nil {
+		return client.MetricsNopHandler.Timer(name)
+	}
+
+	histogram := lv.vec.WithLabelValues(labelValues(lv.labels, h.tags)...)
+	return metricsTimerFunc(func(d time.Duration) {
+		histogram.Observe(d.Seconds())
+	})
+}
+
+// resolveVec returns the vec of a metric, creating and registering it with the labels of tags on first use
+// metrics that can't be registered (i.e. a name clashing with another collector) are dropped
+func resolveVec[V prometheus.Collector](
+	reg prometheus.Registerer,
+	cache map[string]*labeledVec[V],
+	name string,
+	tags map[string]string,
+	build func(name string, labels []string) V,
+) (*labeledVec[V], error) {
+	name = sanitizeName(name)
+	if lv, ok := cache[name]; ok {
+		return lv, nil
+	}
+
+	labels := make([]string, 0, len(tags))
+	for key := range tags {
+		labels = append(labels, sanitizeName(key))
+	}
+	slices.Sort(labels)
+	labels = slices.Compact(labels)
+
+	lv := &labeledVec[V]{vec: build(name, labels), labels: labels}
+	if err := reg.Register(lv.vec); err != nil {
+		var registered prometheus.AlreadyRegisteredError
+		if !errors.As(err, &registered) {
+			return nil, err
+		}
+		existing, ok := registered.ExistingCollector.(V)
+		if !ok {
+			return nil, err
+		}
+		lv.vec = existing
+	}
+
+	cache[name] = lv
+	return lv, nil
+}
+
+// labelValues orders the values of tags by labels
+func labelValues(labels []string, tags map[string]string) []string {
+	values := make([]string, len(labels))
+	for key, value := range tags {
+		if i, ok := slices.BinarySearch(labels, sanitizeName(key)); ok {
+			values[i] = value
+		}
+	}
+	return values
+}
+
+// sanitizeName replaces the characters prometheus doesn't accept in metric and label names
+//
+//	temporal.request → temporal_request
+func sanitizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+type metricsCounterFunc func(int64)
+
+func (f metricsCounterFunc) Inc(delta int64) { f(delta) }
+
+type metricsGaugeFunc func(float64)
+
+func (f metricsGaugeFunc) Update(value float64) { f(value) }
+
+type metricsTimerFunc func(time.Duration)
+
+func (f metricsTimerFunc) Record(d time.Duration) { f(d) }
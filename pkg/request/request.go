@@ -27,6 +27,27 @@ type Client struct {
 	errorDecoder    ErrorDecoder
 }
 
+// URL returns the URL the request will be sent to, including query parameters.
+func (c Client) URL() *url.URL {
+	return c.baseURL
+}
+
+// Header returns the headers that will be sent with the request.
+func (c Client) Header() http.Header {
+	return c.defaultHeader
+}
+
+// HTTPClient returns the http.Client used to send the request.
+// This is useful for protocols that start with an HTTP handshake (i.e. WebSockets).
+func (c Client) HTTPClient() *http.Client {
+	return c.c
+}
+
+// DecodeError decodes an unsuccessful response with the configured ErrorDecoder.
+func (c Client) DecodeError(res *http.Response) error {
+	return c.errorDecoder(res)
+}
+
 // WithErrorDecoder returns a new instance of Client by replacing its response error decoder.
 func (c Client) WithErrorDecoder(f ErrorDecoder) *Client {
 	c.errorDecoder = f
@@ -245,6 +266,22 @@ func (c Client) DoAsJSON(ctx context.Context, result any) (err error) {
 	return
 }
 
+// DoAsStream executes the request, checks the status code, and hands the open response body to read.
+// It is intended for long-lived responses (i.e. text/event-stream) that must be consumed incrementally.
+// The body is closed once read returns.
+func (c Client) DoAsStream(ctx context.Context, read func(body io.Reader) error) (err error) {
+	res, err := c.Do(ctx)
+	if err != nil {
+		return c.decodeResponseError(res, err)
+	}
+
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	return read(res.Body)
+}
+
 // WithUrlValues returns a new instance of Client with an updated url query parameters.
 // The supplied path is joined to the base URL.
 // A baseURL of "http://test.com" using WithUrlValues("{"key":"value"}") will produce a
@@ -0,0 +1,130 @@
+// Package requestctx holds the request ID, tenant and locale of a request in its context
+// they are carried into the temporal workflows started by the request and their activities,
+// where the same Load call returns them
+//
+//	requestID, err := requestctx.RequestIDStore.Load(ctx)
+package requestctx
+
+import (
+	"github.com/google/uuid"
+	"github.com/kibu-sh/kibu/pkg/ctxutil"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// RequestIDHeader carries the ID of a request chosen by the client or a proxy
+const RequestIDHeader = "X-Request-Id"
+
+// maxRequestIDLength bounds the IDs accepted from clients, longer IDs are replaced
+const maxRequestIDLength = 128
+
+// the temporal headers carrying the values of a request into workflows and activities
+const (
+	RequestIDHeaderKey = "kibu-request-id"
+	TenantHeaderKey    = "kibu-tenant"
+	LocaleHeaderKey    = "kibu-locale"
+)
+
+type requestIDKey struct{}
+type tenantKey struct{}
+type localeKey struct{}
+
+// RequestIDStore holds the ID of a request, it is set by Middleware
+var RequestIDStore = ctxutil.NewStore[string, requestIDKey]()
+
+// TenantStore holds the tenant of a request
+// it is never read from the request itself, the application saves it once the caller is known (i.e. from a claim of its principal)
+var TenantStore = ctxutil.NewStore[string, tenantKey]()
+
+// LocaleStore holds the preferred language of a request (i.e. "fr-CA"), it is set by Middleware
+var LocaleStore = ctxutil.NewStore[string, localeKey]()
+
+func init() {
+	ctxutil.RegisterStore(ctxutil.Propagators, RequestIDHeaderKey, RequestIDStore)
+	ctxutil.RegisterStore(ctxutil.Propagators, TenantHeaderKey, TenantStore)
+	ctxutil.RegisterStore(ctxutil.Propagators, LocaleHeaderKey, LocaleStore)
+}
+
+// Middleware saves the request ID and locale of every request
+// the request ID is read from RequestIDHeader, or generated when it is missing or malformed, and sent back in the response
+// the locale is the preferred language of Accept-Language, requests without one have no locale
+func Middleware() transport.Middleware {
+	return transport.NewMiddleware(func(tctx transport.Context, next transport.Handler) error {
+		req := tctx.Request()
+		ctx := req.Context()
+
+		requestID := req.Headers().Get(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+		ctx = RequestIDStore.Save(ctx, requestID)
+		tctx.Response().Headers().Set(RequestIDHeader, requestID)
+
+		if locale := preferredLocale(req.Headers().Get("Accept-Language")); locale != "" {
+			ctx = LocaleStore.Save(ctx, locale)
+		}
+
+		req.WithContext(ctx)
+		return next.Serve(tctx)
+	})
+}
+
+// validRequestID accepts the printable ASCII IDs of clients, so they can be logged and sent back safely
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if r > unicode.MaxASCII || !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// preferredLocale returns the language of Accept-Language with the highest weight, the first one wins ties
+//
+//	fr-CA,fr;q=0.9,en;q=0.8 → fr-CA
+func preferredLocale(header string) (locale string) {
+	best := -1.0
+	for _, entry := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(entry), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" || !validLanguageTag(tag) {
+			continue
+		}
+
+		weight := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			weight = parseWeight(q)
+		}
+		if weight > best && weight > 0 {
+			best, locale = weight, tag
+		}
+	}
+	return
+}
+
+// validLanguageTag accepts letters, digits and hyphens (i.e. en, fr-CA, zh-Hant-TW)
+func validLanguageTag(tag string) bool {
+	if len(tag) > 35 {
+		return false
+	}
+	for _, r := range tag {
+		if r != '-' && (r > unicode.MaxASCII || !unicode.IsLetter(r) && !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseWeight parses the q value of Accept-Language (i.e. 0.8), malformed values weigh nothing
+func parseWeight(q string) float64 {
+	weight, err := strconv.ParseFloat(q, 64)
+	if err != nil || weight < 0 || weight > 1 {
+		return 0
+	}
+	return weight
+}
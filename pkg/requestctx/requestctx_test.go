@@ -0,0 +1,124 @@
+package requestctx
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/kibu-sh/kibu/pkg/ctxutil"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/kibu-sh/kibu/pkg/transport/httpx"
+	"github.com/stretchr/testify/require"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// headerMap implements workflow.HeaderWriter
+type headerMap map[string]*commonpb.Payload
+
+func (h headerMap) Set(key string, payload *commonpb.Payload) {
+	h[key] = payload
+}
+
+func TestMiddleware(t *testing.T) {
+	type values struct {
+		RequestID string
+		Locale    string
+	}
+
+	endpoint := transport.NewEndpoint(func(ctx context.Context, req struct{}) (res values, err error) {
+		res.RequestID, _ = RequestIDStore.Load(ctx)
+		res.Locale, _ = LocaleStore.Load(ctx)
+		return
+	}).WithMiddleware(Middleware())
+	h := httpx.NewHandler("/ping", endpoint).WithMethods(http.MethodGet)
+
+	serve := func(headers map[string]string) (w *httptest.ResponseRecorder, res values) {
+		r := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		for key, value := range headers {
+			r.Header.Set(key, value)
+		}
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		return
+	}
+
+	w, res := serve(map[string]string{RequestIDHeader: "req-1", "Accept-Language": "en;q=0.5, fr-CA, fr;q=0.9"})
+	require.Equal(t, values{RequestID: "req-1", Locale: "fr-CA"}, res)
+	require.Equal(t, "req-1", w.Header().Get(RequestIDHeader))
+
+	w, res = serve(map[string]string{RequestIDHeader: strings.Repeat("x", maxRequestIDLength+1)})
+	require.Len(t, res.RequestID, 36, "oversized request IDs should be replaced by a uuid")
+	require.Equal(t, res.RequestID, w.Header().Get(RequestIDHeader))
+	require.Empty(t, res.Locale)
+}
+
+func TestPreferredLocale(t *testing.T) {
+	require.Equal(t, "fr-CA", preferredLocale("fr-CA,fr;q=0.9,en;q=0.8"))
+	require.Equal(t, "en", preferredLocale("de;q=0.1, en;q=0.7, *"))
+	require.Equal(t, "", preferredLocale("de;q=0, x<script>"))
+	require.Equal(t, "", preferredLocale(""))
+}
+
+func TestPropagation(t *testing.T) {
+	propagators := ctxutil.Propagators.List()
+
+	// what the temporal client writes when a workflow is started by a request
+	header := headerMap{}
+	ctx := RequestIDStore.Save(context.Background(), "req-1")
+	ctx = TenantStore.Save(ctx, "acme")
+	ctx = LocaleStore.Save(ctx, "fr-CA")
+	for _, propagator := range propagators {
+		require.NoError(t, propagator.Inject(ctx, header))
+	}
+	require.Contains(t, header, RequestIDHeaderKey)
+	require.Contains(t, header, TenantHeaderKey)
+	require.Contains(t, header, LocaleHeaderKey)
+
+	load := func(ctx ctxutil.ValueContainer) (values []string, err error) {
+		for _, store := range []ctxutil.Loader[string]{RequestIDStore, TenantStore, LocaleStore} {
+			var value string
+			if value, err = store.Load(ctx); err != nil {
+				return
+			}
+			values = append(values, value)
+		}
+		return
+	}
+
+	activity := func(ctx context.Context) ([]string, error) {
+		return load(ctx)
+	}
+
+	wf := func(ctx workflow.Context) (values []string, err error) {
+		if values, err = load(ctx); err != nil {
+			return
+		}
+
+		var fromActivity []string
+		ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{StartToCloseTimeout: time.Minute})
+		if err = workflow.ExecuteActivity(ctx, activity).Get(ctx, &fromActivity); err != nil {
+			return
+		}
+		return append(values, fromActivity...), nil
+	}
+
+	var suite testsuite.WorkflowTestSuite
+	suite.SetContextPropagators(propagators)
+	suite.SetHeader(&commonpb.Header{Fields: header})
+
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(activity)
+	env.ExecuteWorkflow(wf)
+	require.NoError(t, env.GetWorkflowError())
+
+	var values []string
+	require.NoError(t, env.GetWorkflowResult(&values))
+	require.Equal(t, []string{"req-1", "acme", "fr-CA", "req-1", "acme", "fr-CA"}, values)
+}
@@ -0,0 +1,78 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"io"
+	"sync"
+	"time"
+)
+
+var _ sdktrace.SpanExporter = (*WriterExporter)(nil)
+
+// WriterExporter writes finished spans to an io.Writer as JSON lines
+// it is meant for local development, where running a collector isn't worth it
+type WriterExporter struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+// NewWriterExporter returns an exporter writing to w
+func NewWriterExporter(w io.Writer) *WriterExporter {
+	return &WriterExporter{encoder: json.NewEncoder(w)}
+}
+
+// writtenSpan is the line written for every span
+type writtenSpan struct {
+	Name         string            `json:"name"`
+	Kind         string            `json:"kind"`
+	TraceID      string            `json:"trace_id"`
+	SpanID       string            `json:"span_id"`
+	ParentSpanID string            `json:"parent_span_id,omitempty"`
+	Start        time.Time         `json:"start"`
+	Duration     string            `json:"duration"`
+	Status       string            `json:"status"`
+	Description  string            `json:"description,omitempty"`
+	Attributes   map[string]string `json:"attributes,omitempty"`
+}
+
+// ExportSpans implements sdktrace.SpanExporter
+func (e *WriterExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, span := range spans {
+		line := writtenSpan{
+			Name:        span.Name(),
+			Kind:        span.SpanKind().String(),
+			TraceID:     span.SpanContext().TraceID().String(),
+			SpanID:      span.SpanContext().SpanID().String(),
+			Start:       span.StartTime(),
+			Duration:    span.EndTime().Sub(span.StartTime()).String(),
+			Status:      span.Status().Code.String(),
+			Description: span.Status().Description,
+		}
+
+		if parent := span.Parent(); parent.IsValid() {
+			line.ParentSpanID = parent.SpanID().String()
+		}
+
+		if attrs := span.Attributes(); len(attrs) > 0 {
+			line.Attributes = make(map[string]string, len(attrs))
+			for _, attr := range attrs {
+				line.Attributes[string(attr.Key)] = attr.Value.Emit()
+			}
+		}
+
+		if err := e.encoder.Encode(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Shutdown implements sdktrace.SpanExporter, the writer is owned by the caller and left open
+func (e *WriterExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
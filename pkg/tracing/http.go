@@ -0,0 +1,124 @@
+package tracing
+
+import (
+	"fmt"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/kibu-sh/kibu/pkg/transport/httpx"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+)
+
+// Middleware starts a server span for every request, continuing the trace of the caller
+// the trace context is extracted from the W3C traceparent and baggage headers of transport.Request
+// the span is set on the request context, so it is the parent of every span started by the endpoint
+// requests served by httpx.Handler are named after their route (i.e. GET /accounts/{id}),
+// and their span ends once the error returned by the endpoint has been encoded, so it records the status sent to the client
+//
+//	reg.Register(middleware.RegistryItem{Tags: []string{"global"}, Middleware: tracing.Middleware()})
+func Middleware() transport.Middleware {
+	return transport.NewMiddleware(func(tctx transport.Context, next transport.Handler) (err error) {
+		req := tctx.Request()
+		ctx := otel.GetTextMapPropagator().Extract(req.Context(), propagation.HeaderCarrier(req.Headers()))
+
+		attrs := []attribute.KeyValue{
+			semconv.HTTPRequestMethodKey.String(req.Method()),
+			semconv.URLPath(req.Path()),
+		}
+
+		name := req.Method()
+		httpCtx, isHTTP := tctx.(*httpx.Context)
+		if isHTTP {
+			name = req.Method() + " " + httpCtx.Route()
+			attrs = append(attrs, semconv.HTTPRoute(httpCtx.Route()))
+		}
+
+		ctx, span := Tracer().Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attrs...),
+		)
+		req.WithContext(ctx)
+
+		if isHTTP {
+			httpCtx.OnFinish(func(stats httpx.RequestStats) {
+				defer span.End()
+				// client errors (i.e. 404 Not Found) are recorded without failing the span
+				if stats.Err != nil {
+					span.RecordError(stats.Err)
+				}
+				recordStatus(span, stats.StatusCode)
+			})
+			return next.Serve(tctx)
+		}
+
+		defer span.End()
+		if err = next.Serve(tctx); err != nil {
+			recordError(span, err)
+			return
+		}
+		recordStatus(span, tctx.Response().GetStatusCode())
+		return
+	})
+}
+
+// recordStatus sets the status code of a response on span, server errors fail the span
+func recordStatus(span trace.Span, status int) {
+	if status == 0 {
+		return
+	}
+
+	span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, fmt.Sprintf("status %d", status))
+	}
+}
+
+var _ http.RoundTripper = (*RoundTripper)(nil)
+
+// RoundTripper injects the trace context of the request context into the headers of outgoing requests
+// a client span is started for every request
+//
+//	request.NewClient(baseURL).WithRoundTripper(tracing.NewRoundTripper(http.DefaultTransport))
+type RoundTripper struct {
+	next http.RoundTripper
+}
+
+// NewRoundTripper wraps next, http.DefaultTransport is used when it is nil
+func NewRoundTripper(next http.RoundTripper) *RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &RoundTripper{next: next}
+}
+
+// RoundTrip implements http.RoundTripper
+func (rt *RoundTripper) RoundTrip(r *http.Request) (res *http.Response, err error) {
+	ctx, span := Tracer().Start(r.Context(), r.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(r.Method),
+			semconv.URLFull(r.URL.String()),
+		),
+	)
+	defer span.End()
+
+	// RoundTrip must not modify the original request
+	r = r.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(r.Header))
+
+	res, err = rt.next.RoundTrip(r)
+	if err != nil {
+		recordError(span, err)
+		return
+	}
+
+	span.SetAttributes(semconv.HTTPResponseStatusCode(res.StatusCode))
+	if res.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, res.Status)
+	}
+	return
+}
@@ -0,0 +1,105 @@
+package tracing
+
+import (
+	"context"
+	"github.com/kibu-sh/kibu/pkg/messaging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var _ messaging.Topic[any] = (*Topic[any])(nil)
+
+// Topic starts a producer span around Publish and a consumer span for every message received with Stream.Next
+// the trace context of the publisher is injected into messages implementing messaging.MetadataCarrier (i.e. messaging.Event)
+// so consumers continue the trace of the publisher, see ContextFromMessage
+type Topic[T any] struct {
+	name  string
+	topic messaging.Topic[T]
+}
+
+// NewTopic wraps topic, name is reported as the destination of its spans
+//
+//	topic := tracing.NewTopic("invoices", multichannel.NewTopicWithDefaults[messaging.Event[Invoice]]())
+func NewTopic[T any](name string, topic messaging.Topic[T]) *Topic[T] {
+	return &Topic[T]{name: name, topic: topic}
+}
+
+// Publish implements messaging.Publisher
+func (t *Topic[T]) Publish(ctx context.Context, message T) (err error) {
+	ctx, span := Tracer().Start(ctx, "publish "+t.name,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingDestinationName(t.name),
+			semconv.MessagingOperationTypePublish,
+		),
+	)
+	defer span.End()
+
+	if carrier, ok := messageCarrier(message); ok {
+		otel.GetTextMapPropagator().Inject(ctx, carrier)
+	}
+
+	err = t.topic.Publish(ctx, message)
+	recordError(span, err)
+	return
+}
+
+// Subscribe implements messaging.Consumer
+func (t *Topic[T]) Subscribe(ctx context.Context) (stream messaging.Stream[T], err error) {
+	stream, err = t.topic.Subscribe(ctx)
+	if err != nil {
+		return
+	}
+	return &Stream[T]{name: t.name, Stream: stream}, nil
+}
+
+var _ messaging.Stream[any] = (*Stream[any])(nil)
+
+// Stream starts a consumer span for every message returned by Next
+// messages read from Channel aren't traced
+type Stream[T any] struct {
+	messaging.Stream[T]
+	name string
+}
+
+// Next implements messaging.Stream
+func (s *Stream[T]) Next(ctx context.Context) (message T, hasNext bool, err error) {
+	message, hasNext, err = s.Stream.Next(ctx)
+	if err != nil || !hasNext {
+		return
+	}
+
+	_, span := Tracer().Start(ContextFromMessage(ctx, message), "receive "+s.name,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingDestinationName(s.name),
+			semconv.MessagingOperationTypeReceive,
+		),
+	)
+	span.End()
+	return
+}
+
+// ContextFromMessage returns ctx with the trace context carried by message
+// spans started from the returned context continue the trace of the publisher
+//
+//	event, _, err := stream.Next(ctx)
+//	ctx = tracing.ContextFromMessage(ctx, event)
+func ContextFromMessage(ctx context.Context, message any) context.Context {
+	if carrier, ok := messageCarrier(message); ok {
+		return otel.GetTextMapPropagator().Extract(ctx, carrier)
+	}
+	return ctx
+}
+
+// messageCarrier returns the metadata of a message as a carrier
+// messages without metadata (i.e. an Event built without messaging.NewEvent) can't carry a trace
+func messageCarrier(message any) (propagation.MapCarrier, bool) {
+	carrier, ok := message.(messaging.MetadataCarrier)
+	if !ok || carrier.EventMetadata() == nil {
+		return nil, false
+	}
+	return carrier.EventMetadata(), true
+}
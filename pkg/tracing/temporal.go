@@ -0,0 +1,131 @@
+package tracing
+
+import (
+	"context"
+	"github.com/kibu-sh/kibu/pkg/ctxutil"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/workflow"
+)
+
+// HeaderKey is the temporal header carrying the trace context of workflows and activities
+const HeaderKey = "kibu-trace-context"
+
+func init() {
+	ctxutil.Propagators.Register(HeaderKey, NewWorkflowPropagator())
+}
+
+// spanContextKey stores the carried trace context in a workflow.Context
+type spanContextKey struct{}
+
+var _ ctxutil.Provider[propagation.MapCarrier] = spanContextProvider{}
+
+// spanContextProvider loads the trace context of the current span from a context.Context
+// workflow code can't start spans deterministically, so a workflow.Context holds the trace context it was started with
+// and hands it to the activities and child workflows it schedules
+type spanContextProvider struct{}
+
+func (spanContextProvider) Load(ctx ctxutil.ValueContainer) (carrier propagation.MapCarrier, err error) {
+	if goCtx, ok := ctx.(context.Context); ok {
+		carrier = propagation.MapCarrier{}
+		otel.GetTextMapPropagator().Inject(goCtx, carrier)
+	} else {
+		carrier, _ = ctx.Value(spanContextKey{}).(propagation.MapCarrier)
+	}
+
+	if len(carrier) == 0 {
+		err = ctxutil.ErrNotFoundInContext
+	}
+	return
+}
+
+func (spanContextProvider) Save(ctx context.Context, carrier propagation.MapCarrier) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
+
+func (spanContextProvider) SaveToWorkflow(ctx workflow.Context, carrier propagation.MapCarrier) workflow.Context {
+	return workflow.WithValue(ctx, spanContextKey{}, carrier)
+}
+
+// NewWorkflowPropagator carries the trace context from the caller of a workflow into the workflow,
+// and from the workflow into its activities and child workflows
+//
+// it is registered on ctxutil.Propagators
+func NewWorkflowPropagator() workflow.ContextPropagator {
+	return ctxutil.NewPropagator[propagation.MapCarrier](HeaderKey, spanContextProvider{})
+}
+
+var _ interceptor.Interceptor = (*Interceptor)(nil)
+
+// Interceptor starts a client span when a workflow is started, and a server span around every activity
+// it is both a client and a worker interceptor, so setting it on client.Options instruments the workers of the client too
+// it relies on NewWorkflowPropagator to carry the trace context through the workflow
+//
+//	opts.Interceptors = append(opts.Interceptors, tracing.NewInterceptor())
+type Interceptor struct {
+	interceptor.ClientInterceptorBase
+	interceptor.WorkerInterceptorBase
+}
+
+// NewInterceptor returns a temporal Interceptor
+func NewInterceptor() *Interceptor {
+	return &Interceptor{}
+}
+
+func (i *Interceptor) InterceptClient(next interceptor.ClientOutboundInterceptor) interceptor.ClientOutboundInterceptor {
+	return &clientOutbound{ClientOutboundInterceptorBase: interceptor.ClientOutboundInterceptorBase{Next: next}}
+}
+
+func (i *Interceptor) InterceptActivity(ctx context.Context, next interceptor.ActivityInboundInterceptor) interceptor.ActivityInboundInterceptor {
+	return &activityInbound{ActivityInboundInterceptorBase: interceptor.ActivityInboundInterceptorBase{Next: next}}
+}
+
+type clientOutbound struct {
+	interceptor.ClientOutboundInterceptorBase
+}
+
+func (c *clientOutbound) ExecuteWorkflow(ctx context.Context, in *interceptor.ClientExecuteWorkflowInput) (run client.WorkflowRun, err error) {
+	ctx, span := Tracer().Start(ctx, "StartWorkflow:"+in.WorkflowType,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("temporal.workflow.type", in.WorkflowType)),
+	)
+	defer span.End()
+
+	if in.Options != nil && in.Options.ID != "" {
+		span.SetAttributes(attribute.String("temporal.workflow.id", in.Options.ID))
+	}
+
+	run, err = c.Next.ExecuteWorkflow(ctx, in)
+	recordError(span, err)
+	return
+}
+
+type activityInbound struct {
+	interceptor.ActivityInboundInterceptorBase
+}
+
+func (a *activityInbound) ExecuteActivity(ctx context.Context, in *interceptor.ExecuteActivityInput) (res any, err error) {
+	info := activity.GetInfo(ctx)
+	ctx, span := Tracer().Start(ctx, "RunActivity:"+info.ActivityType.Name,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("temporal.activity.type", info.ActivityType.Name),
+			attribute.String("temporal.workflow.id", info.WorkflowExecution.ID),
+			attribute.String("temporal.workflow.run_id", info.WorkflowExecution.RunID),
+		),
+	)
+	defer span.End()
+
+	if info.WorkflowType != nil {
+		span.SetAttributes(attribute.String("temporal.workflow.type", info.WorkflowType.Name))
+	}
+
+	res, err = a.Next.ExecuteActivity(ctx, in)
+	recordError(span, err)
+	return
+}
@@ -0,0 +1,152 @@
+// Package tracing carries OpenTelemetry traces across the transports of kibu
+// W3C trace context is extracted from incoming requests, injected into outgoing requests,
+// carried through temporal workflows into their activities, and attached to messaging events
+package tracing
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"os"
+	"path/filepath"
+)
+
+// InstrumentationName identifies the spans created by this package
+const InstrumentationName = "github.com/kibu-sh/kibu/pkg/tracing"
+
+// Exporters selectable by Config.Exporter
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// ErrUnknownExporter is returned by NewTracerProvider when Config.Exporter isn't one of the known exporters
+var ErrUnknownExporter = errors.New("unknown trace exporter")
+
+// Config selects where spans are exported, it is read from the "telemetry" key of the config store
+//
+//	{
+//	  "exporter": "otlp",
+//	  "endpoint": "localhost:4318",
+//	  "insecure": true,
+//	  "service_name": "billing",
+//	  "sample_ratio": 0.25
+//	}
+type Config struct {
+	// Exporter is one of none, stdout or otlp, spans aren't recorded when it is empty
+	Exporter string `json:"exporter"`
+
+	// Endpoint is the host:port of an OTLP/HTTP collector, defaults to the OTEL_EXPORTER_OTLP_ENDPOINT environment
+	Endpoint string `json:"endpoint"`
+
+	// Insecure exports to the collector over plain HTTP
+	Insecure bool `json:"insecure"`
+
+	// ServiceName is reported as the service.name resource of every span
+	ServiceName string `json:"service_name"`
+
+	// SampleRatio is the fraction of new traces that are recorded, every trace is recorded when it is zero
+	// traces started by a caller follow the sampling decision of the caller
+	SampleRatio float64 `json:"sample_ratio"`
+}
+
+// DefaultConfig doesn't export spans
+var DefaultConfig = Config{
+	Exporter: ExporterNone,
+}
+
+// Tracer returns the tracer of the global provider used by every span of this package
+func Tracer() trace.Tracer {
+	return otel.Tracer(InstrumentationName)
+}
+
+// NewPropagator returns the W3C trace context and baggage propagator
+func NewPropagator() propagation.TextMapPropagator {
+	return propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	)
+}
+
+// NewTracerProvider builds a provider exporting to the exporter of cfg
+// the provider and NewPropagator are installed globally, so they apply to otel.Tracer everywhere
+// the provider must be shut down to flush the spans it buffers
+func NewTracerProvider(ctx context.Context, cfg Config) (provider *sdktrace.TracerProvider, err error) {
+	exporter, err := newExporter(ctx, cfg)
+	if err != nil {
+		return
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		semconv.ServiceName(serviceName(cfg)),
+	))
+	if err != nil {
+		return
+	}
+
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sampler(cfg)),
+	}
+	if exporter != nil {
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+
+	provider = sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(NewPropagator())
+	return
+}
+
+func newExporter(ctx context.Context, cfg Config) (sdktrace.SpanExporter, error) {
+	switch cfg.Exporter {
+	case "", ExporterNone:
+		return nil, nil
+	case ExporterStdout:
+		return NewWriterExporter(os.Stdout), nil
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, opts...)
+	}
+	return nil, errors.Wrapf(ErrUnknownExporter, "%q, expected %s, %s or %s", cfg.Exporter, ExporterNone, ExporterStdout, ExporterOTLP)
+}
+
+func sampler(cfg Config) sdktrace.Sampler {
+	if cfg.SampleRatio <= 0 || cfg.SampleRatio >= 1 {
+		return sdktrace.ParentBased(sdktrace.AlwaysSample())
+	}
+	return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))
+}
+
+func serviceName(cfg Config) string {
+	if cfg.ServiceName != "" {
+		return cfg.ServiceName
+	}
+	if name, err := os.Executable(); err == nil {
+		return filepath.Base(name)
+	}
+	return "kibu"
+}
+
+// recordError marks a span as failed by err, nil errors are ignored
+func recordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
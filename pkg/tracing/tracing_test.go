@@ -0,0 +1,167 @@
+package tracing
+
+import (
+	"context"
+	"github.com/kibu-sh/kibu/pkg/ctxutil"
+	"github.com/kibu-sh/kibu/pkg/messaging"
+	"github.com/kibu-sh/kibu/pkg/messaging/multichannel"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/kibu-sh/kibu/pkg/transport/httpx"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const testTraceParent = "00-4bf92f3577b34da6a3ce929b0e0e4736-00f067aa0ba902b7-01"
+
+func newTestRecorder(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(NewPropagator())
+	return recorder
+}
+
+func TestMiddleware(t *testing.T) {
+	recorder := newTestRecorder(t)
+
+	var endpointSpan trace.SpanContext
+	endpoint := transport.NewEndpoint(func(ctx context.Context, req struct{}) (res struct{}, err error) {
+		endpointSpan = trace.SpanContextFromContext(ctx)
+		return
+	}).WithMiddleware(Middleware())
+
+	h := httpx.NewHandler("/", endpoint).WithMethods(http.MethodPost)
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set("traceparent", testTraceParent)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, trace.SpanKindServer, spans[0].SpanKind())
+	require.Equal(t, "4bf92f3577b34da6a3ce929b0e0e4736", spans[0].SpanContext().TraceID().String())
+	require.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+	require.Equal(t, spans[0].SpanContext().SpanID(), endpointSpan.SpanID(),
+		"the endpoint should run in the context of the server span")
+}
+
+func TestMiddleware_ErrorStatus(t *testing.T) {
+	recorder := newTestRecorder(t)
+
+	var fail error
+	endpoint := transport.NewEndpoint(func(ctx context.Context, req struct{}) (res struct{}, err error) {
+		return res, fail
+	}).WithMiddleware(Middleware())
+	h := httpx.NewHandler("/accounts/{id}", endpoint)
+
+	serve := func(err error) sdktrace.ReadOnlySpan {
+		fail = err
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/accounts/1", nil))
+
+		spans := recorder.Ended()
+		return spans[len(spans)-1]
+	}
+
+	span := serve(transport.ErrForbidden)
+	require.Equal(t, "GET /accounts/{id}", span.Name())
+	require.Contains(t, span.Attributes(), semconv.HTTPRoute("/accounts/{id}"))
+	require.Contains(t, span.Attributes(), semconv.HTTPResponseStatusCode(http.StatusForbidden),
+		"the span should record the status the error was encoded with")
+	require.Equal(t, codes.Unset, span.Status().Code, "client errors should not fail the span")
+
+	span = serve(errors.New("database unavailable"))
+	require.Contains(t, span.Attributes(), semconv.HTTPResponseStatusCode(http.StatusInternalServerError))
+	require.Equal(t, codes.Error, span.Status().Code)
+}
+
+func TestRoundTripper(t *testing.T) {
+	recorder := newTestRecorder(t)
+
+	var traceParent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceParent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewRoundTripper(nil)}
+	res, err := client.Get(server.URL)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, trace.SpanKindClient, spans[0].SpanKind())
+	require.Equal(t, codes.Error, spans[0].Status().Code)
+	require.Contains(t, traceParent, spans[0].SpanContext().SpanID().String())
+}
+
+func TestWorkflowPropagator(t *testing.T) {
+	newTestRecorder(t)
+	ctx, span := Tracer().Start(context.Background(), "caller")
+	defer span.End()
+
+	var provider spanContextProvider
+	carrier, err := provider.Load(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, carrier.Get("traceparent"))
+
+	extracted := trace.SpanContextFromContext(provider.Save(context.Background(), carrier))
+	require.Equal(t, span.SpanContext().TraceID(), extracted.TraceID())
+	require.Equal(t, span.SpanContext().SpanID(), extracted.SpanID())
+
+	_, err = provider.Load(context.Background())
+	require.ErrorIs(t, err, ctxutil.ErrNotFoundInContext)
+}
+
+func TestTopic(t *testing.T) {
+	recorder := newTestRecorder(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	topic := NewTopic[messaging.Event[string]]("greetings",
+		multichannel.NewTopicWithDefaults[messaging.Event[string]]())
+
+	stream, err := topic.Subscribe(ctx)
+	require.NoError(t, err)
+	defer stream.Unsubscribe()
+
+	go func() {
+		_ = topic.Publish(ctx, messaging.NewEvent("hello"))
+	}()
+
+	event, hasNext, err := stream.Next(ctx)
+	require.NoError(t, err)
+	require.True(t, hasNext)
+	require.Equal(t, "hello", event.Data)
+
+	require.Eventually(t, func() bool {
+		return len(recorder.Ended()) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	spans := map[trace.SpanKind]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.SpanKind()] = span
+	}
+
+	producer, consumer := spans[trace.SpanKindProducer], spans[trace.SpanKindConsumer]
+	require.NotNil(t, producer)
+	require.NotNil(t, consumer)
+	require.Equal(t, producer.SpanContext().TraceID(), consumer.SpanContext().TraceID())
+	require.Equal(t, producer.SpanContext().SpanID(), consumer.Parent().SpanID())
+
+	consumerCtx := ContextFromMessage(ctx, event)
+	require.Equal(t, producer.SpanContext().TraceID(),
+		trace.SpanContextFromContext(consumerCtx).TraceID())
+}
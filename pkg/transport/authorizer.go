@@ -0,0 +1,66 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+)
+
+// AuthorizationCheck is what an Authorizer decides on
+type AuthorizationCheck struct {
+	// Operation is the ID of the operation being called
+	Operation string
+
+	// Permissions are required from the caller (i.e. //kibu:service:method permission=billing.write)
+	Permissions []string
+
+	// Request is the decoded and validated request of the operation
+	Request any
+}
+
+// Authorizer decides whether the caller of an operation may call it
+// it runs after the middleware of the endpoint (i.e. authentication) and before its func
+// errors wrapping ErrForbidden are answered with 403 Forbidden
+type Authorizer interface {
+	Authorize(ctx context.Context, check AuthorizationCheck) error
+}
+
+// AuthorizerFunc is a functional implementation of the Authorizer interface
+type AuthorizerFunc func(ctx context.Context, check AuthorizationCheck) error
+
+// Authorize implements Authorizer
+func (f AuthorizerFunc) Authorize(ctx context.Context, check AuthorizationCheck) error {
+	return f(ctx, check)
+}
+
+// Authorization binds an Authorizer to the operation of an endpoint
+// the zero value authorizes every call
+type Authorization struct {
+	Authorizer  Authorizer
+	Operation   string
+	Permissions []string
+}
+
+// NewAuthorization requires permissions from the callers of operation
+func NewAuthorization(authorizer Authorizer, operation string, permissions ...string) Authorization {
+	return Authorization{
+		Authorizer:  authorizer,
+		Operation:   operation,
+		Permissions: permissions,
+	}
+}
+
+// authorize checks a decoded request with the Authorizer
+// permissions without an Authorizer are never granted, so a missing dependency doesn't open the operation
+func (a Authorization) authorize(ctx context.Context, request any) error {
+	if a.Authorizer == nil {
+		if len(a.Permissions) > 0 {
+			return fmt.Errorf("%w: no authorizer for operation %s", ErrForbidden, a.Operation)
+		}
+		return nil
+	}
+	return a.Authorizer.Authorize(ctx, AuthorizationCheck{
+		Operation:   a.Operation,
+		Permissions: a.Permissions,
+		Request:     request,
+	})
+}
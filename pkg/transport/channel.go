@@ -0,0 +1,184 @@
+package transport
+
+import (
+	"context"
+	"github.com/pkg/errors"
+)
+
+// ErrChannelsNotSupported is returned by a ChannelEndpoint when the transport cannot open a bidirectional connection
+var ErrChannelsNotSupported = errors.New("bidirectional channels are not supported by this transport")
+
+// Conn is a transport specific bidirectional connection carrying whole messages
+// implementations are provided by transports (i.e. httpx upgrades requests to WebSockets)
+type Conn interface {
+	// Send encodes msg and writes it to the peer as a single message
+	Send(ctx context.Context, msg any) error
+
+	// Receive blocks until the next message arrives and decodes it into msg
+	// io.EOF is returned once the peer closes the connection normally
+	Receive(ctx context.Context, msg any) error
+
+	// Close ends the connection, a non-nil err is reported to the peer as the reason
+	Close(err error) error
+
+	// Done is closed once the connection ends
+	Done() <-chan struct{}
+}
+
+// Channeler is implemented by transport contexts that can be upgraded to a bidirectional connection
+type Channeler interface {
+	OpenChannel() (Conn, error)
+}
+
+// Channel is the typed view of a Conn handed to a ChannelEndpointFunc
+// In is received from the client and Out is sent to it
+type Channel[In, Out any] interface {
+	// Receive blocks until the client sends the next message
+	// io.EOF is returned once the client closes the connection
+	Receive(ctx context.Context) (In, error)
+
+	// Send writes a message to the client
+	Send(ctx context.Context, msg Out) error
+
+	// Close ends the connection before the endpoint returns
+	Close() error
+}
+
+// ChannelEndpointFunc is a functional implementation of ChannelEndpoint
+// ctx is cancelled when the connection is closed
+type ChannelEndpointFunc[Req, In, Out any] func(ctx context.Context, request Req, channel Channel[In, Out]) (err error)
+
+// ChannelEndpoint is the bidirectional counterpart of Endpoint
+// it decodes and validates the request that opens the connection, then exchanges messages until the func returns
+type ChannelEndpoint[Req, In, Out any] struct {
+	Func          ChannelEndpointFunc[Req, In, Out]
+	Validator     Validator
+	Middleware    []Middleware
+	Authorization Authorization
+}
+
+func NewChannelEndpoint[Req, In, Out any](
+	endpointFunc ChannelEndpointFunc[Req, In, Out],
+) (ep *ChannelEndpoint[Req, In, Out]) {
+	ep = &ChannelEndpoint[Req, In, Out]{
+		Func: endpointFunc,
+	}
+	return
+}
+
+func (endpoint ChannelEndpoint[Req, In, Out]) WithValidator(validator Validator) ChannelEndpoint[Req, In, Out] {
+	endpoint.Validator = validator
+	return endpoint
+}
+
+// WithMiddleware sets middleware that runs once per connection, before the func is called
+func (endpoint ChannelEndpoint[Req, In, Out]) WithMiddleware(middleware ...Middleware) ChannelEndpoint[Req, In, Out] {
+	endpoint.Middleware = middleware
+	return endpoint
+}
+
+// WithAuthorization checks every connection with an Authorizer after the middleware and before the func
+func (endpoint ChannelEndpoint[Req, In, Out]) WithAuthorization(authorization Authorization) ChannelEndpoint[Req, In, Out] {
+	endpoint.Authorization = authorization
+	return endpoint
+}
+
+// Serve implements transport.Handler
+// the connection is upgraded once the middleware and authorization have accepted the request,
+// errors raised before that are encoded like any other endpoint (i.e. 401 Unauthorized with its headers)
+// errors raised afterward close the connection with the error as the reason
+func (endpoint ChannelEndpoint[Req, In, Out]) Serve(tctx Context) (err error) {
+	codec := tctx.Codec()
+	rawRes := tctx.Response()
+	rawCtx := tctx.Request().Context()
+
+	channeler, ok := tctx.(Channeler)
+	if !ok {
+		return codec.EncodeError(rawCtx, rawRes, ErrChannelsNotSupported)
+	}
+
+	decoded, err := decodeRequest[Req](tctx, endpoint.Validator)
+	if err != nil {
+		return codec.EncodeError(rawCtx, rawRes, err)
+	}
+
+	var conn Conn
+	err = ApplyMiddleware(endpoint.asHandler(*decoded, channeler, &conn), endpoint.Middleware...).Serve(tctx)
+	if errors.Is(err, ErrResponseIntercepted) || errors.Is(err, context.Canceled) {
+		err = nil
+	}
+
+	_, panicked := AsPanicError(err)
+	if conn == nil {
+		if err == nil || panicked {
+			// the transport decides how the panic is reported
+			return err
+		}
+		return codec.EncodeError(rawCtx, rawRes, err)
+	}
+
+	closeErr := conn.Close(err)
+	if panicked {
+		// the connection is closed with a generic reason, the transport decides how the panic is reported
+		return err
+	}
+	return closeErr
+}
+
+// asHandler converts the endpoint func into a HandlerFunc that upgrades the connection once the request is authorized
+// the opened connection is stored in conn, so Serve knows whether errors must close it
+func (endpoint ChannelEndpoint[Req, In, Out]) asHandler(req Req, channeler Channeler, conn *Conn) HandlerFunc {
+	return func(tctx Context) (err error) {
+		defer RecoverPanic(&err)
+
+		if err = endpoint.Authorization.authorize(ContextStore.Save(tctx.Request().Context(), tctx), req); err != nil {
+			return
+		}
+
+		// a rejected upgrade has already written its response and is reported as ErrResponseIntercepted
+		opened, err := channeler.OpenChannel()
+		if err != nil {
+			return
+		}
+		*conn = opened
+
+		ctx, cancel := context.WithCancel(tctx.Request().Context())
+		defer cancel()
+
+		go func() {
+			select {
+			case <-opened.Done():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		// allows endpoint to access the original transport context with a signature of context.Context
+		envelopedTransportCtx := ContextStore.Save(ctx, tctx)
+		return endpoint.Func(envelopedTransportCtx, req, NewChannel[In, Out](opened))
+	}
+}
+
+var _ Channel[any, any] = (*channel[any, any])(nil)
+
+type channel[In, Out any] struct {
+	conn Conn
+}
+
+// NewChannel wraps a transport Conn with a typed Channel
+func NewChannel[In, Out any](conn Conn) Channel[In, Out] {
+	return &channel[In, Out]{conn: conn}
+}
+
+func (c *channel[In, Out]) Receive(ctx context.Context) (msg In, err error) {
+	err = c.conn.Receive(ctx, &msg)
+	return
+}
+
+func (c *channel[In, Out]) Send(ctx context.Context, msg Out) error {
+	return c.conn.Send(ctx, msg)
+}
+
+func (c *channel[In, Out]) Close() error {
+	return c.conn.Close(nil)
+}
@@ -14,9 +14,11 @@ type EndpointFunc[Req, Res any] func(ctx context.Context, request Req) (response
 // Endpoint is any function that can be modeled as service call.
 // These should remain transport agnostic and are used to implement business logic.
 type Endpoint[Req, Res any] struct {
-	Func       EndpointFunc[Req, Res]
-	Validator  Validator
-	Middleware []Middleware
+	Func          EndpointFunc[Req, Res]
+	Validator     Validator
+	Middleware    []Middleware
+	Authorization Authorization
+	Idempotency   Idempotency
 }
 
 func NewEndpoint[Req, Res any](
@@ -38,37 +40,52 @@ func (endpoint Endpoint[Req, Res]) WithMiddleware(middleware ...Middleware) Endp
 	return endpoint
 }
 
+// WithAuthorization checks every call with an Authorizer after the middleware and before the func
+//
+//	transport.NewEndpoint(svc.GetInvoice).WithAuthorization(transport.NewAuthorization(authorizer, "billing.GetInvoice", "billing.read"))
+func (endpoint Endpoint[Req, Res]) WithAuthorization(authorization Authorization) Endpoint[Req, Res] {
+	endpoint.Authorization = authorization
+	return endpoint
+}
+
+// WithIdempotency replays the first response of repeated calls after the middleware and authorization
+//
+//	transport.NewEndpoint(svc.CreateInvoice).WithIdempotency(idempotency.NewReplayer(store))
+func (endpoint Endpoint[Req, Res]) WithIdempotency(idempotency Idempotency) Endpoint[Req, Res] {
+	endpoint.Idempotency = idempotency
+	return endpoint
+}
+
 // Serve implements transport.Handler
 // TODO: benchmark value receiver vs pointer receiver (maybe have request overhead)
 func (endpoint Endpoint[Req, Res]) Serve(tctx Context) (err error) {
-	decoded := new(Req)
 	codec := tctx.Codec()
-	rawReq := tctx.Request()
 	rawRes := tctx.Response()
 	rawCtx := tctx.Request().Context()
 
-	if err = codec.Decode(rawCtx, rawReq, decoded); err != nil {
+	decoded, err := decodeRequest[Req](tctx, endpoint.Validator)
+	if err != nil {
 		return codec.EncodeError(rawCtx, rawRes, err)
 	}
 
-	if endpoint.Validator != nil {
-		if err = endpoint.Validator.Validate(rawCtx, decoded); err != nil {
-			return codec.EncodeError(rawCtx, rawRes, err)
-		}
-	}
-
-	if v, ok := asAny(decoded).(PayloadValidator); ok {
-		if err = v.Validate(); err != nil {
-			return codec.EncodeError(rawCtx, rawRes, err)
+	var finish func(error)
+	defer func() {
+		if finish != nil {
+			finish(err)
 		}
-	}
+	}()
 
-	response, err := endpoint.execute(tctx, *decoded)
+	response, err := endpoint.execute(tctx, *decoded, &finish)
 	if errors.Is(err, ErrResponseIntercepted) {
 		err = nil
 		return
 	}
 
+	if _, ok := AsPanicError(err); ok {
+		// panics are left to the transport, which decides how they are reported
+		return
+	}
+
 	if err != nil {
 		return codec.EncodeError(rawCtx, rawRes, err)
 	}
@@ -77,22 +94,57 @@ func (endpoint Endpoint[Req, Res]) Serve(tctx Context) (err error) {
 }
 
 // execute applies all middleware before execution of the primary endpoint.Func and captures the response
-func (endpoint Endpoint[Req, Res]) execute(tctx Context, req Req) (res Res, err error) {
-	err = ApplyMiddleware(endpoint.asHandlerWithRespCapture(req, &res), endpoint.Middleware...).Serve(tctx)
+// finish is set when the Idempotency of the endpoint needs to see the response once it is written
+func (endpoint Endpoint[Req, Res]) execute(tctx Context, req Req, finish *func(error)) (res Res, err error) {
+	err = ApplyMiddleware(endpoint.asHandlerWithRespCapture(req, &res, finish), endpoint.Middleware...).Serve(tctx)
 	return
 }
 
 // asHandlerWithRespCapture converts the endpoint func into a HandlerFunc
 // the response pointer is overwritten when the HandlerFunc is executed
-func (endpoint Endpoint[Req, Res]) asHandlerWithRespCapture(req Req, res *Res) HandlerFunc {
+// a panic of the endpoint func is returned as a PanicError, so middleware observe it like any other error
+func (endpoint Endpoint[Req, Res]) asHandlerWithRespCapture(req Req, res *Res, finish *func(error)) HandlerFunc {
 	return func(tctx Context) (err error) {
+		defer RecoverPanic(&err)
 		// allows endpoint to access the original transport context with a signature of context.Context
 		envelopedTransportCtx := ContextStore.Save(tctx.Request().Context(), tctx)
+		if err = endpoint.Authorization.authorize(envelopedTransportCtx, req); err != nil {
+			return
+		}
+		if endpoint.Idempotency != nil {
+			if *finish, err = endpoint.Idempotency.Begin(envelopedTransportCtx, tctx, req); err != nil {
+				return
+			}
+		}
 		*res, err = endpoint.Func(envelopedTransportCtx, req)
 		return
 	}
 }
 
+// decodeRequest decodes and validates the request of an endpoint
+// decoding errors are wrapped as a BindingError and validation errors as a ValidationError
+func decodeRequest[Req any](tctx Context, validator Validator) (decoded *Req, err error) {
+	decoded = new(Req)
+	rawCtx := tctx.Request().Context()
+
+	if err = tctx.Codec().Decode(rawCtx, tctx.Request(), decoded); err != nil {
+		return nil, NewBindingError(err)
+	}
+
+	if validator != nil {
+		if err = validator.Validate(rawCtx, decoded); err != nil {
+			return nil, NewValidationError(err)
+		}
+	}
+
+	if v, ok := asAny(decoded).(PayloadValidator); ok {
+		if err = v.Validate(); err != nil {
+			return nil, NewValidationError(err)
+		}
+	}
+	return
+}
+
 func asAny[T any](t *T) any {
 	return t
 }
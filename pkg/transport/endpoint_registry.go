@@ -2,18 +2,22 @@ package transport
 
 import (
 	orderedmap "github.com/wk8/go-ordered-map/v2"
+	"strings"
 )
 
 type Operation interface {
 	ID() string
 	Register(Service)
 	WithHandler(Handler)
+	Handler() Handler
+	Info() OperationInfo
 }
 
 type Service interface {
 	ID() string
 	Register(Registry)
 	WithOperations(...Operation)
+	Operations() []Operation
 }
 
 type Registry interface {
@@ -22,6 +26,13 @@ type Registry interface {
 	Filter(func(Service) bool) []Service
 }
 
+// NewRegistry returns an empty Registry that lists services in the order they were registered
+func NewRegistry() Registry {
+	return &registry{
+		cache: orderedmap.New[string, Service](),
+	}
+}
+
 type registry struct {
 	cache *orderedmap.OrderedMap[string, Service]
 }
@@ -42,3 +53,140 @@ func (e *registry) Filter(filter func(Service) bool) (result []Service) {
 	}
 	return
 }
+
+// LookupOperation finds an operation by the ID generated by kibugenv2
+// the service is resolved from everything before the last dot
+//
+//	billingv1.Service.GetAccount → billingv1.Service
+func LookupOperation(reg Registry, id string) (Operation, bool) {
+	sep := strings.LastIndex(id, ".")
+	if sep < 0 {
+		return nil, false
+	}
+
+	svc, ok := reg.GetByID(id[:sep])
+	if !ok {
+		return nil, false
+	}
+
+	for _, op := range svc.Operations() {
+		if op.ID() == id {
+			return op, true
+		}
+	}
+	return nil, false
+}
+
+// NewService returns a Service that can be registered with a Registry
+//
+//	svc := transport.NewService("billingv1.Service")
+//	svc.WithOperations(transport.NewOperation("billingv1.Service.GetAccount", endpoint))
+//	svc.Register(reg)
+func NewService(id string) Service {
+	return &service{
+		id:         id,
+		operations: orderedmap.New[string, Operation](),
+	}
+}
+
+type service struct {
+	id         string
+	operations *orderedmap.OrderedMap[string, Operation]
+}
+
+func (s *service) ID() string {
+	return s.id
+}
+
+func (s *service) Register(reg Registry) {
+	reg.Register(s)
+}
+
+func (s *service) WithOperations(operations ...Operation) {
+	for _, op := range operations {
+		s.operations.Set(op.ID(), op)
+	}
+}
+
+func (s *service) Operations() (result []Operation) {
+	for op := range s.operations.ValuesFromOldest() {
+		result = append(result, op)
+	}
+	return
+}
+
+// OperationInfo describes how an operation is served for runtime introspection
+// type names are qualified by the package that declares them (i.e. billingv1.GetAccountRequest)
+type OperationInfo struct {
+	ID        string   `json:"id"`
+	Path      string   `json:"path,omitempty"`
+	Methods   []string `json:"methods,omitempty"`
+	Transport string   `json:"transport,omitempty"`
+	Stream    string   `json:"stream,omitempty"`
+	Request   string   `json:"request,omitempty"`
+	Response  string   `json:"response,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	Public    bool     `json:"public,omitempty"`
+
+	// Permissions are checked by the Authorization of the operation
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+// ServiceInfo lists the operations of a registered service
+type ServiceInfo struct {
+	ID         string          `json:"id"`
+	Operations []OperationInfo `json:"operations"`
+}
+
+// Describe lists every service of the registry in the order they were registered
+func Describe(reg Registry) (result []ServiceInfo) {
+	for _, svc := range reg.Filter(func(Service) bool { return true }) {
+		info := ServiceInfo{ID: svc.ID(), Operations: []OperationInfo{}}
+		for _, op := range svc.Operations() {
+			info.Operations = append(info.Operations, op.Info())
+		}
+		result = append(result, info)
+	}
+	return
+}
+
+// NewOperation returns an Operation served by handler
+func NewOperation(id string, handler Handler) Operation {
+	return NewOperationWithInfo(OperationInfo{ID: id}, handler)
+}
+
+// NewOperationWithInfo returns an Operation served by handler that reports info when introspected
+// the ID of the operation is info.ID
+func NewOperationWithInfo(info OperationInfo, handler Handler) Operation {
+	return &operation{
+		id:      info.ID,
+		info:    info,
+		handler: handler,
+	}
+}
+
+type operation struct {
+	id      string
+	info    OperationInfo
+	handler Handler
+}
+
+func (o *operation) ID() string {
+	return o.id
+}
+
+func (o *operation) Register(svc Service) {
+	svc.WithOperations(o)
+}
+
+func (o *operation) WithHandler(handler Handler) {
+	o.handler = handler
+}
+
+func (o *operation) Handler() Handler {
+	return o.handler
+}
+
+func (o *operation) Info() OperationInfo {
+	return o.info
+}
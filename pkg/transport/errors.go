@@ -0,0 +1,139 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Locations of a request a FieldError can refer to
+const (
+	LocationPath   = "path"
+	LocationQuery  = "query"
+	LocationHeader = "header"
+	LocationCookie = "cookie"
+	LocationBody   = "body"
+)
+
+var (
+	// ErrUnauthenticated is returned when a request doesn't carry valid credentials
+	// transports answer it with 401 Unauthorized
+	ErrUnauthenticated = errors.New("unauthenticated")
+
+	// ErrForbidden is returned by an Authorizer when the caller isn't allowed to call an operation
+	// transports answer it with 403 Forbidden
+	ErrForbidden = errors.New("forbidden")
+
+	// ErrRateLimited is returned when a caller exceeds its rate limit
+	// transports answer it with 429 Too Many Requests
+	ErrRateLimited = errors.New("rate limited")
+
+	// ErrConflict is returned when a request conflicts with another one (i.e. a duplicate that is still in flight)
+	// transports answer it with 409 Conflict
+	ErrConflict = errors.New("conflict")
+
+	// ErrUnprocessable is returned when a well-formed request can't be processed (i.e. an idempotency key reused for another request)
+	// transports answer it with 422 Unprocessable Entity
+	ErrUnprocessable = errors.New("unprocessable")
+)
+
+// FieldError describes a single field of a request that couldn't be bound or failed validation
+// Field is the dotted path of the field as the client sent it (i.e. the query param name or the json key)
+type FieldError struct {
+	Field    string `json:"field,omitempty"`
+	Location string `json:"location,omitempty"`
+	Reason   string `json:"reason"`
+}
+
+func (f FieldError) Error() string {
+	var parts []string
+	if f.Location != "" {
+		parts = append(parts, f.Location)
+	}
+	if f.Field != "" {
+		parts = append(parts, f.Field)
+	}
+	if len(parts) == 0 {
+		return f.Reason
+	}
+	return fmt.Sprintf("%s: %s", strings.Join(parts, " "), f.Reason)
+}
+
+// FieldErrors is a list of FieldError that can be returned by a Decoder or Validator
+// the fields are reported individually by encoders that support it
+type FieldErrors []FieldError
+
+func (f FieldErrors) Error() string {
+	messages := make([]string, 0, len(f))
+	for _, field := range f {
+		messages = append(messages, field.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// BindingError is returned by Endpoint.Serve when a request can't be decoded into the type accepted by an EndpointFunc
+// this is always a client error such as malformed JSON or a query param that isn't a number
+type BindingError struct {
+	Fields FieldErrors
+	Err    error
+}
+
+// NewBindingError wraps an error raised by a Decoder
+// decoders that know which field failed should return a BindingError or FieldErrors themselves
+func NewBindingError(err error) *BindingError {
+	var bindingErr *BindingError
+	if errors.As(err, &bindingErr) {
+		return bindingErr
+	}
+	return &BindingError{
+		Fields: fieldErrorsFrom(err),
+		Err:    err,
+	}
+}
+
+func (e *BindingError) Error() string {
+	return fmt.Sprintf("failed to bind request: %v", e.Err)
+}
+
+func (e *BindingError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError is returned by Endpoint.Serve when a decoded request is rejected by a Validator or PayloadValidator
+type ValidationError struct {
+	Fields FieldErrors
+	Err    error
+}
+
+// NewValidationError wraps an error raised by a Validator or PayloadValidator
+func NewValidationError(err error) *ValidationError {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr
+	}
+	return &ValidationError{
+		Fields: fieldErrorsFrom(err),
+		Err:    err,
+	}
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid request: %v", e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+func fieldErrorsFrom(err error) FieldErrors {
+	var fields FieldErrors
+	if errors.As(err, &fields) {
+		return fields
+	}
+
+	var field FieldError
+	if errors.As(err, &field) {
+		return FieldErrors{field}
+	}
+	return nil
+}
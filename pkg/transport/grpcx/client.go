@@ -0,0 +1,92 @@
+package grpcx
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/kibu-sh/kibu/pkg/transport/httpx"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"strings"
+)
+
+// Invoke calls a method served by a Service
+// params of req are sent as metadata the same way httpx.NewClientRequest sends them as part of a request
+// errors returned by the service are decoded as httpx.DefaultJSONError
+//
+//	err = grpcx.Invoke(ctx, conn, "/billingv1.Service/GetAccount", req, &res)
+func Invoke(ctx context.Context, conn grpc.ClientConnInterface, method string, req any, res any, opts ...grpc.CallOption) error {
+	params, err := httpx.EncodeRequestParams(req)
+	if err != nil {
+		return err
+	}
+
+	md := metadata.MD{}
+	for key, values := range params.Header {
+		md.Append(key, values...)
+	}
+
+	var cookies []string
+	for name, values := range params.Cookie {
+		for _, value := range values {
+			cookies = append(cookies, (&http.Cookie{Name: name, Value: value}).String())
+		}
+	}
+
+	if len(cookies) > 0 {
+		md.Set("cookie", strings.Join(cookies, "; "))
+	}
+
+	if len(params.Path) > 0 {
+		md.Set(PathParamsMetadataKey, params.Path.Encode())
+	}
+
+	if len(params.Query) > 0 {
+		md.Set(QueryMetadataKey, params.Query.Encode())
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode request")
+	}
+
+	var reply json.RawMessage
+	var trailer metadata.MD
+	ctx = metadata.NewOutgoingContext(ctx, metadata.Join(md, outgoingMetadata(ctx)))
+	opts = append([]grpc.CallOption{grpc.CallContentSubtype(ContentSubtype), grpc.Trailer(&trailer)}, opts...)
+
+	if err = conn.Invoke(ctx, method, json.RawMessage(body), &reply, opts...); err != nil {
+		return decodeError(err, trailer)
+	}
+
+	if res == nil || len(reply) == 0 || string(reply) == "null" {
+		return nil
+	}
+	return json.Unmarshal(reply, res)
+}
+
+func outgoingMetadata(ctx context.Context) metadata.MD {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	return md
+}
+
+// decodeError restores the error encoded by the service from the trailer of a failed call
+// failures that never reached an endpoint are mapped from their gRPC status
+func decodeError(err error, trailer metadata.MD) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	errRes := httpx.DefaultJSONError{
+		Status:  httpStatusFromCode(st.Code()),
+		Message: st.Message(),
+	}
+
+	if encoded := trailer.Get(ErrorMetadataKey); len(encoded) > 0 {
+		_ = json.Unmarshal([]byte(encoded[0]), &errRes)
+	}
+	return errRes
+}
@@ -0,0 +1,26 @@
+package grpcx
+
+import (
+	"encoding/json"
+	"google.golang.org/grpc/encoding"
+)
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+// jsonCodec encodes gRPC messages as JSON
+// handlers exchange json.RawMessage, so bodies written by a transport.Codec pass through untouched
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return ContentSubtype
+}
@@ -0,0 +1,106 @@
+package grpcx
+
+import (
+	"bytes"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/kibu-sh/kibu/pkg/transport/httpx"
+	"net/http"
+)
+
+var _ transport.Context = (*Context)(nil)
+
+// Context is the transport.Context of a single gRPC call
+// its request reads the message as a JSON body, and params from the metadata of the call
+type Context struct {
+	req   *httpx.Request
+	res   *Response
+	codec transport.Codec
+}
+
+func (c *Context) Codec() transport.Codec {
+	return c.codec
+}
+
+func (c *Context) Request() transport.Request {
+	return c.req
+}
+
+func (c *Context) Response() transport.Response {
+	return c.res
+}
+
+var _ transport.Response = (*Response)(nil)
+
+// Response buffers what a handler writes so it can be returned as the reply message
+// headers are sent as header metadata once the handler returns
+type Response struct {
+	headers http.Header
+	status  int
+	body    *bytes.Buffer
+}
+
+func NewResponse() *Response {
+	return &Response{
+		headers: http.Header{},
+		body:    new(bytes.Buffer),
+	}
+}
+
+func (r *Response) Write(b []byte) (int, error) {
+	// if the status code has not been set, default to 200
+	// this is implied on the first write of the response
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(b)
+}
+
+func (r *Response) Headers() http.Header {
+	return r.headers
+}
+
+func (r *Response) SetStatusCode(code int) {
+	r.status = code
+}
+
+// GetStatusCode returns 204 for handlers that neither set a status nor wrote a body
+func (r *Response) GetStatusCode() int {
+	if r.status == 0 {
+		return http.StatusNoContent
+	}
+	return r.status
+}
+
+func (r *Response) BytesWritten() int64 {
+	return int64(r.body.Len())
+}
+
+// DelCookie is a no-op, gRPC has no cookies
+func (r *Response) DelCookie(cookie http.Cookie) transport.Response {
+	return r
+}
+
+// DelCookieByName is a no-op, gRPC has no cookies
+func (r *Response) DelCookieByName(name string) transport.Response {
+	return r
+}
+
+// SetCookie is a no-op, gRPC has no cookies
+func (r *Response) SetCookie(cookie http.Cookie) transport.Response {
+	return r
+}
+
+// Redirect reports the location in the header metadata, clients decide whether to follow it
+func (r *Response) Redirect(req transport.Request, url string, code int) {
+	r.headers.Set("Location", url)
+	r.SetStatusCode(code)
+}
+
+func (r *Response) BodyBuffer() *bytes.Buffer {
+	return r.body
+}
+
+// Underlying returns nil, the reply is only sent once the handler returns
+func (r *Response) Underlying() any {
+	return nil
+}
@@ -0,0 +1,259 @@
+// Package grpcx serves kibu endpoints as unary gRPC methods
+// messages are JSON encoded, so the same transport.Codec, validators and middleware run over HTTP and gRPC
+// path, query and cookie params travel as metadata next to the message and headers map to metadata of the same name
+package grpcx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/kibu-sh/kibu/pkg/transport/httpx"
+	"github.com/kibu-sh/kibu/pkg/transport/middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// ContentSubtype selects the JSON codec, requests are sent with the content type application/grpc+json
+	ContentSubtype = "json"
+
+	// PathParamsMetadataKey carries the url encoded path params of a request
+	PathParamsMetadataKey = "kibu-path-params"
+
+	// QueryMetadataKey carries the url encoded query of a request
+	QueryMetadataKey = "kibu-query"
+
+	// ErrorMetadataKey carries the encoded error of a failed call in the trailer
+	// it ends with -bin so gRPC transmits it as binary
+	ErrorMetadataKey = "kibu-error-bin"
+)
+
+// ServiceFactory is implemented by generated controllers of services declared with //kibu:service grpc
+type ServiceFactory interface {
+	GRPCServiceFactory(*middleware.Registry) *Service
+}
+
+// Method is a single unary method of a Service
+type Method struct {
+	Name    string
+	Handler transport.Handler
+}
+
+func NewMethod(name string, handler transport.Handler) Method {
+	return Method{
+		Name:    name,
+		Handler: handler,
+	}
+}
+
+// Service adapts a set of transport.Handler into a gRPC service
+//
+//	grpcx.NewService("billingv1.Service", grpcx.NewMethod("GetAccount", transport.NewEndpoint(svc.GetAccount)))
+type Service struct {
+	Name    string
+	Methods []Method
+	Codec   transport.Codec
+}
+
+func NewService(name string, methods ...Method) *Service {
+	return &Service{
+		Name:    name,
+		Methods: methods,
+		Codec:   httpx.DefaultCodec,
+	}
+}
+
+// WithCodec replaces the codec used to decode requests and encode responses and errors
+func (s *Service) WithCodec(codec transport.Codec) *Service {
+	s.Codec = codec
+	return s
+}
+
+// ServiceDesc describes the service to a grpc.ServiceRegistrar
+func (s *Service) ServiceDesc() *grpc.ServiceDesc {
+	desc := &grpc.ServiceDesc{
+		ServiceName: s.Name,
+		HandlerType: (*any)(nil),
+		Metadata:    s.Name,
+	}
+
+	for _, method := range s.Methods {
+		desc.Methods = append(desc.Methods, grpc.MethodDesc{
+			MethodName: method.Name,
+			Handler:    s.methodHandler(method),
+		})
+	}
+	return desc
+}
+
+// Register registers every service with registrar
+func Register(registrar grpc.ServiceRegistrar, services ...*Service) {
+	for _, svc := range services {
+		registrar.RegisterService(svc.ServiceDesc(), svc)
+	}
+}
+
+// BindServices builds the services of every factory with the middleware of reg
+func BindServices(factories []ServiceFactory, reg *middleware.Registry) (services []*Service) {
+	for _, factory := range factories {
+		services = append(services, factory.GRPCServiceFactory(reg))
+	}
+	return
+}
+
+// NewServer returns a grpc.Server with every service registered
+// the JSON codec is selected by the content subtype of each call, so it needs no server option
+func NewServer(services []*Service, opts ...grpc.ServerOption) *grpc.Server {
+	server := grpc.NewServer(opts...)
+	Register(server, services...)
+	return server
+}
+
+func (s *Service) methodHandler(method Method) func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	fullMethod := "/" + s.Name + "/" + method.Name
+	return func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+		var msg json.RawMessage
+		if err := dec(&msg); err != nil {
+			return nil, err
+		}
+
+		handler := func(ctx context.Context, req any) (any, error) {
+			return s.serve(ctx, fullMethod, method.Handler, *req.(*json.RawMessage))
+		}
+
+		if interceptor == nil {
+			return handler(ctx, &msg)
+		}
+
+		return interceptor(ctx, &msg, &grpc.UnaryServerInfo{
+			Server:     srv,
+			FullMethod: fullMethod,
+		}, handler)
+	}
+}
+
+// serve runs handler with a transport.Context reading msg as the body of a request
+// errors are encoded by the codec of the service, then reported as a gRPC status with the encoded body in the trailer
+// panics are logged and reported as codes.Internal without their message, grpc.Server would crash the process otherwise
+func (s *Service) serve(ctx context.Context, fullMethod string, handler transport.Handler, msg json.RawMessage) (reply any, err error) {
+	defer func() {
+		if panicErr, panicked := transport.AsPanicError(err); panicked {
+			slog.Default().ErrorContext(ctx, "recovered panic in grpc method", "grpc.method", fullMethod, "error", panicErr)
+			reply, err = nil, status.Error(codes.Internal, http.StatusText(http.StatusInternalServerError))
+		}
+	}()
+	defer transport.RecoverPanic(&err)
+
+	tctx, err := newContext(ctx, s.Codec, fullMethod, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = handler.Serve(tctx); err != nil {
+		if _, panicked := transport.AsPanicError(err); panicked {
+			return nil, err
+		}
+		if encodeErr := tctx.Codec().EncodeError(tctx.Request().Context(), tctx.res, err); encodeErr != nil {
+			return nil, status.Error(codeFromHTTPStatus(http.StatusInternalServerError), encodeErr.Error())
+		}
+	}
+
+	if md := headerMetadata(tctx.res.Headers()); len(md) > 0 {
+		_ = grpc.SetHeader(ctx, md)
+	}
+
+	body := bytes.TrimSpace(tctx.res.BodyBuffer().Bytes())
+	code := tctx.res.GetStatusCode()
+	if code < http.StatusBadRequest {
+		res := json.RawMessage(body)
+		return &res, nil
+	}
+
+	_ = grpc.SetTrailer(ctx, metadata.Pairs(ErrorMetadataKey, string(body)))
+	return nil, status.Error(codeFromHTTPStatus(code), errorMessage(code, body))
+}
+
+// newContext builds the request of a call the way httpx would receive it
+// the full method becomes the path, so middleware and logs can tell calls apart
+func newContext(ctx context.Context, codec transport.Codec, fullMethod string, msg json.RawMessage) (*Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, fullMethod, bytes.NewReader(msg))
+	if err != nil {
+		return nil, status.Error(codeFromHTTPStatus(http.StatusBadRequest), err.Error())
+	}
+
+	r.Proto = "gRPC"
+	for key, values := range md {
+		switch {
+		case strings.HasPrefix(key, ":"), key == PathParamsMetadataKey, key == QueryMetadataKey:
+			continue
+		case key == "content-type":
+			continue
+		}
+		for _, value := range values {
+			r.Header.Add(key, value)
+		}
+	}
+
+	if authority := md.Get(":authority"); len(authority) > 0 {
+		r.Host = authority[0]
+	}
+
+	// messages are always JSON, whatever the codec negotiates for the response
+	r.Header.Set("Content-Type", "application/json")
+	if r.Header.Get("Accept") == "" {
+		r.Header.Set("Accept", "application/json")
+	}
+
+	if query := md.Get(QueryMetadataKey); len(query) > 0 {
+		r.URL.RawQuery = query[0]
+	}
+
+	pathParams := url.Values{}
+	if encoded := md.Get(PathParamsMetadataKey); len(encoded) > 0 {
+		if pathParams, err = url.ParseQuery(encoded[0]); err != nil {
+			return nil, status.Error(codeFromHTTPStatus(http.StatusBadRequest), "invalid path params")
+		}
+	}
+
+	req := httpx.NewRequest(r.WithContext(httpx.ContextWithPathParams(ctx, pathParams)))
+	tctx := &Context{
+		req:   req,
+		res:   NewResponse(),
+		codec: codec,
+	}
+
+	// encoders reach the transport context through the request context
+	req.WithContext(transport.ContextStore.Save(req.Context(), tctx))
+	return tctx, nil
+}
+
+// headerMetadata forwards response headers that are valid metadata keys
+func headerMetadata(header http.Header) metadata.MD {
+	md := metadata.MD{}
+	for key, values := range header {
+		key = strings.ToLower(key)
+		if key == "content-type" || key == "content-length" {
+			continue
+		}
+		md.Append(key, values...)
+	}
+	return md
+}
+
+// errorMessage reads the message of an encoded error, falling back to the status text
+func errorMessage(code int, body []byte) string {
+	var errRes httpx.DefaultJSONError
+	if err := json.Unmarshal(body, &errRes); err == nil && errRes.Message != "" {
+		return errRes.Message
+	}
+	return http.StatusText(code)
+}
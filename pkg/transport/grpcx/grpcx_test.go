@@ -0,0 +1,133 @@
+package grpcx
+
+import (
+	"context"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/kibu-sh/kibu/pkg/transport/httpx"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"net/http"
+	"testing"
+)
+
+type getAccountReq struct {
+	ID      string `path:"id" json:"-"`
+	Expand  bool   `query:"expand" json:"-"`
+	TraceID string `header:"X-Trace-Id" json:"-"`
+	Session string `cookie:"session" json:"-"`
+	Name    string `json:"name"`
+}
+
+func (r getAccountReq) Validate() error {
+	if r.ID == "" {
+		return transport.FieldErrors{{Field: "id", Location: transport.LocationPath, Reason: "required"}}
+	}
+	return nil
+}
+
+type account struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Expand  bool   `json:"expand"`
+	TraceID string `json:"trace_id"`
+	Session string `json:"session"`
+}
+
+func getAccount(ctx context.Context, req getAccountReq) (res account, err error) {
+	if req.ID == "missing" {
+		err = httpx.DefaultJSONError{Status: http.StatusNotFound, Message: "account not found"}
+		return
+	}
+	return account{ID: req.ID, Name: req.Name, Expand: req.Expand, TraceID: req.TraceID, Session: req.Session}, nil
+}
+
+func newTestConn(t *testing.T, services ...*Service) *grpc.ClientConn {
+	listener := bufconn.Listen(1 << 20)
+	server := NewServer(services)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func TestService(t *testing.T) {
+	var calls int
+	countCalls := transport.NewMiddleware(func(tctx transport.Context, next transport.Handler) error {
+		calls++
+		require.Equal(t, "/accounts.Service/GetAccount", tctx.Request().Path())
+		return next.Serve(tctx)
+	})
+
+	conn := newTestConn(t, NewService("accounts.Service",
+		NewMethod("GetAccount", transport.NewEndpoint(getAccount).WithMiddleware(countCalls)),
+		NewMethod("DeleteAccount", transport.HandlerFunc(func(tctx transport.Context) error {
+			tctx.Response().SetStatusCode(http.StatusNoContent)
+			return nil
+		})),
+		NewMethod("PanicInMiddleware", transport.HandlerFunc(func(tctx transport.Context) error {
+			panic("secret: boom")
+		})),
+		NewMethod("PanicInEndpoint", transport.NewEndpoint(func(ctx context.Context, req getAccountReq) (res account, err error) {
+			panic("secret: boom")
+		})),
+	))
+	ctx := context.Background()
+
+	t.Run("should bind params from metadata and the body from the message", func(t *testing.T) {
+		var res account
+		req := getAccountReq{ID: "123", Expand: true, TraceID: "trace", Session: "secret", Name: "kibu"}
+		require.NoError(t, Invoke(ctx, conn, "/accounts.Service/GetAccount", req, &res))
+		require.Equal(t, account{ID: "123", Name: "kibu", Expand: true, TraceID: "trace", Session: "secret"}, res)
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("should decode validation errors with their fields", func(t *testing.T) {
+		err := Invoke(ctx, conn, "/accounts.Service/GetAccount", getAccountReq{}, new(account))
+		var errRes httpx.DefaultJSONError
+		require.ErrorAs(t, err, &errRes)
+		require.Equal(t, http.StatusUnprocessableEntity, errRes.Status)
+		require.Len(t, errRes.Errors, 1)
+		require.Equal(t, "id", errRes.Errors[0].Field)
+	})
+
+	t.Run("should decode the status of endpoint errors", func(t *testing.T) {
+		err := Invoke(ctx, conn, "/accounts.Service/GetAccount", getAccountReq{ID: "missing"}, new(account))
+		var errRes httpx.DefaultJSONError
+		require.ErrorAs(t, err, &errRes)
+		require.Equal(t, http.StatusNotFound, errRes.Status)
+		require.Equal(t, "account not found", errRes.Message)
+	})
+
+	t.Run("should accept methods without a response", func(t *testing.T) {
+		require.NoError(t, Invoke(ctx, conn, "/accounts.Service/DeleteAccount", getAccountReq{ID: "123"}, nil))
+	})
+
+	t.Run("should recover panics as internal errors", func(t *testing.T) {
+		for _, method := range []string{"/accounts.Service/PanicInMiddleware", "/accounts.Service/PanicInEndpoint"} {
+			err := Invoke(ctx, conn, method, getAccountReq{ID: "123"}, nil)
+			var errRes httpx.DefaultJSONError
+			require.ErrorAs(t, err, &errRes, method)
+			require.Equal(t, http.StatusInternalServerError, errRes.Status, method)
+			require.NotContains(t, errRes.Message, "secret", "the message of panics must not be sent to clients")
+		}
+	})
+
+	t.Run("should map unknown methods to 501", func(t *testing.T) {
+		err := Invoke(ctx, conn, "/accounts.Service/Missing", getAccountReq{ID: "123"}, nil)
+		var errRes httpx.DefaultJSONError
+		require.True(t, errors.As(err, &errRes))
+		require.Equal(t, http.StatusNotImplemented, errRes.Status)
+	})
+}
@@ -0,0 +1,268 @@
+package httpx
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"github.com/kibu-sh/kibu/pkg/request"
+	"github.com/kibu-sh/kibu/pkg/transport/pathparam"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrMissingPathParam = errors.New("missing path parameter")
+
+// NewClientRequest prepares a request.Client to call a kibu service endpoint
+// values tagged with path, query, header and cookie are encoded the same way DefaultDecoderChain reads them
+// the request is sent as a JSON body when the method allows one
+//
+//	NewClientRequest(client, "GET", "/accounts/{id}", GetAccountRequest{ID: "123"})
+func NewClientRequest(client *request.Client, method, path string, req any) (*request.Client, error) {
+	values, err := encodeRequestValues(req)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err = expandPathParams(path, values.path)
+	if err != nil {
+		return nil, err
+	}
+
+	client = client.WithJoinedURLPath(path).WithMethod(method)
+	if len(values.query) > 0 {
+		client = client.WithUrlValues(values.query)
+	}
+
+	for key, list := range values.header {
+		for _, value := range list {
+			client = client.WithHeader(key, value)
+		}
+	}
+
+	var cookies []string
+	for name, list := range values.cookie {
+		for _, value := range list {
+			cookies = append(cookies, (&http.Cookie{Name: name, Value: value}).String())
+		}
+	}
+
+	if len(cookies) > 0 {
+		sort.Strings(cookies)
+		client = client.WithHeader("Cookie", strings.Join(cookies, "; "))
+	}
+
+	if req != nil && hasBodyByMethod(method) {
+		client = client.WithJSONBody(req)
+	}
+
+	return client, nil
+}
+
+func hasBodyByMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		return true
+	}
+	return false
+}
+
+// expandPathParams replaces the parameters of a route with escaped values
+//
+//	expandPathParams("/accounts/{id}", url.Values{"id": {"a b"}}) → "/accounts/a%20b"
+func expandPathParams(path string, values url.Values) (string, error) {
+	var missing []string
+	expanded := pathparam.Replace(path, func(match string, param pathparam.Param) string {
+		if param.Anchor {
+			// {$} anchors the end of a stdlib pattern
+			return ""
+		}
+		if !values.Has(param.Name) {
+			missing = append(missing, param.Name)
+			return match
+		}
+		return url.PathEscape(values.Get(param.Name))
+	})
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("%w: %s in %s", ErrMissingPathParam, strings.Join(missing, ", "), path)
+	}
+	return expanded, nil
+}
+
+type requestValues struct {
+	path   url.Values
+	query  url.Values
+	header url.Values
+	cookie url.Values
+}
+
+func (v requestValues) forTag(tag string) url.Values {
+	switch tag {
+	case "path":
+		return v.path
+	case "query":
+		return v.query
+	case "header":
+		return v.header
+	case "cookie":
+		return v.cookie
+	}
+	return nil
+}
+
+var valueTags = []string{"path", "query", "header", "cookie"}
+
+// RequestParams are the values of a request bound outside its body, keyed by the name in their tag
+type RequestParams struct {
+	Path   url.Values
+	Query  url.Values
+	Header url.Values
+	Cookie url.Values
+}
+
+// EncodeRequestParams encodes the path, query, header and cookie values of req the same way NewClientRequest does
+// transports without URLs use it to carry params next to the body of a message
+func EncodeRequestParams(req any) (RequestParams, error) {
+	values, err := encodeRequestValues(req)
+	return RequestParams{
+		Path:   values.path,
+		Query:  values.query,
+		Header: values.header,
+		Cookie: values.cookie,
+	}, err
+}
+
+func encodeRequestValues(req any) (values requestValues, err error) {
+	values = requestValues{
+		path:   url.Values{},
+		query:  url.Values{},
+		header: url.Values{},
+		cookie: url.Values{},
+	}
+
+	rv := reflect.ValueOf(req)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return
+	}
+
+	err = encodeStructValues(rv, values)
+	return
+}
+
+func encodeStructValues(rv reflect.Value, values requestValues) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		fv := rv.Field(i)
+
+		if field.Anonymous && hasNoValueTags(field) {
+			for fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					break
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				if err := encodeStructValues(fv, values); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		for _, tag := range valueTags {
+			name, ok := field.Tag.Lookup(tag)
+			if !ok {
+				continue
+			}
+
+			name, _, _ = strings.Cut(name, ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+
+			encoded, err := encodeValue(fv)
+			if err != nil {
+				return fmt.Errorf("failed to encode %s %s: %w", tag, name, err)
+			}
+
+			for _, value := range encoded {
+				values.forTag(tag).Add(name, value)
+			}
+		}
+	}
+	return nil
+}
+
+func hasNoValueTags(field reflect.StructField) bool {
+	for _, tag := range valueTags {
+		if _, ok := field.Tag.Lookup(tag); ok {
+			return false
+		}
+	}
+	return true
+}
+
+// encodeValue formats a field as a list of strings
+// nil pointers are omitted and slices produce one value per element
+func encodeValue(rv reflect.Value) ([]string, error) {
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.CanInterface() {
+		if marshaler, ok := rv.Interface().(encoding.TextMarshaler); ok {
+			text, err := marshaler.MarshalText()
+			if err != nil {
+				return nil, err
+			}
+			return []string{string(text)}, nil
+		}
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		return []string{rv.String()}, nil
+	case reflect.Bool:
+		return []string{strconv.FormatBool(rv.Bool())}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return []string{strconv.FormatInt(rv.Int(), 10)}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return []string{strconv.FormatUint(rv.Uint(), 10)}, nil
+	case reflect.Float32, reflect.Float64:
+		return []string{strconv.FormatFloat(rv.Float(), 'f', -1, rv.Type().Bits())}, nil
+	case reflect.Slice, reflect.Array:
+		var result []string
+		for i := 0; i < rv.Len(); i++ {
+			encoded, err := encodeValue(rv.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, encoded...)
+		}
+		return result, nil
+	}
+
+	return nil, fmt.Errorf("unsupported kind %s", rv.Kind())
+}
@@ -0,0 +1,106 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/kibu-sh/kibu/pkg/request"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestNewClientRequest(t *testing.T) {
+	type embedded struct {
+		Limit int `query:"limit"`
+	}
+
+	type clientRequest struct {
+		embedded
+		ID      string     `path:"id"`
+		Tags    []string   `query:"tag"`
+		Since   *time.Time `query:"since"`
+		TraceID string     `header:"X-Trace-Id"`
+		Session string     `cookie:"session"`
+		Name    string     `json:"name"`
+	}
+
+	type captured struct {
+		Method string
+		Path   string
+		Query  url.Values
+		Header http.Header
+		Body   map[string]any
+	}
+
+	var last captured
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		last = captured{
+			Method: r.Method,
+			Path:   r.URL.EscapedPath(),
+			Query:  r.URL.Query(),
+			Header: r.Header,
+		}
+		if r.ContentLength > 0 {
+			_ = json.NewDecoder(r.Body).Decode(&last.Body)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"ok": "true"})
+	}))
+	defer server.Close()
+
+	client, err := request.ParseURL(server.URL)
+	require.NoError(t, err)
+
+	t.Run("should encode path, query, header and cookie values", func(t *testing.T) {
+		since := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		req, err := NewClientRequest(client, http.MethodPut, "/accounts/{id}", clientRequest{
+			embedded: embedded{Limit: 10},
+			ID:       "a b",
+			Tags:     []string{"x", "y"},
+			Since:    &since,
+			TraceID:  "trace",
+			Session:  "secret",
+			Name:     "test",
+		})
+		require.NoError(t, err)
+
+		var res map[string]string
+		require.NoError(t, req.DoAsJSON(context.Background(), &res))
+		require.Equal(t, http.MethodPut, last.Method)
+		require.Equal(t, "/accounts/a%20b", last.Path)
+		require.Equal(t, []string{"x", "y"}, last.Query["tag"])
+		require.Equal(t, "10", last.Query.Get("limit"))
+		require.Equal(t, "2024-01-02T03:04:05Z", last.Query.Get("since"))
+		require.Equal(t, "trace", last.Header.Get("X-Trace-Id"))
+		require.Equal(t, "session=secret", last.Header.Get("Cookie"))
+		require.Equal(t, "test", last.Body["name"])
+	})
+
+	t.Run("should not send a body for methods without one", func(t *testing.T) {
+		req, err := NewClientRequest(client, http.MethodGet, "/accounts/:id", &clientRequest{ID: "1"})
+		require.NoError(t, err)
+
+		var res map[string]string
+		require.NoError(t, req.DoAsJSON(context.Background(), &res))
+		require.Equal(t, "/accounts/1", last.Path)
+		require.Nil(t, last.Body)
+		require.False(t, last.Query.Has("since"), "nil pointers should be omitted")
+	})
+
+	t.Run("should fail when a path parameter is missing", func(t *testing.T) {
+		_, err := NewClientRequest(client, http.MethodGet, "/accounts/{account}", clientRequest{})
+		require.ErrorIs(t, err, ErrMissingPathParam)
+	})
+
+	t.Run("should not modify the parent client", func(t *testing.T) {
+		_, err := NewClientRequest(client, http.MethodGet, "/accounts/{id}", clientRequest{ID: "1", Tags: []string{"x"}})
+		require.NoError(t, err)
+
+		var res map[string]string
+		require.NoError(t, client.DoAsJSON(context.Background(), &res))
+		require.Equal(t, "/", last.Path)
+		require.Empty(t, last.Query)
+	})
+}
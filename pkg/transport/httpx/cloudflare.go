@@ -5,13 +5,26 @@ import (
 	"net/http"
 )
 
+// CloudflareMeta are the headers Cloudflare adds to the requests it proxies
+// any client can send them, they are only meaningful on connections from Cloudflare or a proxy behind it
 type CloudflareMeta struct {
 	Country      string
+	ConnectingIP net.IP
 	TrueClientIP net.IP
 }
 
 func CloudflareMetaFromHeaders(header http.Header) (meta CloudflareMeta) {
+	meta.ConnectingIP = net.ParseIP(header.Get("CF-Connecting-IP"))
 	meta.TrueClientIP = net.ParseIP(header.Get("True-Client-IP"))
 	meta.Country = header.Get("cf-ipcountry")
 	return
 }
+
+// ClientIP is the address of the client that connected to Cloudflare, or nil when neither header is set
+// CF-Connecting-IP is preferred, True-Client-IP carries the same address on Enterprise plans
+func (m CloudflareMeta) ClientIP() net.IP {
+	if m.ConnectingIP != nil {
+		return m.ConnectingIP
+	}
+	return m.TrueClientIP
+}
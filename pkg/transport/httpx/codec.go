@@ -8,8 +8,19 @@ type Codec struct {
 	transport.ErrorEncoder
 }
 
+// WithErrorEncoder returns a copy of the codec that encodes errors with errorEncoder
+func (c Codec) WithErrorEncoder(errorEncoder transport.ErrorEncoder) *Codec {
+	c.ErrorEncoder = errorEncoder
+	return &c
+}
+
+// DefaultCodec is used by every Handler created with NewHandler
+// assign DefaultCodec.ErrorEncoder = ProblemJSONErrorEncoder() at startup to render every error as problem+json
 var DefaultCodec = &Codec{
 	Decoder:      DefaultDecoderChain(),
 	Encoder:      JSONEncoder(),
 	ErrorEncoder: JSONErrorEncoder(),
 }
+
+// ProblemJSONCodec is DefaultCodec with errors rendered as RFC 9457 problem details
+var ProblemJSONCodec = DefaultCodec.WithErrorEncoder(ProblemJSONErrorEncoder())
@@ -7,9 +7,12 @@ import (
 var _ transport.Context = (*Context)(nil)
 
 type Context struct {
-	req    *Request
-	writer *ResponseWriter
-	codec  transport.Codec
+	req       *Request
+	writer    *ResponseWriter
+	codec     transport.Codec
+	webSocket WebSocketOptions
+	route     string
+	finishers []func(stats RequestStats)
 }
 
 func (c *Context) Codec() transport.Codec {
@@ -23,3 +26,15 @@ func (c *Context) Request() transport.Request {
 func (c *Context) Response() transport.Response {
 	return c.writer
 }
+
+// Route returns the path pattern of the Handler serving the request (i.e. /accounts/{id})
+func (c *Context) Route() string {
+	return c.route
+}
+
+// OnFinish calls fn once the request has been served and its errors encoded,
+// so fn sees the status code sent to the client, which middleware returning an error can't
+// the functions are called in the reverse order they were added
+func (c *Context) OnFinish(fn func(stats RequestStats)) {
+	c.finishers = append(c.finishers, fn)
+}
@@ -2,24 +2,66 @@ package httpx
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/kibu-sh/kibu/pkg/transport"
 	"github.com/pkg/errors"
+	"github.com/samber/lo"
 	"mime"
 	"net/http"
+	"reflect"
+	"sort"
 )
 
 type ValueSet map[string][]string
 
+// ValueDecoder binds a set of values to the fields of target matching tag
+// failures are reported as a transport.BindingError with the name of every value that couldn't be bound
 func ValueDecoder(tag string, get func(request transport.Request) ValueSet) transport.DecoderFunc {
 	return func(ctx context.Context, request transport.Request, target any) (err error) {
-		if params := get(request); params != nil {
-			err = binding.MapFormWithTag(target, params, tag)
+		params := get(request)
+		if params == nil {
+			return
+		}
+
+		if err = binding.MapFormWithTag(target, params, tag); err != nil {
+			return &transport.BindingError{
+				Fields: valueFieldErrors(tag, params, target, err),
+				Err:    err,
+			}
 		}
 		return
 	}
 }
 
+// valueFieldErrors finds the values that failed to bind by binding them one at a time to an empty copy of target
+// the binding library doesn't report which field failed, this only runs once a request is already rejected
+func valueFieldErrors(tag string, params ValueSet, target any, err error) (fields transport.FieldErrors) {
+	targetType := reflect.TypeOf(target)
+	if targetType.Kind() != reflect.Pointer {
+		return transport.FieldErrors{{Location: tag, Reason: err.Error()}}
+	}
+
+	keys := lo.Keys(params)
+	sort.Strings(keys)
+	for _, key := range keys {
+		probe := reflect.New(targetType.Elem()).Interface()
+		if keyErr := binding.MapFormWithTag(probe, ValueSet{key: params[key]}, tag); keyErr != nil {
+			fields = append(fields, transport.FieldError{
+				Field:    key,
+				Location: tag,
+				Reason:   keyErr.Error(),
+			})
+		}
+	}
+
+	if len(fields) == 0 {
+		fields = transport.FieldErrors{{Location: tag, Reason: err.Error()}}
+	}
+	return
+}
+
 func HyperMediaDecoder() transport.DecoderFunc {
 	return func(ctx context.Context, request transport.Request, target any) (err error) {
 		r, ok := request.Underlying().(*http.Request)
@@ -32,10 +74,41 @@ func HyperMediaDecoder() transport.DecoderFunc {
 			return err
 		}
 
-		return binding.Default(request.Method(), contentType).Bind(r, target)
+		if err = binding.Default(request.Method(), contentType).Bind(r, target); err != nil {
+			return &transport.BindingError{
+				Fields: bodyFieldErrors(err),
+				Err:    err,
+			}
+		}
+		return
 	}
 }
 
+// bodyFieldErrors describes the errors of encoding/json with the path of the offending field when it's known
+func bodyFieldErrors(err error) transport.FieldErrors {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return transport.FieldErrors{{
+			Field:    typeErr.Field,
+			Location: transport.LocationBody,
+			Reason:   fmt.Sprintf("expected %s but got %s", typeErr.Type, typeErr.Value),
+		}}
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return transport.FieldErrors{{
+			Location: transport.LocationBody,
+			Reason:   fmt.Sprintf("malformed json at offset %d: %s", syntaxErr.Offset, syntaxErr.Error()),
+		}}
+	}
+
+	return transport.FieldErrors{{
+		Location: transport.LocationBody,
+		Reason:   err.Error(),
+	}}
+}
+
 func PathParamsDecoder() transport.DecoderFunc {
 	return ValueDecoder("path", func(request transport.Request) ValueSet {
 		return ValueSet(request.PathParams())
@@ -18,8 +18,76 @@ func JSONEncoder() transport.EncoderFunc {
 }
 
 type DefaultJSONError struct {
-	Message string `json:"message"`
-	Status  int    `json:"status"`
+	Message string                `json:"message"`
+	Status  int                   `json:"status"`
+	Errors  transport.FieldErrors `json:"errors,omitempty"`
+}
+
+// NewDefaultJSONError maps an error to the response written by JSONErrorEncoder
+// errors implementing transport.ErrorResponse take precedence
+// binding errors are client errors (400) and validation errors are unprocessable (422), both list the offending fields
+// any other error is an internal server error (500)
+// panics are internal server errors whose message is hidden since it may leak internal details
+// requests exceeding the Limits of a Handler are answered with 408, 413 or 431
+func NewDefaultJSONError(err error) transport.ErrorResponse {
+	if _, ok := transport.AsPanicError(err); ok {
+		return DefaultJSONError{
+			Status:  http.StatusInternalServerError,
+			Message: http.StatusText(http.StatusInternalServerError),
+		}
+	}
+
+	if status, ok := errorStatus(err); ok {
+		return DefaultJSONError{
+			Status:  status,
+			Message: err.Error(),
+		}
+	}
+
+	var errRes transport.ErrorResponse
+	if errors.As(err, &errRes) {
+		return errRes
+	}
+
+	var bindingErr *transport.BindingError
+	if errors.As(err, &bindingErr) {
+		return DefaultJSONError{
+			Status:  http.StatusBadRequest,
+			Message: bindingErr.Error(),
+			Errors:  bindingErr.Fields,
+		}
+	}
+
+	var validationErr *transport.ValidationError
+	if errors.As(err, &validationErr) {
+		return DefaultJSONError{
+			Status:  http.StatusUnprocessableEntity,
+			Message: validationErr.Error(),
+			Errors:  validationErr.Fields,
+		}
+	}
+
+	return DefaultJSONError{
+		Status:  http.StatusInternalServerError,
+		Message: err.Error(),
+	}
+}
+
+// errorStatus returns the status of the errors raised by the transport itself rather than an endpoint
+func errorStatus(err error) (int, bool) {
+	switch {
+	case errors.Is(err, transport.ErrUnauthenticated):
+		return http.StatusUnauthorized, true
+	case errors.Is(err, transport.ErrForbidden):
+		return http.StatusForbidden, true
+	case errors.Is(err, transport.ErrRateLimited):
+		return http.StatusTooManyRequests, true
+	case errors.Is(err, transport.ErrConflict):
+		return http.StatusConflict, true
+	case errors.Is(err, transport.ErrUnprocessable):
+		return http.StatusUnprocessableEntity, true
+	}
+	return limitStatus(err)
 }
 
 func (d DefaultJSONError) Error() string {
@@ -37,12 +105,7 @@ func (d DefaultJSONError) PrepareResponse() any {
 // JSONErrorEncoder encodes any response as JSON and writes it to the ResponseWriter
 func JSONErrorEncoder() transport.ErrorEncoderFunc {
 	return func(ctx context.Context, writer transport.Response, err error) error {
-		var errRes transport.ErrorResponse = DefaultJSONError{
-			Status:  http.StatusInternalServerError,
-			Message: err.Error(),
-		}
-		_ = errors.As(err, &errRes)
-
+		errRes := NewDefaultJSONError(err)
 		writer.SetStatusCode(errRes.GetStatusCode())
 		writer.Headers().Set("Content-Type", "application/json")
 		return json.NewEncoder(writer).Encode(errRes.PrepareResponse())
@@ -98,4 +98,34 @@ func TestJSONErrorEncoder(t *testing.T) {
 		require.Contains(t, resp.buf.String(), "broken")
 		require.Equal(t, resp.Headers().Get("Content-Type"), "application/json")
 	})
+
+	t.Run("should encode binding errors as bad request", func(t *testing.T) {
+		resp := &mockTransportResponse{
+			headers: http.Header{},
+			buf:     new(bytes.Buffer),
+		}
+		resp.On("SetStatusCode", http.StatusBadRequest).Return()
+		resp.On("Headers").Return(http.Header{})
+		err := encoder(ctx, resp, transport.NewBindingError(transport.FieldErrors{
+			{Field: "count", Location: transport.LocationQuery, Reason: "invalid syntax"},
+		}))
+		require.NoError(t, err)
+		require.JSONEq(t, `{
+			"message": "failed to bind request: query count: invalid syntax",
+			"status": 400,
+			"errors": [{"field": "count", "location": "query", "reason": "invalid syntax"}]
+		}`, resp.buf.String())
+	})
+
+	t.Run("should encode validation errors as unprocessable entity", func(t *testing.T) {
+		resp := &mockTransportResponse{
+			headers: http.Header{},
+			buf:     new(bytes.Buffer),
+		}
+		resp.On("SetStatusCode", http.StatusUnprocessableEntity).Return()
+		resp.On("Headers").Return(http.Header{})
+		err := encoder(ctx, resp, transport.NewValidationError(errors.New("name is required")))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"message": "invalid request: name is required", "status": 422}`, resp.buf.String())
+	})
 }
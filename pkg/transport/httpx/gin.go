@@ -31,9 +31,14 @@ func NewGinMuxWithMiddleware(middleware ...func(engine *gin.Engine) gin.HandlerF
 	return g
 }
 
+// Handle registers the handler for each of its methods
+// stdlib style wildcards are converted to gin parameters
+//
+//	/accounts/{id} → /accounts/:id
 func (g GinMux) Handle(handler *Handler) {
+	path := ginPattern(handler.Path)
 	for _, method := range handler.Methods {
-		g.mux.Handle(method, handler.Path, gin.HandlerFunc(func(c *gin.Context) {
+		g.mux.Handle(method, path, gin.HandlerFunc(func(c *gin.Context) {
 			handler.ServeHTTP(c.Writer, c.Request)
 		}))
 	}
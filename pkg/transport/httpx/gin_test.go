@@ -17,3 +17,10 @@ func TestGin(t *testing.T) {
 	require.HTTPBodyContains(t, http.HandlerFunc(m.ServeHTTP), "GET", "/home/test", nil, "test")
 	require.HTTPStatusCode(t, http.HandlerFunc(m.ServeHTTP), "GET", "/example", nil, http.StatusNotFound)
 }
+
+func TestGin_StdLibPattern(t *testing.T) {
+	svc := testSvc{}
+	m := NewGinMux()
+	m.Handle(NewHandler("/home/{name}", transport.NewEndpoint(svc.Call)))
+	require.HTTPBodyContains(t, http.HandlerFunc(m.ServeHTTP), "GET", "/home/test", nil, "test")
+}
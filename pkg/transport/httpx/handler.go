@@ -21,6 +21,21 @@ type Handler struct {
 	Handler transport.Handler
 	Codec   transport.Codec
 
+	// Operation identifies the endpoint served by the handler in RequestStats
+	Operation string
+
+	// Observer is notified once every request has been served
+	Observer Observer
+
+	// WebSocket configures connections upgraded by channel endpoints
+	WebSocket WebSocketOptions
+
+	// Panics decides how panics recovered while serving a request are reported
+	Panics PanicPolicy
+
+	// Limits bound the duration and size of requests
+	Limits Limits
+
 	// TODO: think about emitting errors at a higher level
 	// Maybe we need a logger here
 	OnError func(err error)
@@ -28,10 +43,13 @@ type Handler struct {
 
 func NewHandler(path string, handler transport.Handler) *Handler {
 	return &Handler{
-		Path:    path,
-		Handler: handler,
-		Methods: []string{http.MethodGet},
-		Codec:   DefaultCodec,
+		Path:      path,
+		Handler:   handler,
+		Methods:   []string{http.MethodGet},
+		Codec:     DefaultCodec,
+		WebSocket: DefaultWebSocketOptions,
+		Panics:    DefaultPanicPolicy,
+		Limits:    DefaultLimits,
 	}
 }
 
@@ -40,20 +58,74 @@ func (h *Handler) WithMethods(methods ...string) *Handler {
 	return h
 }
 
-// TODO: consider capturing panics at this level
+// WithOperation sets the operation ID reported to the Observer
+//
+//	NewHandler("/accounts/{id}", endpoint).WithOperation("billing.AccountService.GetAccount")
+func (h *Handler) WithOperation(operation string) *Handler {
+	h.Operation = operation
+	return h
+}
+
+// WithObserver sets the Observer notified once every request has been served
+func (h *Handler) WithObserver(observer Observer) *Handler {
+	h.Observer = observer
+	return h
+}
+
+// WithCodec replaces the codec used to decode requests and encode responses and errors
+//
+//	NewHandler("/accounts", endpoint).WithCodec(ProblemJSONCodec)
+func (h *Handler) WithCodec(codec transport.Codec) *Handler {
+	h.Codec = codec
+	return h
+}
+
+// WithWebSocketOptions configures the keepalive and limits of connections upgraded by channel endpoints
+func (h *Handler) WithWebSocketOptions(opts WebSocketOptions) *Handler {
+	h.WebSocket = opts
+	return h
+}
+
+// WithPanicPolicy configures how panics recovered while serving a request are reported
+//
+//	NewHandler("/accounts", endpoint).WithPanicPolicy(PanicPolicy{Repanic: true})
+func (h *Handler) WithPanicPolicy(policy PanicPolicy) *Handler {
+	h.Panics = policy
+	return h
+}
+
+// WithLimits bounds the duration and size of requests
+//
+//	NewHandler("/accounts", endpoint).WithLimits(Limits{Timeout: 5 * time.Second, MaxBodyBytes: 1 << 20})
+func (h *Handler) WithLimits(limits Limits) *Handler {
+	h.Limits = limits
+	return h
+}
 
 // ServeHTTP implements http.Handler
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r, cancel := h.Limits.apply(w, r)
+	defer cancel()
+
 	req := NewRequest(r)
 	res := NewResponse(w)
 	ctx := r.Context()
 	logger := slog.Default()
 	tctx := &Context{
-		req:    req,
-		writer: res,
-		codec:  h.Codec,
+		req:       req,
+		writer:    res,
+		codec:     h.Codec,
+		webSocket: h.WebSocket,
+		route:     h.Path,
+	}
+
+	if h.Limits.Timeout > 0 {
+		tctx.codec = timeoutCodec{Codec: h.Codec}
 	}
 
+	// encoders reach the transport context through the request context (i.e. to read the Accept header)
+	req.WithContext(transport.ContextStore.Save(ctx, tctx))
+
 	startTime := time.Now()
 	var serveError error
 	var encodingError error
@@ -78,17 +150,72 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 
 		logger.Log(ctx, level, buildHTTPResponseLogMessage(req, res))
+
+		stats := h.requestStats(req, res, duration, serveError)
+		for i := len(tctx.finishers) - 1; i >= 0; i-- {
+			tctx.finishers[i](stats)
+		}
+
+		if h.Observer != nil {
+			h.Observer.ObserveRequest(ctx, stats)
+		}
 	}()
 
 	// if there's no error from the serve handler, it means the request was successful,
 	// there's no need to encode an error to the transport
-	if serveError = h.Handler.Serve(tctx); serveError == nil {
+	if serveError = h.Limits.check(r); serveError == nil {
+		serveError = h.serve(tctx)
+	}
+
+	if serveError == nil {
 		return
 	}
 
-	if encodingError = tctx.Codec().EncodeError(ctx, res, serveError); encodingError != nil {
-		encodingError = errors.Wrap(encodingError, "failed to write error to transport response")
+	panicErr, panicked := transport.AsPanicError(serveError)
+	if panicked && h.Panics.OnPanic != nil {
+		h.Panics.OnPanic(ctx, panicErr)
+	}
+
+	// once the response has started (i.e. a stream, a WebSocket or an endpoint that panicked mid write)
+	// the status can't change, failures are reported in band or logged
+	if !res.Written() {
+		if encodingError = tctx.Codec().EncodeError(ctx, res, serveError); encodingError != nil {
+			encodingError = errors.Wrap(encodingError, "failed to write error to transport response")
+		}
+	}
+
+	if panicked && h.Panics.Repanic {
+		panic(panicErr)
+	}
+}
+
+// serve calls the handler and converts its panics into a transport.PanicError
+func (h *Handler) serve(tctx transport.Context) (err error) {
+	defer transport.RecoverPanic(&err)
+	return h.Handler.Serve(tctx)
+}
+
+// requestStats describes a request to the Observer
+// responses that were never written are reported with the 200 OK status net/http sends for them
+func (h *Handler) requestStats(req transport.Request, res *ResponseWriter, duration time.Duration, err error) RequestStats {
+	stats := RequestStats{
+		Operation:    h.Operation,
+		Method:       req.Method(),
+		Path:         h.Path,
+		StatusCode:   res.GetStatusCode(),
+		BytesWritten: res.BytesWritten(),
+		Duration:     duration,
+		Err:          err,
+	}
+
+	if stats.Operation == "" {
+		stats.Operation = h.Path
+	}
+
+	if stats.StatusCode == 0 {
+		stats.StatusCode = http.StatusOK
 	}
+	return stats
 }
 
 func buildHTTPResponseLogMessage(req transport.Request, res transport.Response) string {
@@ -106,6 +233,12 @@ var _ http.Handler = (*Handler)(nil)
 type HandlerFactory interface {
 	HTTPHandlerFactory(*middleware.Registry) []*Handler
 }
+
+// ServiceRegistrar is implemented by generated controllers
+// it registers the operations of a service, with the same handlers and middleware as HTTPHandlerFactory
+type ServiceRegistrar interface {
+	RegisterService(transport.Registry, *middleware.Registry)
+}
 type HandlerFactoryFunc func() []*Handler
 
 func (h HandlerFactoryFunc) HTTPHandlerFactory() []*Handler {
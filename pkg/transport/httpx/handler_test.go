@@ -2,9 +2,13 @@ package httpx
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -29,3 +33,144 @@ func TestHandler_ServeHTTP(t *testing.T) {
 	h := NewHandler("/", e)
 	require.HTTPStatusCode(t, http.HandlerFunc(h.ServeHTTP), "GET", "/example", nil, http.StatusOK)
 }
+
+type testCountReq struct {
+	Count int    `query:"count"`
+	Limit int    `query:"limit"`
+	Name  string `json:"name"`
+}
+
+func (r testCountReq) Validate() error {
+	if r.Name == "" {
+		return transport.FieldErrors{{Field: "name", Location: transport.LocationBody, Reason: "required"}}
+	}
+	return nil
+}
+
+func (s testSvc) Count(ctx context.Context, req testCountReq) (res testCountReq, err error) {
+	return req, nil
+}
+
+func TestHandler_BindingErrors(t *testing.T) {
+	svc := testSvc{}
+	h := NewHandler("/", transport.NewEndpoint(svc.Count)).WithMethods(http.MethodPost)
+
+	serve := func(target string, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("should reject malformed query params", func(t *testing.T) {
+		w := serve("/?count=abc&limit=10", `{"name":"test"}`)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.JSONEq(t, `[{"field":"count","location":"query","reason":"strconv.ParseInt: parsing \"abc\": invalid syntax"}]`,
+			jsonField(t, w.Body.Bytes(), "errors"))
+	})
+
+	t.Run("should reject mistyped json fields", func(t *testing.T) {
+		w := serve("/", `{"name":1}`)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.JSONEq(t, `[{"field":"name","location":"body","reason":"expected string but got number"}]`,
+			jsonField(t, w.Body.Bytes(), "errors"))
+	})
+
+	t.Run("should reject malformed json", func(t *testing.T) {
+		w := serve("/", `{"name":`)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("should reject invalid payloads", func(t *testing.T) {
+		w := serve("/", `{}`)
+		require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		require.JSONEq(t, `[{"field":"name","location":"body","reason":"required"}]`,
+			jsonField(t, w.Body.Bytes(), "errors"))
+	})
+
+	t.Run("should accept valid requests", func(t *testing.T) {
+		w := serve("/?count=1", `{"name":"test"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func (s testSvc) Panic(ctx context.Context, req testReq) (res testRes, err error) {
+	panic("boom")
+}
+
+func TestHandler_Panics(t *testing.T) {
+	svc := testSvc{}
+	serve := func(h *Handler) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		return w
+	}
+
+	t.Run("should answer panics of endpoints with a 500", func(t *testing.T) {
+		var recovered *transport.PanicError
+		var observed error
+		observe := func(next transport.Handler) transport.Handler {
+			return transport.HandlerFunc(func(tctx transport.Context) error {
+				observed = next.Serve(tctx)
+				return observed
+			})
+		}
+
+		h := NewHandler("/", transport.NewEndpoint(svc.Panic).WithMiddleware(observe)).
+			WithPanicPolicy(PanicPolicy{
+				OnPanic: func(ctx context.Context, err *transport.PanicError) {
+					recovered = err
+				},
+			})
+
+		w := serve(h)
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+		require.JSONEq(t, `{"message":"Internal Server Error","status":500}`, w.Body.String())
+
+		require.NotNil(t, recovered)
+		require.Equal(t, "boom", recovered.Value)
+		require.NotEmpty(t, recovered.StackTrace())
+		require.ErrorIs(t, observed, recovered)
+	})
+
+	t.Run("should recover panics of handlers", func(t *testing.T) {
+		h := NewHandler("/", transport.HandlerFunc(func(tctx transport.Context) error {
+			panic("boom")
+		}))
+		require.Equal(t, http.StatusInternalServerError, serve(h).Code)
+	})
+
+	t.Run("should repanic once answered", func(t *testing.T) {
+		h := NewHandler("/", transport.NewEndpoint(svc.Panic)).WithPanicPolicy(PanicPolicy{Repanic: true})
+		w := httptest.NewRecorder()
+		require.Panics(t, func() {
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		})
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestHandler_ErrorAfterWrite(t *testing.T) {
+	h := NewHandler("/", transport.HandlerFunc(func(tctx transport.Context) error {
+		res := tctx.Response()
+		res.SetStatusCode(http.StatusAccepted)
+		if _, err := res.Write([]byte("partial")); err != nil {
+			return err
+		}
+		return errors.New("connection reset by upstream")
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusAccepted, w.Code)
+	require.Equal(t, "partial", w.Body.String(), "errors must not be encoded into a response that has started")
+}
+
+// jsonField returns the raw json of a top level key
+func jsonField(t *testing.T, body []byte, key string) string {
+	t.Helper()
+	var m map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(body, &m))
+	return string(m[key])
+}
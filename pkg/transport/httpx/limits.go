@@ -0,0 +1,105 @@
+package httpx
+
+import (
+	"context"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/pkg/errors"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrRequestTimeout is reported when a request is still being served once Limits.Timeout has passed
+	ErrRequestTimeout = errors.New("request timeout")
+
+	// ErrHeadersTooLarge is reported when the headers of a request exceed Limits.MaxHeaderBytes
+	ErrHeadersTooLarge = errors.New("request headers too large")
+)
+
+// Limits bound the resources a single request to a Handler can use
+// zero values are unlimited
+//
+//	//kibu:service:method timeout=5s max_body=1MiB max_header=16KiB
+type Limits struct {
+	// Timeout is the deadline of the request context
+	// endpoints that give up because of it are answered with 408 Request Timeout
+	Timeout time.Duration
+
+	// MaxBodyBytes limits the size of the request body, larger bodies are answered with 413 Content Too Large
+	MaxBodyBytes int64
+
+	// MaxHeaderBytes limits the size of the request headers, larger headers are answered with 431 Request Header Fields Too Large
+	// http.Server.MaxHeaderBytes still applies to every request before it reaches a Handler
+	MaxHeaderBytes int64
+}
+
+// DefaultLimits doesn't limit requests beyond what the http.Server does
+var DefaultLimits = Limits{}
+
+// apply bounds the context and body of a request, the returned func releases the context
+func (l Limits) apply(w http.ResponseWriter, r *http.Request) (*http.Request, context.CancelFunc) {
+	if l.MaxBodyBytes > 0 && r.Body != nil {
+		r.Body = http.MaxBytesReader(w, r.Body, l.MaxBodyBytes)
+	}
+
+	if l.Timeout <= 0 {
+		return r, func() {}
+	}
+
+	ctx, cancel := context.WithTimeoutCause(r.Context(), l.Timeout, ErrRequestTimeout)
+	return r.WithContext(ctx), cancel
+}
+
+// check rejects requests whose declared size already exceeds the limits
+// bodies without a Content-Length are rejected once they are read past MaxBodyBytes
+func (l Limits) check(r *http.Request) error {
+	if l.MaxBodyBytes > 0 && r.ContentLength > l.MaxBodyBytes {
+		return &http.MaxBytesError{Limit: l.MaxBodyBytes}
+	}
+
+	if l.MaxHeaderBytes > 0 && headerSize(r.Header) > l.MaxHeaderBytes {
+		return ErrHeadersTooLarge
+	}
+	return nil
+}
+
+// headerSize approximates the size of headers on the wire
+//
+//	Key: Value\r\n
+func headerSize(header http.Header) (size int64) {
+	for key, values := range header {
+		for _, value := range values {
+			size += int64(len(key) + len(value) + 4)
+		}
+	}
+	return
+}
+
+// limitStatus returns the status of errors raised when a request exceeds its Limits
+func limitStatus(err error) (int, bool) {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, ErrRequestTimeout):
+		return http.StatusRequestTimeout, true
+	case errors.As(err, &maxBytesErr):
+		return http.StatusRequestEntityTooLarge, true
+	case errors.Is(err, ErrHeadersTooLarge):
+		return http.StatusRequestHeaderFieldsTooLarge, true
+	}
+	return 0, false
+}
+
+var _ transport.Codec = timeoutCodec{}
+
+// timeoutCodec reports errors caused by the deadline of Limits.Timeout as ErrRequestTimeout
+// endpoints usually return context.DeadlineExceeded, which can't be told apart from the deadline of a downstream call
+type timeoutCodec struct {
+	transport.Codec
+}
+
+func (c timeoutCodec) EncodeError(ctx context.Context, writer transport.Response, err error) error {
+	if errors.Is(err, context.DeadlineExceeded) && errors.Is(context.Cause(ctx), ErrRequestTimeout) {
+		err = ErrRequestTimeout
+	}
+	return c.Codec.EncodeError(ctx, writer, err)
+}
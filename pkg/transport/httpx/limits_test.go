@@ -0,0 +1,77 @@
+package httpx
+
+import (
+	"context"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testEchoReq struct {
+	Name string `json:"name"`
+}
+
+func TestHandler_Limits(t *testing.T) {
+	echo := transport.NewEndpoint(func(ctx context.Context, req testEchoReq) (res testEchoReq, err error) {
+		return req, nil
+	})
+
+	serve := func(h *Handler, r *http.Request) *httptest.ResponseRecorder {
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("should reject bodies larger than MaxBodyBytes", func(t *testing.T) {
+		h := NewHandler("/", echo).WithMethods(http.MethodPost).WithLimits(Limits{MaxBodyBytes: 16})
+		body := `{"name":"` + strings.Repeat("a", 32) + `"}`
+
+		w := serve(h, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+		require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+		// bodies of unknown length are rejected once they are read past the limit
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		r.ContentLength = -1
+		w = serve(h, r)
+		require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+		w = serve(h, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"a"}`)))
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("should reject headers larger than MaxHeaderBytes", func(t *testing.T) {
+		h := NewHandler("/", echo).WithMethods(http.MethodPost).WithLimits(Limits{MaxHeaderBytes: 64})
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`))
+		r.Header.Set("X-Padding", strings.Repeat("a", 64))
+
+		w := serve(h, r)
+		require.Equal(t, http.StatusRequestHeaderFieldsTooLarge, w.Code)
+	})
+
+	t.Run("should answer requests past their Timeout with a 408", func(t *testing.T) {
+		slow := transport.NewEndpoint(func(ctx context.Context, req testEchoReq) (res testEchoReq, err error) {
+			<-ctx.Done()
+			return res, ctx.Err()
+		})
+
+		h := NewHandler("/", slow).WithMethods(http.MethodPost).WithLimits(Limits{Timeout: 10 * time.Millisecond})
+		w := serve(h, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`)))
+		require.Equal(t, http.StatusRequestTimeout, w.Code)
+		require.JSONEq(t, `{"message":"request timeout","status":408}`, w.Body.String())
+	})
+
+	t.Run("should not mistake the deadline of downstream calls for a timeout", func(t *testing.T) {
+		downstream := transport.NewEndpoint(func(ctx context.Context, req testEchoReq) (res testEchoReq, err error) {
+			return res, context.DeadlineExceeded
+		})
+
+		h := NewHandler("/", downstream).WithMethods(http.MethodPost).WithLimits(Limits{Timeout: time.Minute})
+		w := serve(h, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`)))
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
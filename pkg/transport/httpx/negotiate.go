@@ -0,0 +1,231 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/ugorji/go/codec"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	MediaTypeJSON    = "application/json"
+	MediaTypeXML     = "application/xml"
+	MediaTypeMsgPack = "application/msgpack"
+	MediaTypeCBOR    = "application/cbor"
+)
+
+// Serializer writes a value to w in a single media type
+type Serializer func(w io.Writer, v any) error
+
+// JSONSerializer writes values with encoding/json
+func JSONSerializer(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+// XMLSerializer writes values with encoding/xml
+func XMLSerializer(w io.Writer, v any) error {
+	return xml.NewEncoder(w).Encode(v)
+}
+
+// msgpack and cbor handles are safe for concurrent use once configured
+// both honor json struct tags so field names match the JSON representation
+var (
+	msgpackHandle = &codec.MsgpackHandle{WriteExt: true}
+	cborHandle    = &codec.CborHandle{}
+)
+
+// MsgPackSerializer writes values as MessagePack
+func MsgPackSerializer(w io.Writer, v any) error {
+	return codec.NewEncoder(w, msgpackHandle).Encode(v)
+}
+
+// CBORSerializer writes values as CBOR
+func CBORSerializer(w io.Writer, v any) error {
+	return codec.NewEncoder(w, cborHandle).Encode(v)
+}
+
+// ErrNotAcceptable is returned by the NegotiatingEncoder when no registered media type satisfies the Accept header
+var ErrNotAcceptable = DefaultJSONError{
+	Status:  http.StatusNotAcceptable,
+	Message: "none of the accepted media types can be produced",
+}
+
+// MediaTypes holds the serializers available for content negotiation
+// the order of registration is the preference of the server when the client accepts several types equally
+type MediaTypes struct {
+	types       []string
+	serializers map[string]Serializer
+}
+
+func NewMediaTypes() *MediaTypes {
+	return &MediaTypes{
+		serializers: make(map[string]Serializer),
+	}
+}
+
+// DefaultMediaTypes supports JSON, XML, MessagePack and CBOR, preferring JSON
+func DefaultMediaTypes() *MediaTypes {
+	return NewMediaTypes().
+		Register(MediaTypeJSON, JSONSerializer).
+		Register(MediaTypeXML, XMLSerializer).
+		Register("text/xml", XMLSerializer).
+		Register(MediaTypeMsgPack, MsgPackSerializer).
+		Register("application/x-msgpack", MsgPackSerializer).
+		Register(MediaTypeCBOR, CBORSerializer)
+}
+
+// Register adds or replaces the serializer of a media type
+func (m *MediaTypes) Register(mediaType string, serializer Serializer) *MediaTypes {
+	mediaType = strings.ToLower(mediaType)
+	if _, ok := m.serializers[mediaType]; !ok {
+		m.types = append(m.types, mediaType)
+	}
+	m.serializers[mediaType] = serializer
+	return m
+}
+
+// Default returns the preferred media type of the server
+func (m *MediaTypes) Default() (string, Serializer) {
+	if len(m.types) == 0 {
+		return MediaTypeJSON, JSONSerializer
+	}
+	return m.types[0], m.serializers[m.types[0]]
+}
+
+// Negotiate picks the registered media type with the highest quality in an Accept header
+// the most specific range matching a type determines its quality (RFC 9110 section 12.5.1)
+// an empty header accepts anything
+//
+//	Accept: application/xml;q=0.9, application/*;q=0.5, */*;q=0
+func (m *MediaTypes) Negotiate(accept string) (string, Serializer, bool) {
+	if strings.TrimSpace(accept) == "" {
+		mediaType, serializer := m.Default()
+		return mediaType, serializer, true
+	}
+
+	ranges := parseAccept(accept)
+	best, bestQuality := "", 0.0
+	for _, mediaType := range m.types {
+		if quality := acceptQuality(ranges, mediaType); quality > bestQuality {
+			best, bestQuality = mediaType, quality
+		}
+	}
+
+	if best == "" {
+		return "", nil, false
+	}
+	return best, m.serializers[best], true
+}
+
+type acceptRange struct {
+	mediaType string
+	quality   float64
+}
+
+func parseAccept(accept string) (ranges []acceptRange) {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, acceptRange{mediaType: mediaType, quality: quality})
+	}
+	return
+}
+
+// acceptQuality returns the quality of the most specific range matching mediaType
+func acceptQuality(ranges []acceptRange, mediaType string) float64 {
+	typ, _, _ := strings.Cut(mediaType, "/")
+	specificity, quality := -1, 0.0
+	for _, r := range ranges {
+		var s int
+		switch r.mediaType {
+		case mediaType:
+			s = 2
+		case typ + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		default:
+			continue
+		}
+		if s > specificity {
+			specificity, quality = s, r.quality
+		}
+	}
+	return quality
+}
+
+// acceptFromContext returns the Accept header of the request served by a Handler
+func acceptFromContext(ctx context.Context) string {
+	tctx, err := transport.ContextStore.Load(ctx)
+	if err != nil {
+		return ""
+	}
+	return tctx.Request().Headers().Get("Accept")
+}
+
+// NegotiatingEncoder writes responses in the media type preferred by the Accept header of the request
+// ErrNotAcceptable is returned when none of the media types are accepted and will be encoded by the ErrorEncoder
+func NegotiatingEncoder(types *MediaTypes) transport.EncoderFunc {
+	return func(ctx context.Context, writer transport.Response, response any) error {
+		mediaType, serializer, ok := types.Negotiate(acceptFromContext(ctx))
+		if !ok {
+			return ErrNotAcceptable
+		}
+
+		// the response is serialized first, so a failure leaves the writer untouched for the ErrorEncoder
+		buf := new(bytes.Buffer)
+		if err := serializer(buf, response); err != nil {
+			return fmt.Errorf("failed to encode response as %s: %w", mediaType, err)
+		}
+
+		writer.Headers().Add("Vary", "Accept")
+		writer.Headers().Set("Content-Type", mediaType)
+		_, err := buf.WriteTo(writer)
+		return err
+	}
+}
+
+// NegotiatingErrorEncoder writes errors like the JSONErrorEncoder in the media type preferred by the Accept header
+// errors are always written, falling back to the default media type when none are accepted
+func NegotiatingErrorEncoder(types *MediaTypes) transport.ErrorEncoderFunc {
+	return func(ctx context.Context, writer transport.Response, err error) error {
+		mediaType, serializer, ok := types.Negotiate(acceptFromContext(ctx))
+		if !ok {
+			mediaType, serializer = types.Default()
+		}
+
+		errRes := NewDefaultJSONError(err)
+		writer.SetStatusCode(errRes.GetStatusCode())
+		writer.Headers().Add("Vary", "Accept")
+		writer.Headers().Set("Content-Type", mediaType)
+		return serializer(writer, errRes.PrepareResponse())
+	}
+}
+
+// NewNegotiatingCodec returns DefaultCodec with responses and errors written in the media type requested by clients
+//
+//	NewHandler("/accounts", endpoint).WithCodec(NewNegotiatingCodec(DefaultMediaTypes()))
+func NewNegotiatingCodec(types *MediaTypes) *Codec {
+	return &Codec{
+		Decoder:      DefaultCodec.Decoder,
+		Encoder:      NegotiatingEncoder(types),
+		ErrorEncoder: NegotiatingErrorEncoder(types),
+	}
+}
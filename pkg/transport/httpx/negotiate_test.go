@@ -0,0 +1,101 @@
+package httpx
+
+import (
+	"bytes"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMediaTypes_Negotiate(t *testing.T) {
+	types := DefaultMediaTypes()
+	tests := []struct {
+		accept   string
+		expected string
+		ok       bool
+	}{
+		{"", MediaTypeJSON, true},
+		{"*/*", MediaTypeJSON, true},
+		{"application/xml", MediaTypeXML, true},
+		{"application/cbor, application/json;q=0.5", MediaTypeCBOR, true},
+		{"application/json;q=0.5, application/msgpack", MediaTypeMsgPack, true},
+		{"application/*;q=0.2, application/xml;q=0.9", MediaTypeXML, true},
+		{"text/*", "text/xml", true},
+		{"*/*, application/json;q=0", MediaTypeXML, true},
+		{"text/html", "", false},
+		{"application/json;q=0", "", false},
+		{"application/JSON", MediaTypeJSON, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.accept, func(t *testing.T) {
+			mediaType, _, ok := types.Negotiate(test.accept)
+			require.Equal(t, test.ok, ok)
+			require.Equal(t, test.expected, mediaType)
+		})
+	}
+}
+
+func TestNegotiatingCodec(t *testing.T) {
+	svc := testSvc{}
+	types := DefaultMediaTypes().Register("text/plain", func(w io.Writer, v any) error {
+		_, err := io.WriteString(w, v.(testCountReq).Name)
+		return err
+	}).Register("text/csv", func(w io.Writer, v any) error {
+		_, _ = io.WriteString(w, "partial")
+		return errors.New("csv: unsupported field")
+	})
+	h := NewHandler("/", transport.NewEndpoint(svc.Count)).
+		WithMethods(http.MethodPost).
+		WithCodec(NewNegotiatingCodec(types))
+
+	serve := func(accept string, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+		r.Header.Set("Content-Type", MediaTypeJSON)
+		r.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("should encode the accepted media type", func(t *testing.T) {
+		w := serve(MediaTypeMsgPack, `{"name":"test"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, MediaTypeMsgPack, w.Header().Get("Content-Type"))
+
+		var res testCountReq
+		require.NoError(t, codec.NewDecoderBytes(w.Body.Bytes(), &codec.MsgpackHandle{}).Decode(&res))
+		require.Equal(t, "test", res.Name)
+	})
+
+	t.Run("should encode registered media types", func(t *testing.T) {
+		w := serve("text/plain", `{"name":"test"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "test", w.Body.String())
+	})
+
+	t.Run("should encode errors in the accepted media type", func(t *testing.T) {
+		w := serve(MediaTypeXML, `{}`)
+		require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		require.Equal(t, MediaTypeXML, w.Header().Get("Content-Type"))
+		require.Contains(t, w.Body.String(), "<Status>422</Status>")
+	})
+
+	t.Run("should not write responses that fail to serialize", func(t *testing.T) {
+		w := serve("text/csv", `{"name":"test"}`)
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+		require.NotContains(t, w.Body.String(), "partial", "the partial response should be replaced by the error")
+		require.Equal(t, []string{"Accept"}, w.Header().Values("Vary"))
+	})
+
+	t.Run("should reject unacceptable media types", func(t *testing.T) {
+		w := serve("image/png", `{"name":"test"}`)
+		require.Equal(t, http.StatusNotAcceptable, w.Code)
+		require.Equal(t, MediaTypeJSON, w.Header().Get("Content-Type"))
+	})
+}
@@ -0,0 +1,36 @@
+package httpx
+
+import (
+	"context"
+	"time"
+)
+
+// RequestStats describes a request once a Handler has served it
+type RequestStats struct {
+	// Operation identifies the endpoint, generated handlers use the operation ID of the service method
+	// handlers without an operation report their Path
+	Operation string
+
+	Method       string
+	Path         string
+	StatusCode   int
+	BytesWritten int64
+	Duration     time.Duration
+
+	// Err is the error returned by the endpoint, if any
+	Err error
+}
+
+// Observer is notified of every request served by a Handler (i.e. to record metrics)
+type Observer interface {
+	ObserveRequest(ctx context.Context, stats RequestStats)
+}
+
+var _ Observer = ObserverFunc(nil)
+
+// ObserverFunc adapts a func to Observer
+type ObserverFunc func(ctx context.Context, stats RequestStats)
+
+func (f ObserverFunc) ObserveRequest(ctx context.Context, stats RequestStats) {
+	f(ctx, stats)
+}
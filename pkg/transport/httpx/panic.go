@@ -0,0 +1,19 @@
+package httpx
+
+import (
+	"context"
+	"github.com/kibu-sh/kibu/pkg/transport"
+)
+
+// PanicPolicy decides how a Handler reports the panics it recovers
+// panics are always logged with their stack and answered with a 500 encoded by the ErrorEncoder of the codec
+type PanicPolicy struct {
+	// Repanic raises the panic again once it has been logged and answered (i.e. to fail loudly in development)
+	Repanic bool
+
+	// OnPanic is called with every recovered panic (i.e. to report it to an error tracker)
+	OnPanic func(ctx context.Context, err *transport.PanicError)
+}
+
+// DefaultPanicPolicy recovers panics without reporting them anywhere but the logs
+var DefaultPanicPolicy = PanicPolicy{}
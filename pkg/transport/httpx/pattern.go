@@ -0,0 +1,36 @@
+package httpx
+
+import (
+	"github.com/kibu-sh/kibu/pkg/transport/pathparam"
+)
+
+// stdLibPattern converts gin style parameters of a route to http.ServeMux wildcards
+//
+//	/accounts/:id/files/*path → /accounts/{id}/files/{path...}
+func stdLibPattern(path string) string {
+	return pathparam.Replace(path, func(match string, param pathparam.Param) string {
+		switch {
+		case param.Anchor:
+			return match
+		case param.Wildcard:
+			return "{" + param.Name + "...}"
+		}
+		return "{" + param.Name + "}"
+	})
+}
+
+// ginPattern converts http.ServeMux wildcards of a route to gin style parameters
+// the end of path anchor {$} is dropped since gin routes always match exactly
+//
+//	/accounts/{id}/files/{path...} → /accounts/:id/files/*path
+func ginPattern(path string) string {
+	return pathparam.Replace(path, func(match string, param pathparam.Param) string {
+		switch {
+		case param.Anchor:
+			return ""
+		case param.Wildcard:
+			return "*" + param.Name
+		}
+		return ":" + param.Name
+	})
+}
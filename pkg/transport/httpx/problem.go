@@ -0,0 +1,145 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"net/http"
+)
+
+// ProblemJSONContentType is the media type of RFC 9457 problem details
+const ProblemJSONContentType = "application/problem+json"
+
+// ProblemTypeBlank is the default problem type, the problem has no semantics beyond its status code
+const ProblemTypeBlank = "about:blank"
+
+// Problem is an RFC 9457 problem details object
+// Extensions are written as additional members of the object and can't override the standard members
+//
+//	{"type": "https://example.com/probs/out-of-credit", "title": "You do not have enough credit.", "status": 403, "balance": 30}
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]any
+}
+
+// ProblemDetailer is implemented by errors that describe themselves as a problem
+// zero members are completed by the ProblemJSONErrorEncoder from the status of the error
+type ProblemDetailer interface {
+	ProblemDetails() Problem
+}
+
+var _ error = Problem{}
+var _ transport.ErrorResponse = Problem{}
+
+func (p Problem) Error() string {
+	if p.Detail != "" {
+		return fmt.Sprintf("%d: %s: %s", p.Status, p.Title, p.Detail)
+	}
+	return fmt.Sprintf("%d: %s", p.Status, p.Title)
+}
+
+func (p Problem) GetStatusCode() int {
+	return p.Status
+}
+
+func (p Problem) PrepareResponse() any {
+	return p
+}
+
+func (p Problem) ProblemDetails() Problem {
+	return p
+}
+
+// MarshalJSON flattens the extension members into the problem object
+func (p Problem) MarshalJSON() ([]byte, error) {
+	members := make(map[string]any, len(p.Extensions)+5)
+	for key, value := range p.Extensions {
+		members[key] = value
+	}
+
+	members["type"] = p.Type
+	members["title"] = p.Title
+	members["status"] = p.Status
+	for key, value := range map[string]string{"detail": p.Detail, "instance": p.Instance} {
+		delete(members, key)
+		if value != "" {
+			members[key] = value
+		}
+	}
+	return json.Marshal(members)
+}
+
+// NewProblem describes an error as a problem
+// errors implementing ProblemDetailer supply their own details
+// binding and validation errors list the offending fields in the errors extension member
+// errors implementing transport.ErrorResponse keep their status code
+// requests exceeding the Limits of a Handler are answered with 408, 413 or 431
+// the message of panics and any other error is hidden since it may leak internal details to public clients
+func NewProblem(err error) (problem Problem) {
+	var detailer ProblemDetailer
+	var bindingErr *transport.BindingError
+	var validationErr *transport.ValidationError
+	var errRes transport.ErrorResponse
+
+	_, isPanic := transport.AsPanicError(err)
+	status, isTransportErr := errorStatus(err)
+
+	switch {
+	case isPanic:
+		problem = Problem{Status: http.StatusInternalServerError}
+	case errors.As(err, &detailer):
+		// the details take precedence over the transport error they wrap (i.e. transport.ErrForbidden)
+		problem = detailer.ProblemDetails()
+		if problem.Status == 0 && isTransportErr {
+			problem.Status = status
+		}
+	case isTransportErr:
+		problem = Problem{Status: status, Detail: err.Error()}
+	case errors.As(err, &bindingErr):
+		problem = Problem{Status: http.StatusBadRequest, Detail: bindingErr.Error()}
+		problem.Extensions = fieldErrorsExtension(bindingErr.Fields)
+	case errors.As(err, &validationErr):
+		problem = Problem{Status: http.StatusUnprocessableEntity, Detail: validationErr.Error()}
+		problem.Extensions = fieldErrorsExtension(validationErr.Fields)
+	case errors.As(err, &errRes):
+		problem = Problem{Status: errRes.GetStatusCode(), Detail: err.Error()}
+	default:
+		problem = Problem{Status: http.StatusInternalServerError}
+	}
+
+	if problem.Status == 0 {
+		problem.Status = http.StatusInternalServerError
+	}
+	if problem.Type == "" {
+		problem.Type = ProblemTypeBlank
+	}
+	if problem.Title == "" {
+		problem.Title = http.StatusText(problem.Status)
+	}
+	return
+}
+
+func fieldErrorsExtension(fields transport.FieldErrors) map[string]any {
+	if len(fields) == 0 {
+		return nil
+	}
+	return map[string]any{"errors": fields}
+}
+
+// ProblemJSONErrorEncoder renders errors as RFC 9457 problem details
+// select it for a single handler with Handler.WithCodec(DefaultCodec.WithErrorEncoder(ProblemJSONErrorEncoder()))
+// or for every handler by assigning DefaultCodec.ErrorEncoder before handlers are created
+func ProblemJSONErrorEncoder() transport.ErrorEncoderFunc {
+	return func(ctx context.Context, writer transport.Response, err error) error {
+		problem := NewProblem(err)
+		writer.SetStatusCode(problem.Status)
+		writer.Headers().Set("Content-Type", ProblemJSONContentType)
+		return json.NewEncoder(writer).Encode(problem)
+	}
+}
@@ -0,0 +1,118 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type outOfCreditError struct {
+	balance int
+}
+
+func (e outOfCreditError) Error() string {
+	return "out of credit"
+}
+
+func (e outOfCreditError) ProblemDetails() Problem {
+	return Problem{
+		Type:       "https://example.com/probs/out-of-credit",
+		Title:      "You do not have enough credit.",
+		Status:     http.StatusForbidden,
+		Detail:     "Your current balance is 30, but that costs 50.",
+		Instance:   "/account/12345/msgs/abc",
+		Extensions: map[string]any{"balance": e.balance, "status": 0},
+	}
+}
+
+func TestProblemJSONErrorEncoder(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		status   int
+		expected string
+	}{
+		{
+			name:   "should render problem details supplied by the error",
+			err:    errors.Join(errors.New("wrapped"), outOfCreditError{balance: 30}),
+			status: http.StatusForbidden,
+			expected: `{
+				"type": "https://example.com/probs/out-of-credit",
+				"title": "You do not have enough credit.",
+				"status": 403,
+				"detail": "Your current balance is 30, but that costs 50.",
+				"instance": "/account/12345/msgs/abc",
+				"balance": 30
+			}`,
+		},
+		{
+			name:   "should prefer problem details over the transport error they wrap",
+			err:    errors.Join(transport.ErrForbidden, outOfCreditError{balance: 30}),
+			status: http.StatusForbidden,
+			expected: `{
+				"type": "https://example.com/probs/out-of-credit",
+				"title": "You do not have enough credit.",
+				"status": 403,
+				"detail": "Your current balance is 30, but that costs 50.",
+				"instance": "/account/12345/msgs/abc",
+				"balance": 30
+			}`,
+		},
+		{
+			name:   "should list the fields of binding errors",
+			err:    transport.NewBindingError(transport.FieldError{Field: "id", Location: transport.LocationPath, Reason: "invalid syntax"}),
+			status: http.StatusBadRequest,
+			expected: `{
+				"type": "about:blank",
+				"title": "Bad Request",
+				"status": 400,
+				"detail": "failed to bind request: path id: invalid syntax",
+				"errors": [{"field": "id", "location": "path", "reason": "invalid syntax"}]
+			}`,
+		},
+		{
+			name:   "should keep the status of error responses",
+			err:    DefaultJSONError{Status: http.StatusNotFound, Message: "account not found"},
+			status: http.StatusNotFound,
+			expected: `{
+				"type": "about:blank",
+				"title": "Not Found",
+				"status": 404,
+				"detail": "404: account not found"
+			}`,
+		},
+		{
+			name:     "should hide the message of unknown errors",
+			err:      errors.New("pq: connection refused"),
+			status:   http.StatusInternalServerError,
+			expected: `{"type": "about:blank", "title": "Internal Server Error", "status": 500}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			err := ProblemJSONErrorEncoder()(context.Background(), NewResponse(w), test.err)
+			require.NoError(t, err)
+			require.Equal(t, test.status, w.Code)
+			require.Equal(t, ProblemJSONContentType, w.Header().Get("Content-Type"))
+			require.JSONEq(t, test.expected, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_WithCodec(t *testing.T) {
+	svc := testSvc{}
+	h := NewHandler("/", transport.NewEndpoint(svc.Count)).
+		WithMethods(http.MethodPost).
+		WithCodec(ProblemJSONCodec)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/?count=abc", nil))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Equal(t, ProblemJSONContentType, w.Header().Get("Content-Type"))
+}
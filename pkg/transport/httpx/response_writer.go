@@ -1,15 +1,18 @@
 package httpx
 
 import (
+	"bufio"
 	"bytes"
 	"github.com/kibu-sh/kibu/pkg/transport"
 	"io"
+	"net"
 	"net/http"
 	"time"
 )
 
 var _ transport.Response = (*ResponseWriter)(nil)
 var _ io.Writer = (*ResponseWriter)(nil)
+var _ http.Hijacker = (*ResponseWriter)(nil)
 
 type ResponseWriter struct {
 	http.ResponseWriter
@@ -40,6 +43,11 @@ func (r *ResponseWriter) BytesWritten() int64 {
 	return r.bytesWritten
 }
 
+// Written reports whether the status code was sent, after which the response can no longer be replaced
+func (r *ResponseWriter) Written() bool {
+	return r.sentStatusCode != 0
+}
+
 func (r *ResponseWriter) DelCookie(cookie http.Cookie) transport.Response {
 	cookie.Value = ""
 	cookie.Path = "/"
@@ -68,6 +76,21 @@ func (r *ResponseWriter) SetStatusCode(i int) {
 	r.sentStatusCode = i
 }
 
+// WriteHeader records the status code written by code that only knows about http.ResponseWriter
+func (r *ResponseWriter) WriteHeader(code int) {
+	r.SetStatusCode(code)
+}
+
+// Hijack takes over the connection (i.e. to upgrade it to a WebSocket)
+// the response is logged with 101 Switching Protocols
+func (r *ResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(r.ResponseWriter).Hijack()
+	if err == nil {
+		r.sentStatusCode = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
 func (r *ResponseWriter) GetStatusCode() int {
 	return r.sentStatusCode
 }
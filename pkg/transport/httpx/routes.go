@@ -0,0 +1,23 @@
+package httpx
+
+import (
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"net/http"
+)
+
+// RoutesPath is where NewRoutesHandler is mounted on the admin server by wireset
+const RoutesPath = "/_kibu/routes"
+
+// NewRoutesHandler serves transport.Describe for the registry so that `kibu routes` can list what a binary serves
+//
+//	[{"id": "billingv1.Service", "operations": [{"id": "billingv1.Service.GetAccount", "path": "/accounts/{id}", ...}]}]
+func NewRoutesHandler(reg transport.Registry) *Handler {
+	return NewHandler(RoutesPath, transport.HandlerFunc(func(tctx transport.Context) error {
+		services := transport.Describe(reg)
+		if services == nil {
+			services = []transport.ServiceInfo{}
+		}
+		tctx.Response().SetStatusCode(http.StatusOK)
+		return tctx.Codec().Encode(tctx.Request().Context(), tctx.Response(), services)
+	}))
+}
@@ -0,0 +1,47 @@
+package httpx
+
+import (
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewRoutesHandler(t *testing.T) {
+	t.Run("should list registered operations", func(t *testing.T) {
+		reg := transport.NewRegistry()
+		svc := transport.NewService("billingv1.Service")
+		svc.WithOperations(transport.NewOperationWithInfo(transport.OperationInfo{
+			ID:       "billingv1.Service.GetAccount",
+			Path:     "/accounts/{id}",
+			Methods:  []string{http.MethodGet},
+			Request:  "billingv1.GetAccountRequest",
+			Response: "billingv1.Account",
+			Tags:     []string{"audit"},
+		}, transport.NewEndpoint(testSvc{}.Call)))
+		svc.Register(reg)
+
+		w := httptest.NewRecorder()
+		NewRoutesHandler(reg).ServeHTTP(w, httptest.NewRequest(http.MethodGet, RoutesPath, nil))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.JSONEq(t, `[{
+			"id": "billingv1.Service",
+			"operations": [{
+				"id": "billingv1.Service.GetAccount",
+				"path": "/accounts/{id}",
+				"methods": ["GET"],
+				"request": "billingv1.GetAccountRequest",
+				"response": "billingv1.Account",
+				"tags": ["audit"]
+			}]
+		}]`, w.Body.String())
+	})
+
+	t.Run("should list nothing for an empty registry", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		NewRoutesHandler(transport.NewRegistry()).ServeHTTP(w, httptest.NewRequest(http.MethodGet, RoutesPath, nil))
+		require.JSONEq(t, `[]`, w.Body.String())
+	})
+}
@@ -1,7 +1,9 @@
 package httpx
 
 import (
+	"github.com/kibu-sh/kibu/pkg/transport/pathparam"
 	"net/http"
+	"net/url"
 )
 
 type ServeMux interface {
@@ -18,8 +20,38 @@ type StdLibMux struct {
 	mux *http.ServeMux
 }
 
+// Handle registers a method qualified pattern for every method of the handler
+// gin style parameters are converted to wildcards and their values are captured with r.PathValue
+//
+//	GET /accounts/{id}
 func (s StdLibMux) Handle(handler *Handler) {
-	s.mux.Handle(handler.Path, handler)
+	pattern := stdLibPattern(handler.Path)
+	h := captureStdLibParams(pathparam.Names(pattern), handler)
+
+	if len(handler.Methods) == 0 {
+		s.mux.Handle(pattern, h)
+		return
+	}
+
+	for _, method := range handler.Methods {
+		s.mux.Handle(method+" "+pattern, h)
+	}
+}
+
+// captureStdLibParams exposes the wildcards matched by http.ServeMux through PathParamsFromContext
+// so the PathParamsDecoder binds requests the same way it does for GinMux
+func captureStdLibParams(names []string, next http.Handler) http.Handler {
+	if len(names) == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := make(url.Values, len(names))
+		for _, name := range names {
+			params.Set(name, r.PathValue(name))
+		}
+		next.ServeHTTP(w, r.WithContext(ContextWithPathParams(r.Context(), params)))
+	})
 }
 
 func NewStdLibMux() *StdLibMux {
@@ -15,3 +15,41 @@ func TestServer(t *testing.T) {
 	require.HTTPStatusCode(t, http.HandlerFunc(m.ServeHTTP), "GET", "/home", nil, http.StatusOK)
 	require.HTTPStatusCode(t, http.HandlerFunc(m.ServeHTTP), "GET", "/example", nil, http.StatusNotFound)
 }
+
+func TestStdLibMux_PathParams(t *testing.T) {
+	svc := testSvc{}
+
+	for _, path := range []string{"/home/{name}", "/home/:name"} {
+		t.Run(path, func(t *testing.T) {
+			m := NewStdLibMux()
+			m.Handle(NewHandler(path, transport.NewEndpoint(svc.Call)).WithMethods(http.MethodGet, http.MethodPut))
+			require.HTTPBodyContains(t, http.HandlerFunc(m.ServeHTTP), "GET", "/home/test", nil, "test")
+			require.HTTPBodyContains(t, http.HandlerFunc(m.ServeHTTP), "PUT", "/home/test", nil, "test")
+			require.HTTPStatusCode(t, http.HandlerFunc(m.ServeHTTP), "POST", "/home/test", nil, http.StatusMethodNotAllowed)
+			require.HTTPStatusCode(t, http.HandlerFunc(m.ServeHTTP), "GET", "/home/test/nested", nil, http.StatusNotFound)
+		})
+	}
+}
+
+func TestRoutePatterns(t *testing.T) {
+	tests := []struct {
+		path   string
+		stdlib string
+		gin    string
+	}{
+		{"/accounts", "/accounts", "/accounts"},
+		{"/accounts/{id}", "/accounts/{id}", "/accounts/:id"},
+		{"/accounts/:id", "/accounts/{id}", "/accounts/:id"},
+		{"/files/{path...}", "/files/{path...}", "/files/*path"},
+		{"/files/*path", "/files/{path...}", "/files/*path"},
+		{"/accounts/{$}", "/accounts/{$}", "/accounts/"},
+		{"/v1/items:batchGet", "/v1/items:batchGet", "/v1/items:batchGet"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			require.Equal(t, test.stdlib, stdLibPattern(test.path))
+			require.Equal(t, test.gin, ginPattern(test.path))
+		})
+	}
+}
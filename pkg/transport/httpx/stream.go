@@ -0,0 +1,272 @@
+package httpx
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/kibu-sh/kibu/pkg/request"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	// MediaTypeEventStream is the content type of server-sent events
+	MediaTypeEventStream = "text/event-stream"
+
+	// LastEventIDHeader is sent by reconnecting EventSource clients with the id of the last event they received
+	LastEventIDHeader = "Last-Event-ID"
+
+	// SSEErrorEvent is the event name used to report errors after a stream has been opened
+	SSEErrorEvent = "error"
+)
+
+// SSEEventNamer can be implemented by stream events to set the event field of a server-sent event
+// clients subscribe to named events with EventSource.addEventListener(name)
+type SSEEventNamer interface {
+	SSEEventName() string
+}
+
+var _ transport.Streamer = (*Context)(nil)
+
+// OpenStream implements transport.Streamer by upgrading the response to text/event-stream
+// errors sent after the stream is opened are encoded by the codec of the context
+func (c *Context) OpenStream() (transport.Stream, error) {
+	stream, err := NewSSEStream(c.req, c.writer)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.codec != nil {
+		stream.ErrorEncoder = c.codec
+	}
+	return stream, nil
+}
+
+var _ transport.Stream = (*SSEStream)(nil)
+
+// SSEStream is a transport.Stream that speaks text/event-stream
+// every message is flushed as soon as it is written, so the underlying writer must support http.Flusher
+// events are encoded as JSON and bypass the response body buffer used for logging
+type SSEStream struct {
+	// ErrorEncoder encodes the data of the events sent by Fail, it defaults to JSONErrorEncoder
+	ErrorEncoder transport.ErrorEncoder
+
+	mtx         sync.Mutex
+	req         *Request
+	writer      *ResponseWriter
+	controller  *http.ResponseController
+	lastEventID string
+	closed      bool
+}
+
+// NewSSEStream writes the event stream headers and flushes them to the client
+func NewSSEStream(req *Request, writer *ResponseWriter) (*SSEStream, error) {
+	controller := http.NewResponseController(writer.ResponseWriter)
+
+	headers := writer.Headers()
+	headers.Set("Content-Type", MediaTypeEventStream)
+	headers.Set("Cache-Control", "no-cache")
+	headers.Set("Connection", "keep-alive")
+	// disables response buffering in nginx and similar proxies
+	headers.Set("X-Accel-Buffering", "no")
+	writer.SetStatusCode(http.StatusOK)
+
+	if err := controller.Flush(); err != nil {
+		return nil, errors.Wrap(transport.ErrStreamingNotSupported, err.Error())
+	}
+
+	return &SSEStream{
+		ErrorEncoder: JSONErrorEncoder(),
+		req:          req,
+		writer:       writer,
+		controller:   controller,
+		lastEventID:  req.Headers().Get(LastEventIDHeader),
+	}, nil
+}
+
+func (s *SSEStream) Send(ctx context.Context, id string, event any) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode stream event")
+	}
+
+	var name string
+	if namer, ok := event.(SSEEventNamer); ok {
+		name = namer.SSEEventName()
+	}
+
+	return s.write(ctx, formatSSEMessage(id, name, data))
+}
+
+// Heartbeat writes a comment line, which EventSource clients discard
+func (s *SSEStream) Heartbeat(ctx context.Context) error {
+	return s.write(ctx, []byte(":\n\n"))
+}
+
+// Fail sends the error as an event named SSEErrorEvent with the payload written by ErrorEncoder
+// the status code and headers set by ErrorEncoder are dropped, as they were sent when the stream was opened
+func (s *SSEStream) Fail(ctx context.Context, err error) error {
+	buf := &eventBuffer{header: http.Header{}}
+	if encodeErr := s.ErrorEncoder.EncodeError(ctx, NewResponse(buf), err); encodeErr != nil {
+		return errors.Wrap(encodeErr, "failed to encode stream error")
+	}
+	return s.write(ctx, formatSSEMessage("", SSEErrorEvent, bytes.TrimSpace(buf.Bytes())))
+}
+
+func (s *SSEStream) Close() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.closed = true
+	return nil
+}
+
+func (s *SSEStream) LastEventID() string {
+	return s.lastEventID
+}
+
+func (s *SSEStream) write(ctx context.Context, msg []byte) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.closed {
+		return transport.ErrStreamClosed
+	}
+
+	// the request context is cancelled when the client disconnects
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := s.req.Context().Err(); err != nil {
+		return err
+	}
+
+	written, err := s.writer.ResponseWriter.Write(msg)
+	s.writer.bytesWritten += int64(written)
+	if err != nil {
+		return err
+	}
+
+	return s.controller.Flush()
+}
+
+// formatSSEMessage renders a single server-sent event
+// multi-line data is split across data fields as required by the spec
+//
+//	id: 42
+//	event: account.updated
+//	data: {"id":"42"}
+func formatSSEMessage(id, name string, data []byte) []byte {
+	buf := new(bytes.Buffer)
+	if id != "" {
+		_, _ = fmt.Fprintf(buf, "id: %s\n", sanitizeSSEField(id))
+	}
+	if name != "" {
+		_, _ = fmt.Fprintf(buf, "event: %s\n", sanitizeSSEField(name))
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		_, _ = fmt.Fprintf(buf, "data: %s\n", strings.TrimSuffix(line, "\r"))
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+var _ http.ResponseWriter = (*eventBuffer)(nil)
+
+// eventBuffer collects the body written by an ErrorEncoder, so it can be sent as the data of an event
+type eventBuffer struct {
+	bytes.Buffer
+	header http.Header
+}
+
+func (b *eventBuffer) Header() http.Header {
+	return b.header
+}
+
+func (b *eventBuffer) WriteHeader(int) {}
+
+// sanitizeSSEField strips line breaks that would terminate a field early
+func sanitizeSSEField(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
+
+// DoSSEStream calls a stream endpoint and forwards every event it receives to sink
+// it is the client counterpart of SSEStream and is used by generated service clients
+// the id of the last event seen by sink is sent as Last-Event-ID so the server can resume
+// an error event sent by the server is decoded as DefaultJSONError and returned
+func DoSSEStream[Event any](ctx context.Context, client *request.Client, sink transport.StreamSink[Event]) error {
+	client = client.WithHeader("Accept", MediaTypeEventStream)
+	if id := sink.LastEventID(); id != "" {
+		client = client.WithHeader(LastEventIDHeader, id)
+	}
+
+	return client.DoAsStream(ctx, func(body io.Reader) error {
+		return readSSEStream(ctx, body, func(id, name string, data []byte) error {
+			if name == SSEErrorEvent {
+				errRes := DefaultJSONError{}
+				if err := json.Unmarshal(data, &errRes); err != nil {
+					return errors.Wrap(err, "failed to decode stream error")
+				}
+				return errRes
+			}
+
+			event := new(Event)
+			if err := json.Unmarshal(data, event); err != nil {
+				return errors.Wrap(err, "failed to decode stream event")
+			}
+
+			if id != "" {
+				return sink.SendWithID(ctx, id, *event)
+			}
+			return sink.Send(ctx, *event)
+		})
+	})
+}
+
+// readSSEStream parses text/event-stream messages from r and calls dispatch once per event
+// comments (heartbeats) and retry fields are ignored
+func readSSEStream(ctx context.Context, r io.Reader, dispatch func(id, name string, data []byte) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var id, name string
+	var data []string
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		line := scanner.Text()
+		if line == "" {
+			if len(data) > 0 {
+				if err := dispatch(id, name, []byte(strings.Join(data, "\n"))); err != nil {
+					return err
+				}
+			}
+			id, name, data = "", "", nil
+			continue
+		}
+
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			id = value
+		case "event":
+			name = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,214 @@
+package httpx
+
+import (
+	"context"
+	"github.com/kibu-sh/kibu/pkg/request"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testStreamReq struct {
+	Count int `query:"count"`
+}
+
+func (r testStreamReq) Validate() error {
+	if r.Count < 0 {
+		return transport.FieldErrors{{Field: "count", Location: transport.LocationQuery, Reason: "must not be negative"}}
+	}
+	return nil
+}
+
+type testStreamEvent struct {
+	Seq  int    `json:"seq"`
+	Note string `json:"note"`
+}
+
+func (s testSvc) Watch(ctx context.Context, req testStreamReq, sink transport.StreamSink[testStreamEvent]) error {
+	start := 0
+	if id := sink.LastEventID(); id != "" {
+		start, _ = strconv.Atoi(id)
+		start++
+	}
+
+	if err := sink.Heartbeat(ctx); err != nil {
+		return err
+	}
+
+	for i := start; i < req.Count; i++ {
+		if err := sink.SendWithID(ctx, strconv.Itoa(i), testStreamEvent{Seq: i, Note: "line\nbreak"}); err != nil {
+			return err
+		}
+	}
+
+	if req.Count == 13 {
+		return errors.New("unlucky")
+	}
+	return nil
+}
+
+var _ transport.StreamSink[testStreamEvent] = (*collectingSink)(nil)
+
+type collectingSink struct {
+	lastEventID string
+	events      []testStreamEvent
+	ids         []string
+}
+
+func (c *collectingSink) Send(ctx context.Context, event testStreamEvent) error {
+	return c.SendWithID(ctx, "", event)
+}
+
+func (c *collectingSink) SendWithID(ctx context.Context, id string, event testStreamEvent) error {
+	c.events = append(c.events, event)
+	c.ids = append(c.ids, id)
+	return nil
+}
+
+func (c *collectingSink) Heartbeat(ctx context.Context) error { return nil }
+
+func (c *collectingSink) Close() error { return nil }
+
+func (c *collectingSink) LastEventID() string { return c.lastEventID }
+
+func TestStreamEndpoint(t *testing.T) {
+	svc := testSvc{}
+	server := httptest.NewServer(NewHandler("/", transport.NewStreamEndpoint(svc.Watch)))
+	t.Cleanup(server.Close)
+
+	client, err := request.ParseURL(server.URL)
+	require.NoError(t, err)
+	client = client.WithErrorDecoder(request.JSONErrorDecoder[DefaultJSONError])
+
+	stream := func(t *testing.T, count int, sink *collectingSink) error {
+		rc, err := NewClientRequest(client, http.MethodGet, "/", testStreamReq{Count: count})
+		require.NoError(t, err)
+		return DoSSEStream[testStreamEvent](context.Background(), rc, sink)
+	}
+
+	t.Run("should send events as text/event-stream", func(t *testing.T) {
+		res, err := http.Get(server.URL + "/?count=1")
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		require.Equal(t, MediaTypeEventStream, res.Header.Get("Content-Type"))
+		require.Equal(t, "no-cache", res.Header.Get("Cache-Control"))
+
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.Equal(t, ":\n\nid: 0\ndata: {\"seq\":0,\"note\":\"line\\nbreak\"}\n\n", string(body))
+	})
+
+	t.Run("should decode events on the client", func(t *testing.T) {
+		sink := new(collectingSink)
+		require.NoError(t, stream(t, 3, sink))
+		require.Equal(t, []string{"0", "1", "2"}, sink.ids)
+		require.Equal(t, testStreamEvent{Seq: 2, Note: "line\nbreak"}, sink.events[2])
+	})
+
+	t.Run("should resume after Last-Event-ID", func(t *testing.T) {
+		sink := &collectingSink{lastEventID: "1"}
+		require.NoError(t, stream(t, 4, sink))
+		require.Equal(t, []string{"2", "3"}, sink.ids)
+	})
+
+	t.Run("should reject invalid requests before opening the stream", func(t *testing.T) {
+		err := stream(t, -1, new(collectingSink))
+		var errRes DefaultJSONError
+		require.ErrorAs(t, err, &errRes)
+		require.Equal(t, http.StatusUnprocessableEntity, errRes.Status)
+	})
+
+	t.Run("should deliver errors in band after the stream is opened", func(t *testing.T) {
+		sink := new(collectingSink)
+		err := stream(t, 13, sink)
+		var errRes DefaultJSONError
+		require.ErrorAs(t, err, &errRes)
+		require.Equal(t, http.StatusInternalServerError, errRes.Status)
+		require.Len(t, sink.events, 13)
+	})
+}
+
+func TestStreamEndpoint_ErrorEncoder(t *testing.T) {
+	svc := testSvc{}
+	server := httptest.NewServer(NewHandler("/", transport.NewStreamEndpoint(svc.Watch)).WithCodec(ProblemJSONCodec))
+	t.Cleanup(server.Close)
+
+	res, err := http.Get(server.URL + "/?count=13")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	_, event, ok := strings.Cut(string(body), "event: "+SSEErrorEvent+"\ndata: ")
+	require.True(t, ok, "the stream should end with an error event")
+	require.JSONEq(t, `{"type":"about:blank","title":"Internal Server Error","status":500}`, strings.TrimSpace(event),
+		"errors should be encoded by the codec of the handler")
+}
+
+func TestStreamEndpoint_ClientDisconnect(t *testing.T) {
+	done := make(chan error, 1)
+	endpoint := transport.NewStreamEndpoint(func(ctx context.Context, req testStreamReq, sink transport.StreamSink[testStreamEvent]) error {
+		if err := sink.Send(ctx, testStreamEvent{Seq: 1}); err != nil {
+			return err
+		}
+		<-ctx.Done()
+		done <- ctx.Err()
+		return ctx.Err()
+	})
+
+	server := httptest.NewServer(NewHandler("/", endpoint))
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	buf := make([]byte, 64)
+	_, err = res.Body.Read(buf)
+	require.NoError(t, err)
+	cancel()
+
+	select {
+	case err := <-done:
+		require.ErrorIs(t, err, context.Canceled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("endpoint context was not cancelled after the client disconnected")
+	}
+}
+
+func TestStreamEndpoint_MiddlewareRejection(t *testing.T) {
+	var called bool
+	endpoint := transport.NewStreamEndpoint(func(ctx context.Context, req testStreamReq, sink transport.StreamSink[testStreamEvent]) error {
+		called = true
+		return nil
+	}).WithMiddleware(transport.NewMiddleware(func(tctx transport.Context, next transport.Handler) error {
+		tctx.Response().Headers().Set("WWW-Authenticate", "Bearer")
+		return transport.ErrUnauthenticated
+	}))
+
+	server := httptest.NewServer(NewHandler("/", endpoint))
+	t.Cleanup(server.Close)
+
+	res, err := http.Get(server.URL)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	require.Equal(t, "Bearer", res.Header.Get("WWW-Authenticate"))
+	require.NotEqual(t, MediaTypeEventStream, res.Header.Get("Content-Type"))
+	require.False(t, called)
+}
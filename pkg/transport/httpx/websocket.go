@@ -0,0 +1,321 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/kibu-sh/kibu/pkg/request"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/pkg/errors"
+	"io"
+	"net"
+	"net/http"
+	"nhooyr.io/websocket"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// webSocketCloseStatusOffset maps http status codes into the range of close codes reserved for applications
+// a 422 returned by an endpoint closes the connection with 4422
+const webSocketCloseStatusOffset = 4000
+
+// maxCloseReasonLength is the maximum length of a close reason allowed by RFC 6455
+const maxCloseReasonLength = 123
+
+// WebSocketOptions configures connections upgraded by Context.OpenChannel and DialWebSocket
+type WebSocketOptions struct {
+	// PingInterval is the time between keepalive pings, zero disables them
+	PingInterval time.Duration
+
+	// PingTimeout bounds how long to wait for a pong before the connection is closed
+	PingTimeout time.Duration
+
+	// ReadLimit is the maximum size of a single message in bytes
+	ReadLimit int64
+
+	// OriginPatterns lists the origins authorized to open cross-origin connections
+	// the request host is always authorized
+	OriginPatterns []string
+}
+
+// DefaultWebSocketOptions pings idle connections every 30 seconds and accepts messages up to 1MiB
+var DefaultWebSocketOptions = WebSocketOptions{
+	PingInterval: 30 * time.Second,
+	PingTimeout:  10 * time.Second,
+	ReadLimit:    1 << 20,
+}
+
+var _ transport.Channeler = (*Context)(nil)
+
+// OpenChannel implements transport.Channeler by upgrading the request to a WebSocket
+// a failed upgrade has already written its response, so the error is marked as transport.ErrResponseIntercepted
+func (c *Context) OpenChannel() (transport.Conn, error) {
+	conn, err := AcceptWebSocket(c.writer, c.req.Underlying().(*http.Request), c.webSocket)
+	if err != nil {
+		return nil, errors.Wrap(transport.ErrResponseIntercepted, err.Error())
+	}
+	return conn, nil
+}
+
+var _ transport.Conn = (*WebSocketConn)(nil)
+
+// WebSocketConn is a transport.Conn exchanging JSON encoded text messages
+// messages are read in the background, so keepalive pongs are processed even while nothing is received
+type WebSocketConn struct {
+	conn      *websocket.Conn
+	ctx       context.Context
+	cancel    context.CancelFunc
+	messages  chan []byte
+	readErr   error
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// AcceptWebSocket upgrades an HTTP request and starts the keepalive loop
+func AcceptWebSocket(w http.ResponseWriter, r *http.Request, opts WebSocketOptions) (*WebSocketConn, error) {
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		OriginPatterns: opts.OriginPatterns,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newWebSocketConn(r.Context(), conn, opts), nil
+}
+
+// DialWebSocket opens a connection to the URL of client, sending its headers with the handshake
+// a rejected handshake is decoded with the error decoder of client
+func DialWebSocket(ctx context.Context, client *request.Client, opts WebSocketOptions) (*WebSocketConn, error) {
+	conn, res, err := websocket.Dial(ctx, client.URL().String(), &websocket.DialOptions{
+		HTTPClient: client.HTTPClient(),
+		HTTPHeader: client.Header(),
+	})
+	if err != nil {
+		if res != nil && res.StatusCode != http.StatusSwitchingProtocols {
+			return nil, client.DecodeError(res)
+		}
+		return nil, err
+	}
+	return newWebSocketConn(ctx, conn, opts), nil
+}
+
+func newWebSocketConn(ctx context.Context, conn *websocket.Conn, opts WebSocketOptions) *WebSocketConn {
+	if opts.ReadLimit > 0 {
+		conn.SetReadLimit(opts.ReadLimit)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	c := &WebSocketConn{
+		conn:     conn,
+		ctx:      ctx,
+		cancel:   cancel,
+		messages: make(chan []byte),
+	}
+
+	go c.readLoop()
+	if opts.PingInterval > 0 {
+		go c.keepalive(opts.PingInterval, opts.PingTimeout)
+	}
+	return c
+}
+
+// readLoop delivers messages until the connection fails, the error is kept for Receive before Done is closed
+func (c *WebSocketConn) readLoop() {
+	defer c.cancel()
+	for {
+		_, data, err := c.conn.Read(c.ctx)
+		if err != nil {
+			c.readErr = err
+			return
+		}
+
+		select {
+		case c.messages <- data:
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+// Done is closed once the connection ends
+func (c *WebSocketConn) Done() <-chan struct{} {
+	return c.ctx.Done()
+}
+
+// keepalive closes the connection when the peer stops answering pings
+func (c *WebSocketConn) keepalive(interval, timeout time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			ctx := c.ctx
+			var cancel context.CancelFunc = func() {}
+			if timeout > 0 {
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+			}
+			err := c.conn.Ping(ctx)
+			cancel()
+			if err != nil {
+				_ = c.conn.Close(websocket.StatusPolicyViolation, "keepalive timeout")
+				c.cancel()
+				return
+			}
+		}
+	}
+}
+
+func (c *WebSocketConn) Send(ctx context.Context, msg any) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode message")
+	}
+	return c.conn.Write(ctx, websocket.MessageText, data)
+}
+
+// Receive decodes the next message into msg
+// a normal closure is reported as io.EOF and an application close code is decoded as DefaultJSONError
+func (c *WebSocketConn) Receive(ctx context.Context, msg any) error {
+	var data []byte
+	select {
+	case data = <-c.messages:
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.ctx.Done():
+		return webSocketCloseError(c.readErr)
+	}
+
+	if err := json.Unmarshal(data, msg); err != nil {
+		return transport.NewBindingError(err)
+	}
+	return nil
+}
+
+// Close sends a close frame, an error is mapped to an application close code with its message as the reason
+func (c *WebSocketConn) Close(err error) error {
+	c.closeOnce.Do(func() {
+		defer c.cancel()
+
+		if err == nil {
+			c.closeErr = c.conn.Close(websocket.StatusNormalClosure, "")
+			return
+		}
+
+		errRes := NewDefaultJSONError(err)
+		c.closeErr = c.conn.Close(
+			websocket.StatusCode(webSocketCloseStatusOffset+errRes.GetStatusCode()),
+			truncateCloseReason(errorMessage(errRes)),
+		)
+	})
+
+	if errors.Is(c.closeErr, net.ErrClosed) {
+		return nil
+	}
+	return c.closeErr
+}
+
+// webSocketCloseError translates the error that ended a connection
+func webSocketCloseError(err error) error {
+	if err == nil || errors.Is(err, context.Canceled) {
+		// the connection was closed locally
+		return io.EOF
+	}
+
+	var closeErr websocket.CloseError
+	if !errors.As(err, &closeErr) {
+		return err
+	}
+
+	switch {
+	case closeErr.Code == websocket.StatusNormalClosure, closeErr.Code == websocket.StatusGoingAway:
+		return io.EOF
+	case closeErr.Code >= webSocketCloseStatusOffset+100 && closeErr.Code < webSocketCloseStatusOffset+600:
+		return DefaultJSONError{
+			Status:  int(closeErr.Code) - webSocketCloseStatusOffset,
+			Message: closeErr.Reason,
+		}
+	}
+	return err
+}
+
+func errorMessage(errRes transport.ErrorResponse) string {
+	switch res := errRes.(type) {
+	case DefaultJSONError:
+		return res.Message
+	case error:
+		return res.Error()
+	}
+	return http.StatusText(errRes.GetStatusCode())
+}
+
+// truncateCloseReason shortens a reason to fit a close frame without splitting a rune
+func truncateCloseReason(reason string) string {
+	if len(reason) <= maxCloseReasonLength {
+		return reason
+	}
+
+	reason = reason[:maxCloseReasonLength]
+	for !utf8.ValidString(reason) {
+		reason = reason[:len(reason)-1]
+	}
+	return reason
+}
+
+// DialChannel opens a connection to a channel endpoint
+// the returned channel is the client side view, it receives what the endpoint sends and sends what it receives
+//
+//	ch, err := DialChannel[ChatMessage, ChatReply](ctx, rc, DefaultWebSocketOptions)
+//	err = ch.Send(ctx, ChatMessage{Text: "hello"})
+//	reply, err := ch.Receive(ctx)
+func DialChannel[Send, Receive any](ctx context.Context, client *request.Client, opts WebSocketOptions) (transport.Channel[Receive, Send], error) {
+	conn, err := DialWebSocket(ctx, client, opts)
+	if err != nil {
+		return nil, err
+	}
+	return transport.NewChannel[Receive, Send](conn), nil
+}
+
+// DoWebSocket calls a channel endpoint on behalf of a generated service client
+// messages received from ch are sent to the endpoint, and messages sent by the endpoint are passed to ch.Send
+// the connection is closed normally once ch.Receive returns io.EOF
+func DoWebSocket[In, Out any](ctx context.Context, client *request.Client, ch transport.Channel[In, Out]) error {
+	conn, err := DialWebSocket(ctx, client, DefaultWebSocketOptions)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for {
+			msg, err := ch.Receive(conn.ctx)
+			if errors.Is(err, io.EOF) {
+				_ = conn.Close(nil)
+				return
+			}
+			if err == nil {
+				err = conn.Send(conn.ctx, msg)
+			}
+			if err != nil {
+				_ = conn.Close(err)
+				return
+			}
+		}
+	}()
+
+	for {
+		var msg Out
+		if err = conn.Receive(ctx, &msg); err != nil {
+			_ = conn.Close(nil)
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		if err = ch.Send(ctx, msg); err != nil {
+			_ = conn.Close(err)
+			return err
+		}
+	}
+}
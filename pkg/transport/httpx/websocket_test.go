@@ -0,0 +1,183 @@
+package httpx
+
+import (
+	"context"
+	"github.com/kibu-sh/kibu/pkg/request"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testChatReq struct {
+	Room string `query:"room"`
+}
+
+func (r testChatReq) Validate() error {
+	if r.Room == "" {
+		return transport.FieldErrors{{Field: "room", Location: transport.LocationQuery, Reason: "required"}}
+	}
+	return nil
+}
+
+type testChatMessage struct {
+	Text string `json:"text"`
+}
+
+type testChatReply struct {
+	Room string `json:"room"`
+	Text string `json:"text"`
+}
+
+func (s testSvc) Chat(ctx context.Context, req testChatReq, ch transport.Channel[testChatMessage, testChatReply]) error {
+	for {
+		msg, err := ch.Receive(ctx)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if msg.Text == "fail" {
+			return transport.NewValidationError(errors.New("fail is not allowed"))
+		}
+
+		if err = ch.Send(ctx, testChatReply{Room: req.Room, Text: strings.ToUpper(msg.Text)}); err != nil {
+			return err
+		}
+	}
+}
+
+func newChatClient(t *testing.T, server *httptest.Server, room string) *request.Client {
+	client, err := request.ParseURL(server.URL)
+	require.NoError(t, err)
+	client = client.WithErrorDecoder(request.JSONErrorDecoder[DefaultJSONError])
+
+	rc, err := NewClientRequest(client, http.MethodGet, "/", testChatReq{Room: room})
+	require.NoError(t, err)
+	return rc
+}
+
+func TestChannelEndpoint(t *testing.T) {
+	svc := testSvc{}
+	server := httptest.NewServer(NewHandler("/", transport.NewChannelEndpoint(svc.Chat)))
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+
+	t.Run("should exchange typed messages", func(t *testing.T) {
+		ch, err := DialChannel[testChatMessage, testChatReply](ctx, newChatClient(t, server, "lobby"), DefaultWebSocketOptions)
+		require.NoError(t, err)
+		defer ch.Close()
+
+		for _, text := range []string{"hello", "world"} {
+			require.NoError(t, ch.Send(ctx, testChatMessage{Text: text}))
+			reply, err := ch.Receive(ctx)
+			require.NoError(t, err)
+			require.Equal(t, testChatReply{Room: "lobby", Text: strings.ToUpper(text)}, reply)
+		}
+	})
+
+	t.Run("should reject invalid requests before upgrading", func(t *testing.T) {
+		_, err := DialChannel[testChatMessage, testChatReply](ctx, newChatClient(t, server, ""), DefaultWebSocketOptions)
+		var errRes DefaultJSONError
+		require.ErrorAs(t, err, &errRes)
+		require.Equal(t, http.StatusUnprocessableEntity, errRes.Status)
+	})
+
+	t.Run("should close the connection with the status of an endpoint error", func(t *testing.T) {
+		ch, err := DialChannel[testChatMessage, testChatReply](ctx, newChatClient(t, server, "lobby"), DefaultWebSocketOptions)
+		require.NoError(t, err)
+		defer ch.Close()
+
+		require.NoError(t, ch.Send(ctx, testChatMessage{Text: "fail"}))
+		_, err = ch.Receive(ctx)
+		var errRes DefaultJSONError
+		require.ErrorAs(t, err, &errRes)
+		require.Equal(t, http.StatusUnprocessableEntity, errRes.Status)
+		require.Equal(t, "invalid request: fail is not allowed", errRes.Message)
+	})
+
+	t.Run("should reject plain http requests", func(t *testing.T) {
+		res, err := http.Get(server.URL + "/?room=lobby")
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusUpgradeRequired, res.StatusCode)
+	})
+}
+
+func TestChannelEndpoint_MiddlewareRejection(t *testing.T) {
+	var called bool
+	endpoint := transport.NewChannelEndpoint(func(ctx context.Context, req testChatReq, ch transport.Channel[testChatMessage, testChatReply]) error {
+		called = true
+		return nil
+	}).WithMiddleware(transport.NewMiddleware(func(tctx transport.Context, next transport.Handler) error {
+		return transport.ErrUnauthenticated
+	}))
+
+	server := httptest.NewServer(NewHandler("/", endpoint))
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+
+	_, err := DialChannel[testChatMessage, testChatReply](ctx, newChatClient(t, server, "lobby"), DefaultWebSocketOptions)
+	var errRes DefaultJSONError
+	require.ErrorAs(t, err, &errRes)
+	require.Equal(t, http.StatusUnauthorized, errRes.Status)
+	require.False(t, called)
+}
+
+func TestChannelEndpoint_ClientDisconnect(t *testing.T) {
+	done := make(chan error, 1)
+	endpoint := transport.NewChannelEndpoint(func(ctx context.Context, req testChatReq, ch transport.Channel[testChatMessage, testChatReply]) error {
+		<-ctx.Done()
+		done <- ctx.Err()
+		return nil
+	})
+
+	server := httptest.NewServer(NewHandler("/", endpoint))
+	t.Cleanup(server.Close)
+
+	ch, err := DialChannel[testChatMessage, testChatReply](context.Background(), newChatClient(t, server, "lobby"), DefaultWebSocketOptions)
+	require.NoError(t, err)
+	require.NoError(t, ch.Close())
+
+	select {
+	case err := <-done:
+		require.ErrorIs(t, err, context.Canceled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("endpoint context was not cancelled after the client disconnected")
+	}
+}
+
+func TestWebSocketConn_Keepalive(t *testing.T) {
+	endpoint := transport.NewChannelEndpoint(func(ctx context.Context, req testChatReq, ch transport.Channel[testChatMessage, testChatReply]) error {
+		<-ctx.Done()
+		return nil
+	})
+
+	server := httptest.NewServer(NewHandler("/", endpoint).WithWebSocketOptions(WebSocketOptions{
+		PingInterval: 10 * time.Millisecond,
+		PingTimeout:  time.Second,
+	}))
+	t.Cleanup(server.Close)
+
+	conn, err := DialWebSocket(context.Background(), newChatClient(t, server, "lobby"), WebSocketOptions{})
+	require.NoError(t, err)
+	defer conn.Close(nil)
+
+	// pongs are answered by the background reader, so idle connections outlive many ping intervals
+	select {
+	case <-conn.Done():
+		t.Fatal("connection closed while idle")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
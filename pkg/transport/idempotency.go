@@ -0,0 +1,20 @@
+package transport
+
+import "context"
+
+// Idempotency replays the response of requests that are repeated (i.e. retried POSTs)
+// Begin runs after the middleware of the endpoint (i.e. authentication) and before its func
+// it can write a stored response itself and return ErrResponseIntercepted, so the func isn't called again
+// otherwise the finish func it returns (if any) is called once the response has been written,
+// with the error that prevented it from being written
+type Idempotency interface {
+	Begin(ctx context.Context, tctx Context, request any) (finish func(err error), err error)
+}
+
+// IdempotencyFunc is a functional implementation of the Idempotency interface
+type IdempotencyFunc func(ctx context.Context, tctx Context, request any) (finish func(err error), err error)
+
+// Begin implements Idempotency
+func (f IdempotencyFunc) Begin(ctx context.Context, tctx Context, request any) (finish func(err error), err error) {
+	return f(ctx, tctx, request)
+}
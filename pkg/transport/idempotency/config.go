@@ -0,0 +1,50 @@
+package idempotency
+
+import (
+	"github.com/pkg/errors"
+	"time"
+)
+
+// Config configures a Replayer, wireset reads it from the "idempotency" key of the config store
+//
+//	{"header": "Idempotency-Key", "ttl": "24h", "lock_timeout": "5m"}
+type Config struct {
+	// Header defaults to DefaultHeader
+	Header string `json:"header"`
+
+	// TTL is parsed with time.ParseDuration, it defaults to 24 hours
+	TTL string `json:"ttl"`
+
+	// LockTimeout is parsed with time.ParseDuration, it defaults to 5 minutes
+	LockTimeout string `json:"lock_timeout"`
+}
+
+// Replayer builds the Replayer of cfg on store
+func (cfg Config) Replayer(store Store) (*Replayer, error) {
+	replayer := NewReplayer(store)
+	if cfg.Header != "" {
+		replayer.Header = cfg.Header
+	}
+
+	if err := parseDuration("ttl", cfg.TTL, &replayer.TTL); err != nil {
+		return nil, err
+	}
+	if err := parseDuration("lock_timeout", cfg.LockTimeout, &replayer.LockTimeout); err != nil {
+		return nil, err
+	}
+	return replayer, nil
+}
+
+// parseDuration sets target to the duration of value, unless value is empty
+func parseDuration(name, value string, target *time.Duration) error {
+	if value == "" {
+		return nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return errors.Errorf("invalid idempotency %s %q", name, value)
+	}
+	*target = duration
+	return nil
+}
@@ -0,0 +1,242 @@
+// Package idempotency replays the first response of requests repeated with the same Idempotency-Key header
+// clients retrying a request (i.e. a POST that timed out) get the response of the first attempt
+// instead of running the operation twice
+//
+//	//kibu:service:method path=/invoices method=POST idempotent
+//
+// generated controllers of idempotent operations are injected with a transport.Idempotency, i.e. by wireset.NewIdempotency
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/kibu-sh/kibu/pkg/auth"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/kibu-sh/kibu/pkg/transport/httpx"
+	"github.com/pkg/errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+)
+
+const (
+	// DefaultHeader carries the key chosen by the client for a request and its retries
+	DefaultHeader = "Idempotency-Key"
+
+	// ReplayedHeader is set on responses replayed from the Store
+	ReplayedHeader = "Idempotent-Replayed"
+
+	// MaxKeyLength is the longest key accepted
+	MaxKeyLength = 255
+)
+
+// DefaultStoredHeaders are the response headers that describe the stored body, they are replayed with it
+// other headers (i.e. Set-Cookie, RateLimit-*, tracing) belong to the first response only
+var DefaultStoredHeaders = []string{
+	"Content-Type",
+	"Content-Language",
+	"Content-Disposition",
+	"Location",
+	"ETag",
+	"Last-Modified",
+}
+
+var (
+	// ErrInFlight is returned for a repeated request while the first one is still being served
+	ErrInFlight = fmt.Errorf("%w: a request with this idempotency key is in progress", transport.ErrConflict)
+
+	// ErrKeyReused is returned when a key is sent again with a different request
+	ErrKeyReused = fmt.Errorf("%w: idempotency key was used for a different request", transport.ErrUnprocessable)
+)
+
+// Record is what a Store keeps for a key
+type Record struct {
+	// Fingerprint identifies the request that claimed the key
+	Fingerprint string `json:"fingerprint"`
+
+	// Completed is false while the first request is in flight
+	Completed bool `json:"completed"`
+
+	StatusCode int         `json:"status_code,omitempty"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       []byte      `json:"body,omitempty"`
+}
+
+// Store keeps the Record of every key
+type Store interface {
+	// Claim records an in flight request for key and returns nil, unless key already has a Record, which is returned
+	// claims must be atomic, so only one of concurrent requests claims a key
+	Claim(ctx context.Context, key string, fingerprint string, ttl time.Duration) (existing *Record, err error)
+
+	// Complete replaces the claim of key with the response of its request
+	Complete(ctx context.Context, key string, record Record, ttl time.Duration) error
+
+	// Release forgets the claim of key, so the request can be retried
+	Release(ctx context.Context, key string) error
+}
+
+var _ transport.Idempotency = (*Replayer)(nil)
+
+// Replayer implements transport.Idempotency with a Store
+// keys are scoped to the principal of the request, so callers can't replay each other's responses
+// responses with a 5xx status aren't stored, so the request can be retried
+type Replayer struct {
+	Store Store
+
+	// Header defaults to DefaultHeader
+	Header string
+
+	// TTL is how long responses are replayed, it defaults to 24 hours
+	TTL time.Duration
+
+	// LockTimeout is how long a claim is held by a request that never completes (i.e. the instance crashed)
+	// it defaults to 5 minutes
+	LockTimeout time.Duration
+
+	// StoredHeaders are the response headers replayed with the body, they default to DefaultStoredHeaders
+	StoredHeaders []string
+}
+
+// NewReplayer stores responses in store
+func NewReplayer(store Store) *Replayer {
+	return &Replayer{
+		Store:         store,
+		Header:        DefaultHeader,
+		TTL:           24 * time.Hour,
+		LockTimeout:   5 * time.Minute,
+		StoredHeaders: DefaultStoredHeaders,
+	}
+}
+
+// Begin implements transport.Idempotency
+// requests without a key are served as usual
+func (r *Replayer) Begin(ctx context.Context, tctx transport.Context, request any) (finish func(err error), err error) {
+	req := tctx.Request()
+	key := req.Headers().Get(r.Header)
+	if key == "" {
+		return nil, nil
+	}
+
+	if len(key) > MaxKeyLength {
+		return nil, transport.NewBindingError(transport.FieldError{
+			Field:    r.Header,
+			Location: transport.LocationHeader,
+			Reason:   fmt.Sprintf("must be at most %d characters", MaxKeyLength),
+		})
+	}
+
+	fingerprint, err := Fingerprint(req, request)
+	if err != nil {
+		return nil, err
+	}
+
+	key = scopedKey(ctx, key)
+	existing, err := r.Store.Claim(ctx, key, fingerprint, r.LockTimeout)
+	switch {
+	case err != nil:
+		return nil, errors.Wrap(err, "failed to claim idempotency key")
+	case existing == nil:
+		return r.finish(ctx, tctx, key, fingerprint), nil
+	case existing.Fingerprint != fingerprint:
+		return nil, ErrKeyReused
+	case !existing.Completed:
+		return nil, ErrInFlight
+	}
+	return nil, replay(tctx.Response(), existing)
+}
+
+// finish stores the response of the request that claimed key once it is written
+func (r *Replayer) finish(ctx context.Context, tctx transport.Context, key, fingerprint string) func(err error) {
+	return func(err error) {
+		// the request context may be canceled (i.e. by a timeout) by the time the response is written
+		ctx := context.WithoutCancel(ctx)
+		res := tctx.Response()
+
+		status := res.GetStatusCode()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		if err != nil || status >= http.StatusInternalServerError {
+			if err = r.Store.Release(ctx, key); err != nil {
+				slog.Default().ErrorContext(ctx, "failed to release idempotency key", "error", err)
+			}
+			return
+		}
+
+		err = r.Store.Complete(ctx, key, Record{
+			Fingerprint: fingerprint,
+			Completed:   true,
+			StatusCode:  status,
+			Header:      storedHeaders(res.Headers(), r.StoredHeaders),
+			Body:        bytes.Clone(res.BodyBuffer().Bytes()),
+		}, r.TTL)
+		if err != nil {
+			slog.Default().ErrorContext(ctx, "failed to store idempotent response", "error", err)
+		}
+	}
+}
+
+// storedHeaders copies the headers of names
+func storedHeaders(headers http.Header, names []string) http.Header {
+	stored := http.Header{}
+	for _, name := range names {
+		if values := headers.Values(name); len(values) > 0 {
+			stored[http.CanonicalHeaderKey(name)] = slices.Clone(values)
+		}
+	}
+	return stored
+}
+
+// replay writes a stored response, the endpoint func isn't called
+func replay(res transport.Response, record *Record) error {
+	headers := res.Headers()
+	for name, values := range record.Header {
+		headers[name] = slices.Clone(values)
+	}
+	headers.Set(ReplayedHeader, "true")
+
+	res.SetStatusCode(record.StatusCode)
+	if _, err := res.Write(record.Body); err != nil {
+		return errors.Wrap(transport.ErrResponseIntercepted, err.Error())
+	}
+	return transport.ErrResponseIntercepted
+}
+
+// Fingerprint identifies a request by its method, path, params and body
+// params are encoded like the client encodes them, so fields the body doesn't carry (i.e. query and header params) count
+// a key sent again with another fingerprint is rejected with ErrKeyReused
+func Fingerprint(req transport.Request, request any) (string, error) {
+	params, err := httpx.EncodeRequestParams(request)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to fingerprint request params")
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to fingerprint request body")
+	}
+
+	hash := sha256.New()
+	_, _ = fmt.Fprintf(hash, "%s %s\n", req.Method(), req.Path())
+	for _, values := range []url.Values{params.Path, params.Query, params.Header, params.Cookie} {
+		_, _ = fmt.Fprintf(hash, "%s\n", values.Encode())
+	}
+	_, _ = hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// scopedKey prefixes key with the principal of the request
+func scopedKey(ctx context.Context, key string) string {
+	principal, err := auth.FromContext(ctx)
+	if err != nil {
+		return "anonymous:" + key
+	}
+	return "principal:" + principal.Method + ":" + principal.Subject + ":" + key
+}
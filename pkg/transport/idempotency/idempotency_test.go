@@ -0,0 +1,148 @@
+package idempotency
+
+import (
+	"context"
+	"fmt"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/kibu-sh/kibu/pkg/transport/httpx"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type chargeRequest struct {
+	Amount   int    `json:"amount"`
+	Currency string `json:"-" query:"currency"`
+}
+
+type charge struct {
+	ID     int `json:"id"`
+	Amount int `json:"amount"`
+}
+
+func newTestHandler(replayer *Replayer, charges *atomic.Int64, wait chan struct{}) *httpx.Handler {
+	// a header of every response that isn't part of the stored representation
+	var attempts atomic.Int64
+	session := transport.NewMiddleware(func(tctx transport.Context, next transport.Handler) error {
+		tctx.Response().Headers().Set("Set-Cookie", fmt.Sprintf("attempt=%d", attempts.Add(1)))
+		return next.Serve(tctx)
+	})
+
+	endpoint := transport.NewEndpoint(func(ctx context.Context, req chargeRequest) (res charge, err error) {
+		if wait != nil {
+			<-wait
+		}
+		if req.Amount < 0 {
+			return res, errors.New("ledger unavailable")
+		}
+		return charge{ID: int(charges.Add(1)), Amount: req.Amount}, nil
+	}).WithIdempotency(replayer).WithMiddleware(session)
+	return httpx.NewHandler("/charges", endpoint).WithMethods(http.MethodPost)
+}
+
+func post(h http.Handler, key, body string) *httptest.ResponseRecorder {
+	return postURL(h, "/charges", key, body)
+}
+
+func postURL(h http.Handler, target, key, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		r.Header.Set(DefaultHeader, key)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestReplayer(t *testing.T) {
+	var charges atomic.Int64
+	h := newTestHandler(NewReplayer(NewMemoryStore()), &charges, nil)
+
+	t.Run("should replay the first response", func(t *testing.T) {
+		first := post(h, "k1", `{"amount": 100}`)
+		require.Equal(t, http.StatusOK, first.Code)
+
+		second := post(h, "k1", `{ "amount": 100 }`)
+		require.Equal(t, http.StatusOK, second.Code)
+		require.Equal(t, first.Body.String(), second.Body.String())
+		require.Equal(t, "true", second.Header().Get(ReplayedHeader))
+		require.Equal(t, first.Header().Get("Content-Type"), second.Header().Get("Content-Type"))
+		require.Equal(t, "attempt=2", second.Header().Get("Set-Cookie"), "only representation headers should be replayed")
+		require.Equal(t, int64(1), charges.Load())
+	})
+
+	t.Run("should reject a key reused for another request", func(t *testing.T) {
+		w := post(h, "k1", `{"amount": 200}`)
+		require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		require.Equal(t, int64(1), charges.Load())
+	})
+
+	t.Run("should reject a key reused with other params", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, postURL(h, "/charges?currency=usd", "k3", `{"amount": 100}`).Code)
+		require.Equal(t, http.StatusUnprocessableEntity, postURL(h, "/charges?currency=eur", "k3", `{"amount": 100}`).Code)
+		require.Equal(t, int64(2), charges.Load())
+	})
+
+	t.Run("should serve requests without a key", func(t *testing.T) {
+		post(h, "", `{"amount": 100}`)
+		post(h, "", `{"amount": 100}`)
+		require.Equal(t, int64(4), charges.Load())
+	})
+
+	t.Run("should release the key of failed requests", func(t *testing.T) {
+		require.Equal(t, http.StatusInternalServerError, post(h, "k2", `{"amount": -1}`).Code)
+		require.Equal(t, http.StatusInternalServerError, post(h, "k2", `{"amount": -1}`).Code)
+		require.Empty(t, post(h, "k2", `{"amount": -1}`).Header().Get(ReplayedHeader))
+	})
+
+	t.Run("should reject keys that are too long", func(t *testing.T) {
+		w := post(h, strings.Repeat("k", MaxKeyLength+1), `{"amount": 100}`)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestReplayer_InFlight(t *testing.T) {
+	var charges atomic.Int64
+	wait := make(chan struct{})
+	h := newTestHandler(NewReplayer(NewMemoryStore()), &charges, wait)
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- post(h, "k1", `{"amount": 100}`)
+	}()
+
+	// the first request holds the claim until wait is closed
+	var duplicate *httptest.ResponseRecorder
+	require.Eventually(t, func() bool {
+		duplicate = post(h, "k1", `{"amount": 100}`)
+		return duplicate.Code == http.StatusConflict
+	}, time.Second, 10*time.Millisecond)
+	require.JSONEq(t, `{"message":"conflict: a request with this idempotency key is in progress","status":409}`, duplicate.Body.String())
+
+	close(wait)
+	require.Equal(t, http.StatusOK, (<-done).Code)
+	require.Equal(t, int64(1), charges.Load())
+}
+
+func TestConfig(t *testing.T) {
+	replayer, err := Config{}.Replayer(NewMemoryStore())
+	require.NoError(t, err)
+	require.Equal(t, DefaultHeader, replayer.Header)
+	require.Equal(t, 24*time.Hour, replayer.TTL)
+	require.Equal(t, 5*time.Minute, replayer.LockTimeout)
+
+	replayer, err = Config{Header: "X-Request-Key", TTL: "1h", LockTimeout: "30s"}.Replayer(NewMemoryStore())
+	require.NoError(t, err)
+	require.Equal(t, "X-Request-Key", replayer.Header)
+	require.Equal(t, time.Hour, replayer.TTL)
+	require.Equal(t, 30*time.Second, replayer.LockTimeout)
+
+	_, err = Config{TTL: "a day"}.Replayer(NewMemoryStore())
+	require.Error(t, err)
+}
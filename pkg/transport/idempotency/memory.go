@@ -0,0 +1,85 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var _ Store = (*MemoryStore)(nil)
+
+// MemoryStore keeps records in the memory of a single instance
+// services running more than one instance need a shared Store, or retries landing on another instance run again
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]memoryRecord
+	sweptAt time.Time
+}
+
+type memoryRecord struct {
+	record    Record
+	expiresAt time.Time
+}
+
+// NewMemoryStore returns an empty store, expired records are forgotten at most once a minute
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		records: map[string]memoryRecord{},
+		sweptAt: time.Now(),
+	}
+}
+
+// Claim implements Store
+func (s *MemoryStore) Claim(ctx context.Context, key string, fingerprint string, ttl time.Duration) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	if existing, ok := s.records[key]; ok && now.Before(existing.expiresAt) {
+		record := existing.record
+		return &record, nil
+	}
+
+	s.records[key] = memoryRecord{
+		record:    Record{Fingerprint: fingerprint},
+		expiresAt: now.Add(ttl),
+	}
+	return nil, nil
+}
+
+// Complete implements Store
+func (s *MemoryStore) Complete(ctx context.Context, key string, record Record, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[key] = memoryRecord{
+		record:    record,
+		expiresAt: time.Now().Add(ttl),
+	}
+	return nil
+}
+
+// Release implements Store
+func (s *MemoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
+
+// sweep forgets expired records, the caller must hold the lock
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.sweptAt) < time.Minute {
+		return
+	}
+
+	s.sweptAt = now
+	for key, entry := range s.records {
+		if now.After(entry.expiresAt) {
+			delete(s.records, key)
+		}
+	}
+}
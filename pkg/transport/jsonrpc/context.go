@@ -0,0 +1,130 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/kibu-sh/kibu/pkg/transport/httpx"
+	"github.com/kibu-sh/kibu/pkg/transport/idempotency"
+	"io"
+	"net/http"
+)
+
+var _ transport.Context = (*Context)(nil)
+
+// Context is the transport.Context of a single call
+// its request inherits the path and headers of the HTTP request that carried the call and reads the params as its body
+// the query and Idempotency-Key of the carrier describe the whole request, not its calls, so they are dropped:
+// every call of a batch would otherwise bind the same query, and be replayed as the first call carrying the key
+type Context struct {
+	req   *httpx.Request
+	res   *callResponse
+	codec transport.Codec
+}
+
+func newContext(codec transport.Codec, carrier *http.Request, params json.RawMessage) *Context {
+	r := carrier.Clone(carrier.Context())
+	// params are always JSON, so they are bound like the body of a POST
+	r.Method = http.MethodPost
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Accept", "application/json")
+	r.Body = io.NopCloser(bytes.NewReader(params))
+	r.ContentLength = int64(len(params))
+	r.URL.RawQuery = ""
+	r.Header.Del(idempotency.DefaultHeader)
+
+	req := httpx.NewRequest(r)
+	tctx := &Context{
+		req:   req,
+		res:   newCallResponse(),
+		codec: codec,
+	}
+
+	// encoders reach the transport context through the request context
+	req.WithContext(transport.ContextStore.Save(r.Context(), tctx))
+	return tctx
+}
+
+func (c *Context) Codec() transport.Codec {
+	return c.codec
+}
+
+func (c *Context) Request() transport.Request {
+	return c.req
+}
+
+func (c *Context) Response() transport.Response {
+	return c.res
+}
+
+var _ transport.Response = (*callResponse)(nil)
+
+// callResponse buffers what an operation writes so it can be returned as the result of a call
+// cookies and redirects can't be expressed by a result, so they are ignored
+type callResponse struct {
+	headers http.Header
+	status  int
+	body    *bytes.Buffer
+}
+
+func newCallResponse() *callResponse {
+	return &callResponse{
+		headers: http.Header{},
+		body:    new(bytes.Buffer),
+	}
+}
+
+func (r *callResponse) Write(b []byte) (int, error) {
+	// if the status code has not been set, default to 200
+	// this is implied on the first write of the response
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(b)
+}
+
+func (r *callResponse) Headers() http.Header {
+	return r.headers
+}
+
+func (r *callResponse) SetStatusCode(code int) {
+	r.status = code
+}
+
+// GetStatusCode returns 204 for operations that neither set a status nor wrote a body
+func (r *callResponse) GetStatusCode() int {
+	if r.status == 0 {
+		return http.StatusNoContent
+	}
+	return r.status
+}
+
+func (r *callResponse) BytesWritten() int64 {
+	return int64(r.body.Len())
+}
+
+func (r *callResponse) DelCookie(cookie http.Cookie) transport.Response {
+	return r
+}
+
+func (r *callResponse) DelCookieByName(name string) transport.Response {
+	return r
+}
+
+func (r *callResponse) SetCookie(cookie http.Cookie) transport.Response {
+	return r
+}
+
+func (r *callResponse) Redirect(req transport.Request, url string, code int) {
+	r.headers.Set("Location", url)
+	r.SetStatusCode(code)
+}
+
+func (r *callResponse) BodyBuffer() *bytes.Buffer {
+	return r.body
+}
+
+// Underlying returns nil, the result is only sent once the operation returns
+func (r *callResponse) Underlying() any {
+	return nil
+}
@@ -0,0 +1,122 @@
+// Package jsonrpc serves every operation of a transport.Registry from a single JSON-RPC 2.0 endpoint
+// methods are named after the operation IDs generated by kibugenv2 (i.e. billingv1.Service.GetAccount)
+// and calls are answered over HTTP or, once upgraded, over a WebSocket
+package jsonrpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/kibu-sh/kibu/pkg/transport/httpx"
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+// Version is the only protocol version accepted by the Server
+const Version = "2.0"
+
+// Error codes defined by the JSON-RPC 2.0 specification
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+
+	// CodeServerError is reported for endpoint errors that aren't caused by the params of a call
+	CodeServerError = -32000
+)
+
+// Request is a single call or notification
+// a request without an ID is a notification and is never answered
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+}
+
+// IsNotification reports whether the client expects no response
+// an explicit null ID is still a call
+func (r Request) IsNotification() bool {
+	return r.ID == nil
+}
+
+// Response answers a single Request with either a Result or an Error
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// Error is the error object of a Response
+// endpoint errors carry the body of the transport.ErrorResponse they were mapped from as Data
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("jsonrpc error %d: %s", e.Code, e.Message)
+}
+
+func newError(code int, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// NewError maps an error returned by an endpoint to an error object
+// transport.ErrorResponse is honoured the same way httpx encodes it, and its status decides the code
+//
+//	400, 422 → -32602 invalid params
+//	5xx      → -32603 internal error
+//	other    → -32000 server error
+func NewError(err error) *Error {
+	var rpcErr *Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr
+	}
+
+	errRes := httpx.NewDefaultJSONError(err)
+	message := http.StatusText(errRes.GetStatusCode())
+	if jsonErr, ok := errRes.(httpx.DefaultJSONError); ok && jsonErr.Message != "" {
+		message = jsonErr.Message
+	}
+
+	return &Error{
+		Code:    codeFromStatus(errRes.GetStatusCode()),
+		Message: message,
+		Data:    errRes.PrepareResponse(),
+	}
+}
+
+func codeFromStatus(status int) int {
+	switch {
+	case status == http.StatusBadRequest, status == http.StatusUnprocessableEntity:
+		return CodeInvalidParams
+	case status >= http.StatusInternalServerError:
+		return CodeInternalError
+	}
+	return CodeServerError
+}
+
+// errorFromBody maps an error already encoded by a transport.Codec to an error object
+// the encoded body is kept as Data and its message is reused when it has one
+func errorFromBody(status int, body []byte) *Error {
+	rpcErr := &Error{
+		Code:    codeFromStatus(status),
+		Message: http.StatusText(status),
+		Data:    string(body),
+	}
+
+	if !json.Valid(body) {
+		return rpcErr
+	}
+
+	rpcErr.Data = json.RawMessage(body)
+	var errRes httpx.DefaultJSONError
+	if err := json.Unmarshal(body, &errRes); err == nil && errRes.Message != "" {
+		rpcErr.Message = errRes.Message
+	}
+	return rpcErr
+}
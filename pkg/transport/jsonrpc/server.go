@@ -0,0 +1,246 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+var _ transport.Handler = (*Server)(nil)
+
+// Server dispatches JSON-RPC calls to the operations of a transport.Registry
+// it is served like any other endpoint, so it can be mounted on any httpx.ServeMux
+//
+//	mux.Handle(httpx.NewHandler("/rpc", jsonrpc.NewServer(reg)).WithMethods("GET", "POST"))
+//
+// POST requests carry a single call or a batch, GET requests are upgraded to a WebSocket
+// that answers every message it receives the same way
+type Server struct {
+	Registry   transport.Registry
+	Middleware []transport.Middleware
+}
+
+func NewServer(registry transport.Registry) *Server {
+	return &Server{
+		Registry: registry,
+	}
+}
+
+// WithMiddleware sets middleware that runs once per HTTP request or WebSocket connection
+// middleware of the operations runs once per call
+func (s *Server) WithMiddleware(middleware ...transport.Middleware) *Server {
+	s.Middleware = middleware
+	return s
+}
+
+// Serve implements transport.Handler
+func (s *Server) Serve(tctx transport.Context) error {
+	return transport.ApplyMiddleware(transport.HandlerFunc(s.serve), s.Middleware...).Serve(tctx)
+}
+
+func (s *Server) serve(tctx transport.Context) error {
+	r, ok := tctx.Request().Underlying().(*http.Request)
+	if !ok {
+		return errors.New("jsonrpc: requests must be received over http")
+	}
+
+	if isWebSocketUpgrade(r) {
+		return s.serveConn(tctx, r)
+	}
+
+	body, err := io.ReadAll(tctx.Request().Body())
+	if err != nil {
+		return transport.NewBindingError(err)
+	}
+
+	reply, ok := s.handleMessage(tctx.Codec(), r, body)
+	if !ok {
+		// batches made only of notifications have no response
+		tctx.Response().SetStatusCode(http.StatusNoContent)
+		return nil
+	}
+
+	tctx.Response().Headers().Set("Content-Type", "application/json")
+	tctx.Response().SetStatusCode(http.StatusOK)
+	_, err = tctx.Response().Write(reply)
+	return err
+}
+
+// serveConn answers every message received on a WebSocket until the client closes it
+func (s *Server) serveConn(tctx transport.Context, r *http.Request) error {
+	channeler, ok := tctx.(transport.Channeler)
+	if !ok {
+		return transport.ErrChannelsNotSupported
+	}
+
+	conn, err := channeler.OpenChannel()
+	if errors.Is(err, transport.ErrResponseIntercepted) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	ctx := r.Context()
+	for {
+		var msg json.RawMessage
+		err = conn.Receive(ctx, &msg)
+		if errors.Is(err, io.EOF) {
+			_ = conn.Close(nil)
+			return nil
+		}
+
+		var bindingErr *transport.BindingError
+		switch {
+		case errors.As(err, &bindingErr):
+			msg = nil
+		case err != nil:
+			// once upgraded, errors can only be reported by closing the connection
+			_ = conn.Close(err)
+			return nil
+		}
+
+		reply, ok := s.handleMessage(tctx.Codec(), r, msg)
+		if !ok {
+			continue
+		}
+
+		if err = conn.Send(ctx, reply); err != nil {
+			_ = conn.Close(err)
+			return nil
+		}
+	}
+}
+
+// handleMessage answers a single call or a batch
+// ok is false when nothing should be sent back, i.e. every call was a notification
+func (s *Server) handleMessage(codec transport.Codec, r *http.Request, msg []byte) (reply json.RawMessage, ok bool) {
+	msg = bytes.TrimSpace(msg)
+	if len(msg) == 0 || !json.Valid(msg) {
+		return encode(errorResponse(nil, newError(CodeParseError, "parse error")))
+	}
+
+	if msg[0] != '[' {
+		res := s.handleCall(codec, r, msg)
+		if res == nil {
+			return nil, false
+		}
+		return encode(res)
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(msg, &batch); err != nil || len(batch) == 0 {
+		return encode(errorResponse(nil, newError(CodeInvalidRequest, "invalid request")))
+	}
+
+	var responses []*Response
+	for _, call := range batch {
+		if res := s.handleCall(codec, r, call); res != nil {
+			responses = append(responses, res)
+		}
+	}
+
+	if len(responses) == 0 {
+		return nil, false
+	}
+	return encode(responses)
+}
+
+// handleCall dispatches a single request to its operation
+// notifications are dispatched as well, but their response is discarded
+func (s *Server) handleCall(codec transport.Codec, r *http.Request, msg json.RawMessage) *Response {
+	var req Request
+	if err := json.Unmarshal(msg, &req); err != nil || req.JSONRPC != Version || req.Method == "" {
+		return errorResponse(req.ID, newError(CodeInvalidRequest, "invalid request"))
+	}
+
+	res := s.call(codec, r, req)
+	if req.IsNotification() {
+		return nil
+	}
+	return res
+}
+
+func (s *Server) call(codec transport.Codec, r *http.Request, req Request) *Response {
+	op, ok := transport.LookupOperation(s.Registry, req.Method)
+	if !ok || op.Handler() == nil {
+		return errorResponse(req.ID, newError(CodeMethodNotFound, "method not found"))
+	}
+
+	params, rpcErr := paramsBody(req.Params)
+	if rpcErr != nil {
+		return errorResponse(req.ID, rpcErr)
+	}
+
+	tctx := newContext(codec, r, params)
+	if err := op.Handler().Serve(tctx); err != nil {
+		return errorResponse(req.ID, NewError(err))
+	}
+
+	body := bytes.TrimSpace(tctx.res.BodyBuffer().Bytes())
+	if status := tctx.res.GetStatusCode(); status >= http.StatusBadRequest {
+		// endpoints encode their errors with the codec instead of returning them
+		return errorResponse(req.ID, errorFromBody(status, body))
+	}
+
+	if len(body) == 0 || !json.Valid(body) {
+		body, _ = json.Marshal(nullOrString(body))
+	}
+
+	return &Response{JSONRPC: Version, Result: body, ID: req.ID}
+}
+
+// paramsBody turns the params of a call into the body the operation binds
+// operations accept a single request, so positional params must hold exactly one value
+//
+//	{"id":"123"} → {"id":"123"}
+//	[{"id":"123"}] → {"id":"123"}
+func paramsBody(params json.RawMessage) (json.RawMessage, *Error) {
+	params = bytes.TrimSpace(params)
+	if len(params) == 0 || params[0] != '[' {
+		return params, nil
+	}
+
+	var positional []json.RawMessage
+	if err := json.Unmarshal(params, &positional); err != nil {
+		return nil, newError(CodeInvalidParams, "invalid params")
+	}
+
+	switch len(positional) {
+	case 0:
+		return nil, nil
+	case 1:
+		return positional[0], nil
+	}
+	return nil, newError(CodeInvalidParams, "invalid params: expected a single positional param")
+}
+
+func errorResponse(id json.RawMessage, err *Error) *Response {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &Response{JSONRPC: Version, Error: err, ID: id}
+}
+
+func encode(v any) (json.RawMessage, bool) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		b, _ = json.Marshal(errorResponse(nil, newError(CodeInternalError, err.Error())))
+	}
+	return b, true
+}
+
+func nullOrString(body []byte) any {
+	if len(body) == 0 {
+		return nil
+	}
+	return string(body)
+}
+
+func isWebSocketUpgrade(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
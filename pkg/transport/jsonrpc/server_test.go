@@ -0,0 +1,165 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/kibu-sh/kibu/pkg/request"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/kibu-sh/kibu/pkg/transport/httpx"
+	"github.com/kibu-sh/kibu/pkg/transport/idempotency"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type greetReq struct {
+	Name string `json:"name"`
+}
+
+func (r greetReq) Validate() error {
+	if r.Name == "" {
+		return transport.FieldErrors{{Field: "name", Location: transport.LocationBody, Reason: "required"}}
+	}
+	return nil
+}
+
+type greetRes struct {
+	Greeting string `json:"greeting"`
+}
+
+func newTestServer(t *testing.T) (*httptest.Server, *[]string) {
+	var notified []string
+	greet := transport.NewEndpoint(func(ctx context.Context, req greetReq) (res greetRes, err error) {
+		return greetRes{Greeting: "hello " + req.Name}, nil
+	})
+	notify := transport.NewEndpoint(func(ctx context.Context, req greetReq) (res greetRes, err error) {
+		notified = append(notified, req.Name)
+		return
+	})
+
+	reg := transport.NewRegistry()
+	svc := transport.NewService("greeterv1.Service")
+	svc.WithOperations(
+		transport.NewOperation("greeterv1.Service.Greet", greet),
+		transport.NewOperation("greeterv1.Service.Notify", notify),
+	)
+	svc.Register(reg)
+
+	server := httptest.NewServer(httpx.NewHandler("/rpc", NewServer(reg)).WithMethods(http.MethodGet, http.MethodPost))
+	t.Cleanup(server.Close)
+	return server, &notified
+}
+
+func post(t *testing.T, server *httptest.Server, body string) (int, string) {
+	res, err := http.Post(server.URL+"/rpc", "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	return res.StatusCode, string(b)
+}
+
+func TestServer(t *testing.T) {
+	server, notified := newTestServer(t)
+
+	t.Run("should call operations by their ID", func(t *testing.T) {
+		status, body := post(t, server, `{"jsonrpc":"2.0","method":"greeterv1.Service.Greet","params":{"name":"kibu"},"id":1}`)
+		require.Equal(t, http.StatusOK, status)
+		require.JSONEq(t, `{"jsonrpc":"2.0","result":{"greeting":"hello kibu"},"id":1}`, body)
+	})
+
+	t.Run("should accept a single positional param", func(t *testing.T) {
+		_, body := post(t, server, `{"jsonrpc":"2.0","method":"greeterv1.Service.Greet","params":[{"name":"kibu"}],"id":"a"}`)
+		require.JSONEq(t, `{"jsonrpc":"2.0","result":{"greeting":"hello kibu"},"id":"a"}`, body)
+	})
+
+	t.Run("should answer batches and skip notifications", func(t *testing.T) {
+		_, body := post(t, server, `[
+			{"jsonrpc":"2.0","method":"greeterv1.Service.Greet","params":{"name":"a"},"id":1},
+			{"jsonrpc":"2.0","method":"greeterv1.Service.Notify","params":{"name":"b"}},
+			{"jsonrpc":"2.0","method":"greeterv1.Service.Missing","id":2},
+			{"foo":"bar"}
+		]`)
+		require.JSONEq(t, `[
+			{"jsonrpc":"2.0","result":{"greeting":"hello a"},"id":1},
+			{"jsonrpc":"2.0","error":{"code":-32601,"message":"method not found"},"id":2},
+			{"jsonrpc":"2.0","error":{"code":-32600,"message":"invalid request"},"id":null}
+		]`, body)
+		require.Equal(t, []string{"b"}, *notified)
+	})
+
+	t.Run("should not respond to batches of notifications", func(t *testing.T) {
+		status, body := post(t, server, `[{"jsonrpc":"2.0","method":"greeterv1.Service.Notify","params":{"name":"c"}}]`)
+		require.Equal(t, http.StatusNoContent, status)
+		require.Empty(t, body)
+	})
+
+	t.Run("should map validation errors to invalid params", func(t *testing.T) {
+		_, body := post(t, server, `{"jsonrpc":"2.0","method":"greeterv1.Service.Greet","params":{},"id":1}`)
+
+		var res Response
+		require.NoError(t, json.Unmarshal([]byte(body), &res))
+		require.Equal(t, CodeInvalidParams, res.Error.Code)
+		require.Contains(t, res.Error.Message, "name: required")
+
+		data := res.Error.Data.(map[string]any)
+		require.EqualValues(t, http.StatusUnprocessableEntity, data["status"])
+	})
+
+	t.Run("should report parse errors", func(t *testing.T) {
+		_, body := post(t, server, `{"jsonrpc":`)
+		require.JSONEq(t, `{"jsonrpc":"2.0","error":{"code":-32700,"message":"parse error"},"id":null}`, body)
+	})
+
+	t.Run("should reject empty batches", func(t *testing.T) {
+		_, body := post(t, server, `[]`)
+		require.JSONEq(t, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"invalid request"},"id":null}`, body)
+	})
+}
+
+func TestNewContext(t *testing.T) {
+	carrier := httptest.NewRequest(http.MethodPost, "/rpc?name=query", strings.NewReader(`[]`))
+	carrier.Header.Set(idempotency.DefaultHeader, "key-1")
+	carrier.Header.Set("Authorization", "Bearer token")
+
+	tctx := newContext(httpx.DefaultCodec, carrier, json.RawMessage(`{"name":"kibu"}`))
+	req := tctx.Request()
+	require.Equal(t, "/rpc", req.Path())
+	require.Empty(t, req.QueryParams(), "the query of the carrier must not be bound by every call")
+	require.Empty(t, req.Headers().Get(idempotency.DefaultHeader), "calls of a batch must not share an idempotency key")
+	require.Equal(t, "Bearer token", req.Headers().Get("Authorization"))
+	require.Equal(t, "name=query", carrier.URL.RawQuery, "the carrier must not be modified")
+}
+
+func TestServer_WebSocket(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+
+	client, err := request.ParseURL(server.URL)
+	require.NoError(t, err)
+
+	conn, err := httpx.DialWebSocket(ctx, client.WithJoinedURLPath("/rpc"), httpx.DefaultWebSocketOptions)
+	require.NoError(t, err)
+	defer conn.Close(nil)
+
+	for _, name := range []string{"a", "b"} {
+		require.NoError(t, conn.Send(ctx, Request{
+			JSONRPC: Version,
+			Method:  "greeterv1.Service.Greet",
+			Params:  json.RawMessage(`{"name":"` + name + `"}`),
+			ID:      json.RawMessage(`"` + name + `"`),
+		}))
+
+		var res Response
+		require.NoError(t, conn.Receive(ctx, &res))
+		require.JSONEq(t, `{"greeting":"hello `+name+`"}`, string(res.Result))
+		require.JSONEq(t, `"`+name+`"`, string(res.ID))
+	}
+}
@@ -34,6 +34,11 @@ type GetParams struct {
 // Get returns a list of Middleware for the given tags
 // "global" middleware are always returned as a part of the list
 // "auth" middleware are always returned if a tag of "public" is not specified
+// "after_auth" middleware are returned with "global", and run once the request is authenticated (i.e. rate limits keyed by principal)
+//
+// the last middleware of the list is the outermost, so the list reads from the endpoint outwards
+//
+//	[after_auth..., auth..., global..., tags...]
 func (r *Registry) Get(params GetParams) (result []transport.Middleware) {
 	var tags = params.Tags
 
@@ -45,6 +50,10 @@ func (r *Registry) Get(params GetParams) (result []transport.Middleware) {
 		tags = append([]string{"auth"}, tags...)
 	}
 
+	if !params.ExcludeGlobal {
+		tags = append([]string{"after_auth"}, tags...)
+	}
+
 	seen := make(map[*RegistryItem]bool)
 	for _, tag := range tags {
 		if items, ok := r.cache[tag]; ok {
@@ -64,8 +73,9 @@ func (r *Registry) Get(params GetParams) (result []transport.Middleware) {
 func NewRegistry() *Registry {
 	return &Registry{
 		cache: map[string][]*RegistryItem{
-			"global": {},
-			"auth":   {},
+			"global":     {},
+			"auth":       {},
+			"after_auth": {},
 		},
 	}
 }
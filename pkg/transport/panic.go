@@ -0,0 +1,67 @@
+package transport
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+)
+
+// PanicError is returned in place of a panic recovered while serving a request
+// it carries the stack of the panic, so slogx.WithErrorInfo logs where it happened
+type PanicError struct {
+	// Value is the value passed to panic
+	Value any
+	stack errors.StackTrace
+}
+
+// NewPanicError captures the stack of the goroutine that recovered v
+// it must be called from the deferred function that called recover
+func NewPanicError(v any) *PanicError {
+	type stackTracer interface {
+		StackTrace() errors.StackTrace
+	}
+
+	// skip NewPanicError and the deferred function that recovered the panic
+	stack := errors.New("").(stackTracer).StackTrace()
+	if len(stack) > 2 {
+		stack = stack[2:]
+	}
+
+	return &PanicError{
+		Value: v,
+		stack: stack,
+	}
+}
+
+// RecoverPanic converts a panic of the calling function into a PanicError assigned to err
+//
+//	func (h Handler) Serve(tctx Context) (err error) {
+//		defer transport.RecoverPanic(&err)
+//		...
+//	}
+func RecoverPanic(err *error) {
+	if v := recover(); v != nil {
+		*err = NewPanicError(v)
+	}
+}
+
+// AsPanicError finds the first PanicError in the chain of err
+func AsPanicError(err error) (*PanicError, bool) {
+	var panicErr *PanicError
+	ok := errors.As(err, &panicErr)
+	return panicErr, ok
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the value passed to panic when it is an error
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// StackTrace implements the stackTracer interface of github.com/pkg/errors
+func (e *PanicError) StackTrace() errors.StackTrace {
+	return e.stack
+}
@@ -0,0 +1,65 @@
+// Package pathparam parses the parameters of routes in both gin (:id, *path) and stdlib ({id}, {path...}) style
+// it has no dependencies, so the toolchain shares it with httpx without importing the runtime
+package pathparam
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Pattern matches the parameters of a route and the stdlib end of path anchor {$}
+// gin parameters only start a segment, so colons inside a segment (i.e. /items:batchGet) are literals,
+// their match includes the "/" before them, which Replace keeps
+var Pattern = regexp.MustCompile(`\{([^}.]+)(?:\.\.\.)?}|(?:^|/)[:*]([^/]+)`)
+
+// Param is a parameter matched by Pattern
+type Param struct {
+	Name string
+
+	// Wildcard is set for parameters matching the rest of a path ({path...}, *path)
+	Wildcard bool
+
+	// Anchor is set for the end of path anchor {$}, which isn't a parameter
+	Anchor bool
+}
+
+// Parse describes a match of Pattern, the "/" before a gin parameter is ignored
+//
+//	{id} → id, {path...} → path wildcard, :id → id, *path → path wildcard, {$} → anchor
+func Parse(match string) (param Param) {
+	match = strings.TrimPrefix(match, "/")
+	groups := Pattern.FindStringSubmatch(match)
+	if groups == nil {
+		return
+	}
+
+	param.Name = groups[1] + groups[2]
+	param.Anchor = param.Name == "$"
+	param.Wildcard = strings.HasPrefix(match, "*") || strings.HasSuffix(match, "...}")
+	return
+}
+
+// Replace replaces every parameter of path with the result of fn, which receives the parameter as written in path
+//
+//	Replace("/accounts/:id", func(match string, param Param) string { return "{" + param.Name + "}" }) → /accounts/{id}
+func Replace(path string, fn func(match string, param Param) string) string {
+	return Pattern.ReplaceAllStringFunc(path, func(match string) string {
+		var prefix string
+		if strings.HasPrefix(match, "/") {
+			prefix, match = "/", match[1:]
+		}
+		return prefix + fn(match, Parse(match))
+	})
+}
+
+// Names returns the names of the parameters of a route in order of appearance
+//
+//	/accounts/{id}/files/*path → [id path]
+func Names(path string) (names []string) {
+	for _, match := range Pattern.FindAllString(path, -1) {
+		if param := Parse(match); !param.Anchor {
+			names = append(names, param.Name)
+		}
+	}
+	return
+}
@@ -0,0 +1,43 @@
+package pathparam
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestNames(t *testing.T) {
+	tests := []struct {
+		path   string
+		params []string
+	}{
+		{"/accounts", nil},
+		{"/accounts/{id}", []string{"id"}},
+		{"/accounts/:id", []string{"id"}},
+		{"/files/{path...}", []string{"path"}},
+		{"/files/*path", []string{"path"}},
+		{"/accounts/{$}", nil},
+		{"/accounts/:id/files/{path...}", []string{"id", "path"}},
+		{"/v1/items:batchGet", nil},
+		{"/v1/items/{id}:cancel", []string{"id"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			require.Equal(t, test.params, Names(test.path))
+		})
+	}
+}
+
+func TestReplace(t *testing.T) {
+	braces := func(match string, param Param) string {
+		if param.Wildcard {
+			return "{" + param.Name + "...}"
+		}
+		return "{" + param.Name + "}"
+	}
+
+	require.Equal(t, "/accounts/{id}/files/{path...}", Replace("/accounts/:id/files/*path", braces))
+	require.Equal(t, "/v1/items:batchGet/{id}", Replace("/v1/items:batchGet/:id", braces),
+		"colons inside a segment should be kept")
+	require.Equal(t, Param{Name: "$", Anchor: true}, Parse("{$}"))
+}
@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+var _ Algorithm = TokenBucket{}
+
+// TokenBucket refills Requests tokens per Period into a bucket holding Burst tokens, every request takes a token
+// it allows short bursts while keeping the average rate of the limit
+type TokenBucket struct{}
+
+// Allow implements Algorithm
+func (TokenBucket) Allow(state State, limit Limit, now time.Time) (State, Result) {
+	burst := float64(limit.burst())
+	rate := float64(limit.Requests) / limit.Period.Seconds()
+
+	tokens := burst
+	if !state.At.IsZero() {
+		elapsed := max(now.Sub(state.At).Seconds(), 0)
+		tokens = math.Min(burst, state.Value+elapsed*rate)
+	}
+
+	result := Result{Limit: limit.burst()}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsToDuration((1 - tokens) / rate)
+	}
+
+	result.Remaining = int(tokens)
+	result.Reset = secondsToDuration((burst - tokens) / rate)
+	return State{Value: tokens, At: now}, result
+}
+
+var _ Algorithm = SlidingWindow{}
+
+// SlidingWindow allows Requests in any window of Period
+// the requests of the previous window are weighted by how much it overlaps the sliding window,
+// so only two counters are kept per key
+type SlidingWindow struct{}
+
+// Allow implements Algorithm
+func (SlidingWindow) Allow(state State, limit Limit, now time.Time) (State, Result) {
+	window := now.Truncate(limit.Period)
+	switch {
+	case !state.At.Before(window):
+		// the current window, or a window started by an instance whose clock is ahead
+	case state.At.Add(limit.Period).Equal(window):
+		state = State{Previous: state.Value, At: window}
+	default:
+		state = State{At: window}
+	}
+
+	elapsed := max(now.Sub(window), 0)
+	weight := 1 - float64(elapsed)/float64(limit.Period)
+	requests := float64(limit.Requests)
+	estimated := state.Previous*weight + state.Value
+
+	result := Result{
+		Limit: limit.Requests,
+		Reset: limit.Period - elapsed,
+	}
+
+	switch {
+	case estimated+1 <= requests:
+		state.Value++
+		estimated++
+		result.Allowed = true
+	case state.Value+1 > requests:
+		// the current window is full, wait for enough of it to slide out of the next window
+		result.RetryAfter = limit.Period - elapsed + scale(limit.Period, 1-(requests-1)/state.Value)
+	default:
+		// wait for enough of the previous window to slide out
+		result.RetryAfter = scale(limit.Period, 1-(requests-1-state.Value)/state.Previous) - elapsed
+	}
+
+	result.Remaining = max(limit.Requests-int(math.Ceil(estimated)), 0)
+	return state, result
+}
+
+func scale(d time.Duration, f float64) time.Duration {
+	return time.Duration(float64(d) * f)
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"github.com/kibu-sh/kibu/pkg/auth"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// Algorithms and keys of a Config
+const (
+	AlgorithmTokenBucket   = "token_bucket"
+	AlgorithmSlidingWindow = "sliding_window"
+
+	KeyPrincipal = "principal"
+	KeyAPIKey    = "api_key"
+	KeyClientIP  = "client_ip"
+)
+
+var (
+	// ErrUnknownAlgorithm is returned by Config.Limiter for an algorithm other than token_bucket or sliding_window
+	ErrUnknownAlgorithm = errors.New("unknown rate limit algorithm")
+
+	// ErrUnknownKey is returned by Config.Limiter for a key other than principal, api_key or client_ip
+	ErrUnknownKey = errors.New("unknown rate limit key")
+)
+
+// Config configures a Limiter, wireset reads it from the "rate_limit" key of the config store
+//
+//	{"requests": 100, "period": "1m", "algorithm": "sliding_window", "key": "client_ip", "trusted_proxies": ["10.0.0.0/8"]}
+type Config struct {
+	Requests int `json:"requests"`
+
+	// Period is parsed with time.ParseDuration
+	Period string `json:"period"`
+
+	// Burst is only used by the token bucket
+	Burst int `json:"burst"`
+
+	// Algorithm defaults to AlgorithmTokenBucket
+	Algorithm string `json:"algorithm"`
+
+	// Key defaults to the principal of authenticated requests, and the client IP of others
+	Key string `json:"key"`
+
+	// TrustedProxies are the addresses or CIDR ranges of the proxies in front of the service (i.e. a load balancer)
+	// the client IP is read from CF-Connecting-IP, True-Client-IP or X-Forwarded-For on their connections, and is the remote address otherwise
+	TrustedProxies []string `json:"trusted_proxies"`
+}
+
+// Limiter builds the Limiter of cfg on store
+func (cfg Config) Limiter(store Store) (*Limiter, error) {
+	period, err := time.ParseDuration(cfg.Period)
+	if err != nil || period <= 0 || cfg.Requests <= 0 {
+		return nil, errors.Errorf("invalid rate limit of %d requests per %q", cfg.Requests, cfg.Period)
+	}
+
+	var algorithm Algorithm
+	switch cfg.Algorithm {
+	case "", AlgorithmTokenBucket:
+		algorithm = TokenBucket{}
+	case AlgorithmSlidingWindow:
+		algorithm = SlidingWindow{}
+	default:
+		return nil, errors.Wrapf(ErrUnknownAlgorithm, "%q", cfg.Algorithm)
+	}
+
+	clientIP := ByClientIP
+	if len(cfg.TrustedProxies) > 0 {
+		trusted, err := ParseTrustedProxies(cfg.TrustedProxies)
+		if err != nil {
+			return nil, err
+		}
+		clientIP = ByProxiedClientIP(trusted...)
+	}
+
+	limiter := NewLimiter(store, algorithm, Limit{Requests: cfg.Requests, Period: period, Burst: cfg.Burst})
+	switch cfg.Key {
+	case "":
+		limiter.WithKey(FirstKey(ByPrincipal, clientIP))
+	case KeyPrincipal:
+		limiter.WithKey(ByPrincipal)
+	case KeyAPIKey:
+		limiter.WithKey(ByAPIKey(auth.DefaultAPIKeyHeader))
+	case KeyClientIP:
+		limiter.WithKey(clientIP)
+	default:
+		return nil, errors.Wrapf(ErrUnknownKey, "%q", cfg.Key)
+	}
+	return limiter, nil
+}
@@ -0,0 +1,146 @@
+package ratelimit
+
+import (
+	"github.com/kibu-sh/kibu/pkg/auth"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/kibu-sh/kibu/pkg/transport/httpx"
+	"github.com/pkg/errors"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// KeyFunc identifies the caller of a request
+// requests it returns false for aren't limited
+type KeyFunc func(req transport.Request) (key string, ok bool)
+
+// ByPrincipal limits every authenticated principal separately
+// it must run after the "auth" middleware (i.e. registered for the "after_auth" tag), unauthenticated requests aren't limited
+func ByPrincipal(req transport.Request) (string, bool) {
+	principal, err := auth.FromContext(req.Context())
+	if err != nil {
+		return "", false
+	}
+	return "principal:" + principal.Method + ":" + principal.Subject, true
+}
+
+// ByAPIKey limits every key sent in header (i.e. auth.DefaultAPIKeyHeader) separately
+// keys are hashed, so they are never written to the store
+func ByAPIKey(header string) KeyFunc {
+	return func(req transport.Request) (string, bool) {
+		key := req.Headers().Get(header)
+		if key == "" {
+			return "", false
+		}
+		return "api_key:" + auth.HashAPIKey(key), true
+	}
+}
+
+// ByClientIP limits every client address separately
+// the address is the remote address of the connection, proxy headers are ignored as any client can send them
+// services behind a load balancer use ByProxiedClientIP instead
+func ByClientIP(req transport.Request) (string, bool) {
+	ip, ok := remoteIP(req)
+	if !ok {
+		return "", false
+	}
+	return "ip:" + ip.String(), true
+}
+
+// ByProxiedClientIP limits every client address separately, for services behind the proxies of trusted
+// proxy headers are only honored on connections from a trusted proxy, which must set or strip them:
+// the CF-Connecting-IP and True-Client-IP headers of Cloudflare (see httpx.CloudflareMetaFromHeaders) come first,
+// then X-Forwarded-For is read from the right:
+// the client is the last address that isn't a trusted proxy, as the addresses before it are sent by the client
+func ByProxiedClientIP(trusted ...netip.Prefix) KeyFunc {
+	isTrusted := func(ip netip.Addr) bool {
+		for _, prefix := range trusted {
+			if prefix.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(req transport.Request) (string, bool) {
+		ip, ok := remoteIP(req)
+		if !ok {
+			return "", false
+		}
+
+		if !isTrusted(ip) {
+			return "ip:" + ip.String(), true
+		}
+
+		if cf, ok := netip.AddrFromSlice(httpx.CloudflareMetaFromHeaders(req.Headers()).ClientIP()); ok {
+			return "ip:" + cf.Unmap().String(), true
+		}
+
+		forwarded := strings.Split(strings.Join(req.Headers().Values("X-Forwarded-For"), ","), ",")
+		for i := len(forwarded) - 1; i >= 0; i-- {
+			hop, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+			if err != nil {
+				break
+			}
+
+			ip = hop.Unmap()
+			if !isTrusted(ip) {
+				break
+			}
+		}
+		return "ip:" + ip.String(), true
+	}
+}
+
+// ParseTrustedProxies parses the addresses and CIDR ranges of trusted proxies (i.e. "10.0.0.0/8")
+func ParseTrustedProxies(proxies []string) (trusted []netip.Prefix, err error) {
+	for _, proxy := range proxies {
+		var prefix netip.Prefix
+		if strings.Contains(proxy, "/") {
+			prefix, err = netip.ParsePrefix(proxy)
+		} else {
+			var addr netip.Addr
+			addr, err = netip.ParseAddr(proxy)
+			prefix = netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen())
+		}
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid trusted proxy %q", proxy)
+		}
+		trusted = append(trusted, prefix.Masked())
+	}
+	return
+}
+
+// remoteIP is the address of the connection of req
+func remoteIP(req transport.Request) (netip.Addr, bool) {
+	underlying, ok := req.Underlying().(*http.Request)
+	if !ok || underlying.RemoteAddr == "" {
+		return netip.Addr{}, false
+	}
+
+	if addrPort, err := netip.ParseAddrPort(underlying.RemoteAddr); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+
+	addr, err := netip.ParseAddr(underlying.RemoteAddr)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// FirstKey identifies callers with the first of keys that returns true
+func FirstKey(keys ...KeyFunc) KeyFunc {
+	return func(req transport.Request) (string, bool) {
+		for _, key := range keys {
+			if k, ok := key(req); ok {
+				return k, true
+			}
+		}
+		return "", false
+	}
+}
+
+// ByPrincipalOrClientIP limits authenticated principals separately, and other requests by client IP
+var ByPrincipalOrClientIP = FirstKey(ByPrincipal, ByClientIP)
@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var _ Store = (*MemoryStore)(nil)
+
+// MemoryStore keeps the state of every key in the memory of a single instance
+// services running more than one instance should share a PostgresStore instead
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]memoryEntry
+	sweptAt   time.Time
+	sweepEach time.Duration
+}
+
+type memoryEntry struct {
+	state     State
+	expiresAt time.Time
+}
+
+// NewMemoryStore returns an empty store, expired keys are forgotten at most once a minute
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries:   map[string]memoryEntry{},
+		sweptAt:   time.Now(),
+		sweepEach: time.Minute,
+	}
+}
+
+// Update implements Store
+func (s *MemoryStore) Update(ctx context.Context, key string, ttl time.Duration, update func(state State) State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	entry, ok := s.entries[key]
+	if !ok || now.After(entry.expiresAt) {
+		entry = memoryEntry{}
+	}
+
+	s.entries[key] = memoryEntry{
+		state:     update(entry.state),
+		expiresAt: now.Add(ttl),
+	}
+	return nil
+}
+
+// sweep forgets expired keys, the caller must hold the lock
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.sweptAt) < s.sweepEach {
+		return
+	}
+
+	s.sweptAt = now
+	for key, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
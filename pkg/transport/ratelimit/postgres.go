@@ -0,0 +1,100 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// DefaultPostgresTable is the table of a PostgresStore
+const DefaultPostgresTable = "kibu_rate_limits"
+
+var _ Store = (*PostgresStore)(nil)
+
+// PostgresStore shares the state of every key between the instances of a service
+// every update locks the row of its key, so concurrent requests are counted once each
+//
+//	store := ratelimit.NewPostgresStore(db, "")
+//	err := store.CreateTable(ctx)
+type PostgresStore struct {
+	db    *sql.DB
+	table string
+}
+
+// NewPostgresStore keeps the state of keys in table, which defaults to DefaultPostgresTable
+func NewPostgresStore(db *sql.DB, table string) *PostgresStore {
+	if table == "" {
+		table = DefaultPostgresTable
+	}
+	return &PostgresStore{db: db, table: pq.QuoteIdentifier(table)}
+}
+
+// CreateTable creates the table of the store if it doesn't exist
+func (s *PostgresStore) CreateTable(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+		create table if not exists %s (
+			key        text primary key,
+			value      double precision not null default 0,
+			previous   double precision not null default 0,
+			at         timestamptz,
+			expires_at timestamptz not null
+		)`, s.table))
+	return errors.Wrap(err, "failed to create rate limit table")
+}
+
+// DeleteExpired forgets the keys that weren't updated for their ttl
+func (s *PostgresStore) DeleteExpired(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf(`delete from %s where expires_at < now()`, s.table))
+	return errors.Wrap(err, "failed to delete expired rate limits")
+}
+
+// Update implements Store
+func (s *PostgresStore) Update(ctx context.Context, key string, ttl time.Duration, update func(state State) State) (err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin rate limit transaction")
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// the row is created first, so that concurrent requests for a new key wait on the same lock
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(`
+		insert into %s (key, expires_at) values ($1, now())
+		on conflict (key) do nothing`, s.table), key)
+	if err != nil {
+		return errors.Wrap(err, "failed to insert rate limit")
+	}
+
+	var state State
+	var at sql.NullTime
+	var expired bool
+	err = tx.QueryRowContext(ctx, fmt.Sprintf(`
+		select value, previous, at, expires_at < now()
+		from %s where key = $1 for update`, s.table), key).
+		Scan(&state.Value, &state.Previous, &at, &expired)
+	if err != nil {
+		return errors.Wrap(err, "failed to lock rate limit")
+	}
+
+	if expired {
+		state = State{}
+	} else if at.Valid {
+		state.At = at.Time
+	}
+
+	next := update(state)
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(`
+		update %s set value = $2, previous = $3, at = $4, expires_at = now() + $5 * interval '1 microsecond'
+		where key = $1`, s.table), key, next.Value, next.Previous, next.At, ttl.Microseconds())
+	if err != nil {
+		return errors.Wrap(err, "failed to update rate limit")
+	}
+
+	return errors.Wrap(tx.Commit(), "failed to commit rate limit")
+}
@@ -0,0 +1,171 @@
+// Package ratelimit throttles the callers of operations
+// a Limiter counts the requests of every key (i.e. a principal or client IP) with an Algorithm,
+// and keeps its counters in a Store shared by every instance of the service
+//
+//	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), ratelimit.TokenBucket{}, ratelimit.Limit{Requests: 100, Period: time.Minute})
+//	reg.Register(middleware.RegistryItem{
+//		Tags:       []string{"after_auth"},
+//		Middleware: ratelimit.Middleware(limiter),
+//	})
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Limit allows Requests per Period to every key
+type Limit struct {
+	Requests int
+	Period   time.Duration
+
+	// Burst is the number of requests a TokenBucket allows at once, it defaults to Requests
+	Burst int
+}
+
+func (l Limit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Requests
+}
+
+// Result is the outcome of a request counted by a Limiter
+type Result struct {
+	Allowed bool
+
+	// Limit is the number of requests allowed per period
+	Limit int
+
+	// Remaining is the number of requests still allowed
+	Remaining int
+
+	// Reset is the time until the full limit is available again
+	Reset time.Duration
+
+	// RetryAfter is the time until the next request is allowed, it is zero when Allowed
+	RetryAfter time.Duration
+}
+
+// State holds the counters of a key, it is interpreted by the Algorithm that wrote it
+type State struct {
+	// Value is the tokens left in a TokenBucket or the requests of the current window of a SlidingWindow
+	Value float64 `json:"value"`
+
+	// Previous is the requests of the previous window of a SlidingWindow
+	Previous float64 `json:"previous"`
+
+	// At is when the state was last updated (TokenBucket) or the start of the current window (SlidingWindow)
+	// a zero value is a key that wasn't seen before
+	At time.Time `json:"at"`
+}
+
+// Algorithm counts a request in the State of its key
+type Algorithm interface {
+	Allow(state State, limit Limit, now time.Time) (next State, result Result)
+}
+
+// Store keeps the State of every key
+// Update must apply update atomically, so that concurrent requests of a key (even from other instances) are all counted
+// keys that aren't updated for ttl can be forgotten
+type Store interface {
+	Update(ctx context.Context, key string, ttl time.Duration, update func(state State) State) error
+}
+
+// Limiter counts the requests of every key in a Store
+type Limiter struct {
+	Store     Store
+	Algorithm Algorithm
+	Limit     Limit
+
+	// Key identifies the caller of a request, it defaults to ByPrincipalOrClientIP
+	Key KeyFunc
+
+	// Prefix namespaces the keys of the limiter in its store, so limiters can share a Store
+	Prefix string
+
+	// Now defaults to time.Now
+	Now func() time.Time
+}
+
+// NewLimiter applies limit with algorithm to the callers of every request
+func NewLimiter(store Store, algorithm Algorithm, limit Limit) *Limiter {
+	return &Limiter{
+		Store:     store,
+		Algorithm: algorithm,
+		Limit:     limit,
+		Key:       ByPrincipalOrClientIP,
+		Prefix:    "ratelimit",
+		Now:       time.Now,
+	}
+}
+
+// WithKey identifies callers with key
+func (l *Limiter) WithKey(key KeyFunc) *Limiter {
+	l.Key = key
+	return l
+}
+
+// WithPrefix namespaces the keys of the limiter
+func (l *Limiter) WithPrefix(prefix string) *Limiter {
+	l.Prefix = prefix
+	return l
+}
+
+// Allow counts a request of key
+func (l *Limiter) Allow(ctx context.Context, key string) (result Result, err error) {
+	now := time.Now
+	if l.Now != nil {
+		now = l.Now
+	}
+
+	err = l.Store.Update(ctx, l.Prefix+":"+key, 2*l.Limit.Period, func(state State) State {
+		state, result = l.Algorithm.Allow(state, l.Limit, now())
+		return state
+	})
+	return
+}
+
+// Middleware counts every request with limiter and rejects the requests over the limit with transport.ErrRateLimited
+// requests without a key (i.e. KeyFunc returned false) aren't limited
+// the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers report the state of the caller,
+// and Retry-After is sent with 429 Too Many Requests
+func Middleware(limiter *Limiter) transport.Middleware {
+	keyFunc := limiter.Key
+	if keyFunc == nil {
+		keyFunc = ByPrincipalOrClientIP
+	}
+
+	return transport.NewMiddleware(func(tctx transport.Context, next transport.Handler) error {
+		req := tctx.Request()
+		key, ok := keyFunc(req)
+		if !ok {
+			return next.Serve(tctx)
+		}
+
+		result, err := limiter.Allow(req.Context(), key)
+		if err != nil {
+			return err
+		}
+
+		headers := tctx.Response().Headers()
+		headers.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		headers.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		headers.Set("RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+
+		if !result.Allowed {
+			headers.Set("Retry-After", strconv.Itoa(seconds(result.RetryAfter)))
+			return fmt.Errorf("%w: retry in %ds", transport.ErrRateLimited, seconds(result.RetryAfter))
+		}
+		return next.Serve(tctx)
+	})
+}
+
+// seconds rounds d up to whole seconds, as expected by Retry-After
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,233 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/kibu-sh/kibu/pkg/auth"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/kibu-sh/kibu/pkg/transport/httpx"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newTestClock() *testClock {
+	return &testClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func allow(t *testing.T, limiter *Limiter, n int) (results []Result) {
+	for range n {
+		result, err := limiter.Allow(context.Background(), "caller")
+		require.NoError(t, err)
+		results = append(results, result)
+	}
+	return
+}
+
+func TestTokenBucket(t *testing.T) {
+	clock := newTestClock()
+	limiter := NewLimiter(NewMemoryStore(), TokenBucket{}, Limit{Requests: 1, Period: time.Second, Burst: 3})
+	limiter.Now = clock.Now
+
+	t.Run("should allow a burst", func(t *testing.T) {
+		results := allow(t, limiter, 4)
+		require.True(t, results[2].Allowed)
+		require.Equal(t, 0, results[2].Remaining)
+		require.Equal(t, 3*time.Second, results[2].Reset)
+
+		require.False(t, results[3].Allowed)
+		require.Equal(t, time.Second, results[3].RetryAfter)
+	})
+
+	t.Run("should refill tokens at the rate of the limit", func(t *testing.T) {
+		clock.Advance(1500 * time.Millisecond)
+		results := allow(t, limiter, 2)
+		require.True(t, results[0].Allowed)
+		require.False(t, results[1].Allowed)
+		require.Equal(t, 500*time.Millisecond, results[1].RetryAfter)
+	})
+}
+
+func TestSlidingWindow(t *testing.T) {
+	clock := newTestClock()
+	limiter := NewLimiter(NewMemoryStore(), SlidingWindow{}, Limit{Requests: 4, Period: time.Minute})
+	limiter.Now = clock.Now
+
+	t.Run("should allow the limit in a window", func(t *testing.T) {
+		results := allow(t, limiter, 5)
+		require.True(t, results[3].Allowed)
+		require.Equal(t, 0, results[3].Remaining)
+		require.Equal(t, time.Minute, results[3].Reset)
+
+		require.False(t, results[4].Allowed)
+		require.Equal(t, time.Minute+15*time.Second, results[4].RetryAfter)
+	})
+
+	t.Run("should weight the requests of the previous window", func(t *testing.T) {
+		// 4 requests * 3/4 of the previous window still overlap
+		clock.Advance(75 * time.Second)
+		results := allow(t, limiter, 2)
+		require.True(t, results[0].Allowed)
+		require.Equal(t, 0, results[0].Remaining)
+		require.False(t, results[1].Allowed)
+		require.Equal(t, 15*time.Second, results[1].RetryAfter)
+	})
+
+	t.Run("should forget windows older than the previous one", func(t *testing.T) {
+		clock.Advance(2 * time.Minute)
+		results := allow(t, limiter, 1)
+		require.True(t, results[0].Allowed)
+		require.Equal(t, 3, results[0].Remaining)
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	clock := newTestClock()
+	limiter := NewLimiter(NewMemoryStore(), SlidingWindow{}, Limit{Requests: 1, Period: time.Minute})
+	limiter.Now = clock.Now
+
+	endpoint := transport.NewEndpoint(func(ctx context.Context, req struct{}) (res struct{}, err error) {
+		return
+	}).WithMiddleware(Middleware(limiter))
+	h := httpx.NewHandler("/", endpoint)
+
+	request := func(ip string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = ip + ":5000"
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	w := request("203.0.113.1")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "1", w.Header().Get("RateLimit-Limit"))
+	require.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	require.Equal(t, "60", w.Header().Get("RateLimit-Reset"))
+
+	w = request("203.0.113.1")
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "120", w.Header().Get("Retry-After"))
+	require.JSONEq(t, `{"message":"rate limited: retry in 120s","status":429}`, w.Body.String())
+
+	require.Equal(t, http.StatusOK, request("203.0.113.2").Code)
+}
+
+func TestKeyFuncs(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "192.0.2.1:5000"
+	req := &httpx.Request{Request: r}
+
+	key, ok := ByClientIP(req)
+	require.True(t, ok)
+	require.Equal(t, "ip:192.0.2.1", key)
+
+	t.Run("should ignore proxy headers sent by the client", func(t *testing.T) {
+		r.Header.Set("True-Client-IP", "198.51.100.7")
+		r.Header.Set("X-Real-Ip", "198.51.100.7")
+		r.Header.Set("X-Forwarded-For", "198.51.100.7")
+		defer r.Header.Del("True-Client-IP")
+		defer r.Header.Del("X-Real-Ip")
+		defer r.Header.Del("X-Forwarded-For")
+
+		key, _ := ByClientIP(req)
+		require.Equal(t, "ip:192.0.2.1", key)
+
+		trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8"})
+		require.NoError(t, err)
+		key, _ = ByProxiedClientIP(trusted...)(req)
+		require.Equal(t, "ip:192.0.2.1", key)
+	})
+
+	t.Run("should read the client from the right of X-Forwarded-For behind trusted proxies", func(t *testing.T) {
+		trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.1"})
+		require.NoError(t, err)
+		byProxiedClientIP := ByProxiedClientIP(trusted...)
+
+		// the client forged the first address, the load balancer appended the address it saw and an internal hop
+		r.Header.Set("X-Forwarded-For", "198.51.100.7, 203.0.113.9, 10.0.0.2")
+		defer r.Header.Del("X-Forwarded-For")
+
+		key, _ := byProxiedClientIP(req)
+		require.Equal(t, "ip:203.0.113.9", key)
+
+		r.Header.Set("X-Forwarded-For", "10.0.0.3")
+		key, _ = byProxiedClientIP(req)
+		require.Equal(t, "ip:10.0.0.3", key)
+
+		r.Header.Set("X-Forwarded-For", "unknown, 10.0.0.3")
+		key, _ = byProxiedClientIP(req)
+		require.Equal(t, "ip:10.0.0.3", key)
+	})
+
+	t.Run("should prefer the client IP headers of Cloudflare behind trusted proxies", func(t *testing.T) {
+		trusted, err := ParseTrustedProxies([]string{"192.0.2.1"})
+		require.NoError(t, err)
+		byProxiedClientIP := ByProxiedClientIP(trusted...)
+
+		r.Header.Set("X-Forwarded-For", "203.0.113.9")
+		r.Header.Set("True-Client-IP", "198.51.100.8")
+		defer r.Header.Del("X-Forwarded-For")
+		defer r.Header.Del("True-Client-IP")
+
+		key, _ := byProxiedClientIP(req)
+		require.Equal(t, "ip:198.51.100.8", key)
+
+		r.Header.Set("CF-Connecting-IP", "198.51.100.7")
+		defer r.Header.Del("CF-Connecting-IP")
+
+		key, _ = byProxiedClientIP(req)
+		require.Equal(t, "ip:198.51.100.7", key)
+
+		key, _ = ByProxiedClientIP()(req)
+		require.Equal(t, "ip:192.0.2.1", key, "the headers must be ignored on connections from untrusted peers")
+	})
+
+	_, ok = ByPrincipal(req)
+	require.False(t, ok)
+
+	req.WithContext(auth.PrincipalStore.Save(r.Context(), &auth.Principal{Subject: "user-1", Method: auth.MethodJWT}))
+	key, _ = ByPrincipalOrClientIP(req)
+	require.Equal(t, "principal:jwt:user-1", key)
+
+	r.Header.Set(auth.DefaultAPIKeyHeader, "secret")
+	key, _ = ByAPIKey(auth.DefaultAPIKeyHeader)(req)
+	require.Equal(t, "api_key:"+auth.HashAPIKey("secret"), key)
+}
+
+func TestConfig(t *testing.T) {
+	limiter, err := Config{Requests: 10, Period: "1m", Algorithm: AlgorithmSlidingWindow}.Limiter(NewMemoryStore())
+	require.NoError(t, err)
+	require.Equal(t, Limit{Requests: 10, Period: time.Minute}, limiter.Limit)
+
+	_, err = Config{Requests: 10, Period: "1m", Algorithm: "leaky_bucket"}.Limiter(NewMemoryStore())
+	require.ErrorIs(t, err, ErrUnknownAlgorithm)
+
+	_, err = Config{Requests: 10}.Limiter(NewMemoryStore())
+	require.Error(t, err)
+
+	_, err = Config{Requests: 10, Period: "1m", TrustedProxies: []string{"10.0.0.0/33"}}.Limiter(NewMemoryStore())
+	require.Error(t, err)
+
+	limiter, err = Config{Requests: 10, Period: "1m", Key: KeyClientIP, TrustedProxies: []string{"10.0.0.1", "::1"}}.Limiter(NewMemoryStore())
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "[::1]:5000"
+	r.Header.Set("X-Forwarded-For", "203.0.113.9")
+	key, _ := limiter.Key(&httpx.Request{Request: r})
+	require.Equal(t, "ip:203.0.113.9", key)
+}
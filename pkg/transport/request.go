@@ -24,9 +24,8 @@ type Request interface {
 	WithContext(ctx context.Context) Request
 
 	// Body exposes io.ReadCloser from the Underlying request
-	// We recommend using http.MaxBytesReader to limit the size of the body
-	// Alternatively you can use io.LimitReader to limit the size of the body
-	// Consider using a middleware function to limit the maximum size of the body
+	// httpx.Handler limits its size with http.MaxBytesReader when httpx.Limits.MaxBodyBytes is set
+	// (i.e. //kibu:service:method max_body=1MiB)
 	Body() io.ReadCloser
 
 	// BodyBuffer returns a buffer that can be used to read the body of the request
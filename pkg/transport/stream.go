@@ -0,0 +1,195 @@
+package transport
+
+import (
+	"context"
+	"github.com/pkg/errors"
+)
+
+// ErrStreamClosed is returned when sending to a stream that has already been closed
+var ErrStreamClosed = errors.New("stream closed")
+
+// ErrStreamingNotSupported is returned by a StreamEndpoint when the transport cannot push events to a client
+var ErrStreamingNotSupported = errors.New("streaming is not supported by this transport")
+
+// Stream is a transport specific connection that pushes a sequence of events to a client
+// implementations are provided by transports (i.e. httpx speaks text/event-stream)
+type Stream interface {
+	// Send writes a single event to the client and flushes it
+	// id is optional and is echoed back by reconnecting clients through LastEventID
+	Send(ctx context.Context, id string, event any) error
+
+	// Heartbeat writes a message the client ignores, keeping idle connections and proxies alive
+	Heartbeat(ctx context.Context) error
+
+	// Fail reports an error to the client once the stream has been opened
+	// the status code has already been sent, so errors are delivered in band
+	Fail(ctx context.Context, err error) error
+
+	// Close ends the stream, subsequent calls to Send return ErrStreamClosed
+	Close() error
+
+	// LastEventID returns the id of the last event seen by a reconnecting client
+	// an empty string means the client is connecting for the first time
+	LastEventID() string
+}
+
+// Streamer is implemented by transport contexts that support streaming responses
+type Streamer interface {
+	OpenStream() (Stream, error)
+}
+
+// StreamSink is the typed view of a Stream handed to a StreamEndpointFunc
+type StreamSink[Event any] interface {
+	// Send writes an event to the client
+	Send(ctx context.Context, event Event) error
+
+	// SendWithID writes an event with an id the client can resume from
+	SendWithID(ctx context.Context, id string, event Event) error
+
+	// Heartbeat keeps an idle stream alive
+	Heartbeat(ctx context.Context) error
+
+	// Close ends the stream before the endpoint returns
+	Close() error
+
+	// LastEventID returns the id a reconnecting client wants to resume after
+	LastEventID() string
+}
+
+// StreamEndpointFunc is a functional implementation of StreamEndpoint
+// ctx is cancelled when the client disconnects
+type StreamEndpointFunc[Req, Event any] func(ctx context.Context, request Req, sink StreamSink[Event]) (err error)
+
+// StreamEndpoint is the streaming counterpart of Endpoint
+// it decodes and validates a request the same way, then pushes events to the client until the func returns
+type StreamEndpoint[Req, Event any] struct {
+	Func          StreamEndpointFunc[Req, Event]
+	Validator     Validator
+	Middleware    []Middleware
+	Authorization Authorization
+}
+
+func NewStreamEndpoint[Req, Event any](
+	endpointFunc StreamEndpointFunc[Req, Event],
+) (ep *StreamEndpoint[Req, Event]) {
+	ep = &StreamEndpoint[Req, Event]{
+		Func: endpointFunc,
+	}
+	return
+}
+
+func (endpoint StreamEndpoint[Req, Event]) WithValidator(validator Validator) StreamEndpoint[Req, Event] {
+	endpoint.Validator = validator
+	return endpoint
+}
+
+func (endpoint StreamEndpoint[Req, Event]) WithMiddleware(middleware ...Middleware) StreamEndpoint[Req, Event] {
+	endpoint.Middleware = middleware
+	return endpoint
+}
+
+// WithAuthorization checks every call with an Authorizer after the middleware and before the func
+func (endpoint StreamEndpoint[Req, Event]) WithAuthorization(authorization Authorization) StreamEndpoint[Req, Event] {
+	endpoint.Authorization = authorization
+	return endpoint
+}
+
+// Serve implements transport.Handler
+// the stream is opened once the middleware and authorization have accepted the request,
+// errors raised before that are encoded like any other endpoint (i.e. 401 Unauthorized with its headers)
+// errors raised afterward are delivered in band through Stream.Fail
+func (endpoint StreamEndpoint[Req, Event]) Serve(tctx Context) (err error) {
+	codec := tctx.Codec()
+	rawRes := tctx.Response()
+	rawCtx := tctx.Request().Context()
+
+	streamer, ok := tctx.(Streamer)
+	if !ok {
+		return codec.EncodeError(rawCtx, rawRes, ErrStreamingNotSupported)
+	}
+
+	decoded, err := decodeRequest[Req](tctx, endpoint.Validator)
+	if err != nil {
+		return codec.EncodeError(rawCtx, rawRes, err)
+	}
+
+	var stream Stream
+	defer func() {
+		if stream != nil {
+			_ = stream.Close()
+		}
+	}()
+
+	err = ApplyMiddleware(endpoint.asHandler(*decoded, streamer, &stream), endpoint.Middleware...).Serve(tctx)
+	if err == nil || errors.Is(err, ErrResponseIntercepted) || errors.Is(err, context.Canceled) {
+		return nil
+	}
+
+	_, panicked := AsPanicError(err)
+	if stream == nil {
+		if panicked {
+			// the transport decides how the panic is reported
+			return err
+		}
+		return codec.EncodeError(rawCtx, rawRes, err)
+	}
+
+	failErr := stream.Fail(rawCtx, err)
+	if panicked {
+		// the stream reports a generic failure, the transport decides how the panic is reported
+		return err
+	}
+	return failErr
+}
+
+// asHandler converts the endpoint func into a HandlerFunc that opens the stream once the request is authorized
+// the opened stream is stored in stream, so Serve knows whether errors must be delivered in band
+func (endpoint StreamEndpoint[Req, Event]) asHandler(req Req, streamer Streamer, stream *Stream) HandlerFunc {
+	return func(tctx Context) (err error) {
+		defer RecoverPanic(&err)
+
+		// allows endpoint to access the original transport context with a signature of context.Context
+		envelopedTransportCtx := ContextStore.Save(tctx.Request().Context(), tctx)
+		if err = endpoint.Authorization.authorize(envelopedTransportCtx, req); err != nil {
+			return
+		}
+
+		opened, err := streamer.OpenStream()
+		if err != nil {
+			return
+		}
+		*stream = opened
+		return endpoint.Func(envelopedTransportCtx, req, NewStreamSink[Event](opened))
+	}
+}
+
+var _ StreamSink[any] = (*streamSink[any])(nil)
+
+type streamSink[Event any] struct {
+	stream Stream
+}
+
+// NewStreamSink wraps a transport Stream with a typed StreamSink
+func NewStreamSink[Event any](stream Stream) StreamSink[Event] {
+	return &streamSink[Event]{stream: stream}
+}
+
+func (s *streamSink[Event]) Send(ctx context.Context, event Event) error {
+	return s.stream.Send(ctx, "", event)
+}
+
+func (s *streamSink[Event]) SendWithID(ctx context.Context, id string, event Event) error {
+	return s.stream.Send(ctx, id, event)
+}
+
+func (s *streamSink[Event]) Heartbeat(ctx context.Context) error {
+	return s.stream.Heartbeat(ctx)
+}
+
+func (s *streamSink[Event]) Close() error {
+	return s.stream.Close()
+}
+
+func (s *streamSink[Event]) LastEventID() string {
+	return s.stream.LastEventID()
+}
@@ -0,0 +1,209 @@
+// Package validation implements transport.Validator with `validate:"..."` struct tags
+// rules are the ones of github.com/go-playground/validator, and violations are reported as transport.FieldErrors
+// named the way clients send them (i.e. the json key or the query param)
+//
+//	type CreateAccountRequest struct {
+//		OrgID string `path:"org_id" validate:"uuid"`
+//		Name  string `json:"name" validate:"required,max=64"`
+//		State string `json:"state" validate:"state"`
+//	}
+package validation
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"github.com/kibu-sh/kibu/pkg/enum"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/pkg/errors"
+	"reflect"
+	"strings"
+)
+
+// TagName is the struct tag holding the rules of a field
+const TagName = "validate"
+
+var _ transport.Validator = (*Validator)(nil)
+
+// Default is installed on every endpoint generated by kibugenv2
+// custom rules should be registered on it before any request is served (i.e. in an init func)
+var Default = New()
+
+// Rule reports whether value satisfies a custom rule
+// param is the value after the equal sign of the tag (i.e. 10 in `validate:"multiple_of=10"`)
+type Rule func(value any, param string) bool
+
+// Validator validates decoded requests with their struct tags
+type Validator struct {
+	validate *validator.Validate
+}
+
+// New returns a Validator with the built-in rules of github.com/go-playground/validator
+func New() *Validator {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	validate.SetTagName(TagName)
+	return &Validator{validate: validate}
+}
+
+// RegisterRule makes a custom rule available to struct tags under name
+//
+//	v.RegisterRule("even", func(value any, param string) bool {
+//		n, ok := value.(int)
+//		return ok && n%2 == 0
+//	})
+func (v *Validator) RegisterRule(name string, rule Rule) error {
+	return v.validate.RegisterValidation(name, func(fl validator.FieldLevel) bool {
+		return rule(fl.Field().Interface(), fl.Param())
+	})
+}
+
+// RegisterEnum makes a rule named name that accepts the members of set
+// fields of any type convertible to T are accepted (i.e. a string field for a Set of a string type)
+//
+//	validation.RegisterEnum(validation.Default, "state", stateSet)
+func RegisterEnum[T enum.ID](v *Validator, name string, set enum.Set[T]) error {
+	target := reflect.TypeFor[T]()
+	return v.validate.RegisterValidation(name, func(fl validator.FieldLevel) bool {
+		field := fl.Field()
+		if !field.CanConvert(target) {
+			return false
+		}
+		return set.Has(field.Convert(target).Interface().(T))
+	})
+}
+
+// Validate implements transport.Validator
+// values that aren't structs have no tags to validate and are always valid
+func (v *Validator) Validate(ctx context.Context, decoded any) error {
+	value := reflect.Indirect(reflect.ValueOf(decoded))
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	err := v.validate.StructCtx(ctx, value.Interface())
+
+	var violations validator.ValidationErrors
+	if !errors.As(err, &violations) {
+		return err
+	}
+
+	fields := make(transport.FieldErrors, 0, len(violations))
+	for _, violation := range violations {
+		name, location := describeField(value.Type(), violation.StructNamespace())
+		fields = append(fields, transport.FieldError{
+			Field:    name,
+			Location: location,
+			Reason:   reason(violation),
+		})
+	}
+	return fields
+}
+
+// reason describes a violated rule the way it is written in the tag
+//
+//	required, max=64, oneof=a b
+func reason(violation validator.FieldError) string {
+	if violation.Param() == "" {
+		return violation.Tag()
+	}
+	return fmt.Sprintf("%s=%s", violation.Tag(), violation.Param())
+}
+
+// locationTags are the tags of the httpx decoders that bind a field from outside the body
+var locationTags = []string{
+	transport.LocationPath,
+	transport.LocationQuery,
+	transport.LocationHeader,
+	transport.LocationCookie,
+}
+
+// describeField resolves the dotted name and location of a field from the go names of its namespace
+// the location is decided by the top level field, and fields of embedded structs are promoted like encoding/json does
+//
+//	CreateInvoiceRequest.Lines[0].Amount → lines[0].amount, body
+//	CreateInvoiceRequest.AccountPath.ID → id, path
+func describeField(root reflect.Type, namespace string) (name string, location string) {
+	segments := strings.Split(namespace, ".")
+	if len(segments) > 0 {
+		// the first segment is the name of the root type
+		segments = segments[1:]
+	}
+
+	var path []string
+	ty := root
+	for _, segment := range segments {
+		fieldName, index := splitIndex(segment)
+
+		ty = derefType(ty)
+		if ty.Kind() != reflect.Struct {
+			path = append(path, segment)
+			continue
+		}
+
+		field, ok := ty.FieldByName(fieldName)
+		if !ok {
+			path = append(path, segment)
+			continue
+		}
+
+		tagName, tagLocation := fieldTagName(field)
+		if location == "" && len(path) == 0 && tagLocation != "" {
+			location = tagLocation
+		}
+
+		ty = field.Type
+		if field.Anonymous && tagName == "" {
+			continue
+		}
+
+		if tagName == "" {
+			tagName = field.Name
+		}
+		path = append(path, tagName+index)
+		if index != "" {
+			ty = derefType(ty).Elem()
+		}
+	}
+
+	if location == "" {
+		location = transport.LocationBody
+	}
+	return strings.Join(path, "."), location
+}
+
+// fieldTagName returns the name of a field bound by a location tag, or else its json key
+func fieldTagName(field reflect.StructField) (name string, location string) {
+	for _, tag := range locationTags {
+		if value, ok := field.Tag.Lookup(tag); ok {
+			return tagValueName(value), tag
+		}
+	}
+
+	name = tagValueName(field.Tag.Get("json"))
+	if name == "-" {
+		name = ""
+	}
+	return name, ""
+}
+
+func tagValueName(value string) string {
+	name, _, _ := strings.Cut(value, ",")
+	return name
+}
+
+// splitIndex separates the index of a slice or map element from its field name
+//
+//	Lines[0] → Lines, [0]
+func splitIndex(segment string) (name string, index string) {
+	if i := strings.Index(segment, "["); i >= 0 {
+		return segment[:i], segment[i:]
+	}
+	return segment, ""
+}
+
+func derefType(ty reflect.Type) reflect.Type {
+	for ty.Kind() == reflect.Pointer {
+		ty = ty.Elem()
+	}
+	return ty
+}
@@ -0,0 +1,83 @@
+package validation
+
+import (
+	"context"
+	"github.com/kibu-sh/kibu/pkg/enum"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type stateCode string
+
+var stateSet = enum.NewSet[stateCode](
+	enum.NewItem[stateCode]("AZ", "Arizona"),
+	enum.NewItem[stateCode]("CA", "California"),
+)
+
+type accountPath struct {
+	ID string `path:"id" validate:"required"`
+}
+
+type invoiceLine struct {
+	Amount int `json:"amount" validate:"gt=0"`
+}
+
+type createInvoiceRequest struct {
+	accountPath
+	Currency string        `query:"currency" validate:"omitempty,len=3"`
+	State    stateCode     `json:"state" validate:"state"`
+	Lines    []invoiceLine `json:"lines" validate:"required,dive"`
+	Seats    int           `json:"seats,omitempty" validate:"even"`
+	Memo     string        `validate:"max=5"`
+}
+
+func newTestValidator(t *testing.T) *Validator {
+	v := New()
+	require.NoError(t, RegisterEnum(v, "state", stateSet))
+	require.NoError(t, v.RegisterRule("even", func(value any, param string) bool {
+		n, ok := value.(int)
+		return ok && n%2 == 0
+	}))
+	return v
+}
+
+func TestValidator(t *testing.T) {
+	v := newTestValidator(t)
+	ctx := context.Background()
+
+	t.Run("should accept valid requests", func(t *testing.T) {
+		req := &createInvoiceRequest{
+			accountPath: accountPath{ID: "123"},
+			State:       "AZ",
+			Lines:       []invoiceLine{{Amount: 1}},
+		}
+		require.NoError(t, v.Validate(ctx, req))
+	})
+
+	t.Run("should name fields the way clients send them", func(t *testing.T) {
+		req := &createInvoiceRequest{
+			Currency: "dollars",
+			State:    "NY",
+			Lines:    []invoiceLine{{Amount: 1}, {Amount: 0}},
+			Seats:    3,
+			Memo:     "too long",
+		}
+
+		err := v.Validate(ctx, req)
+		require.Equal(t, transport.FieldErrors{
+			{Field: "id", Location: transport.LocationPath, Reason: "required"},
+			{Field: "currency", Location: transport.LocationQuery, Reason: "len=3"},
+			{Field: "state", Location: transport.LocationBody, Reason: "state"},
+			{Field: "lines[1].amount", Location: transport.LocationBody, Reason: "gt=0"},
+			{Field: "seats", Location: transport.LocationBody, Reason: "even"},
+			{Field: "Memo", Location: transport.LocationBody, Reason: "max=5"},
+		}, err)
+	})
+
+	t.Run("should ignore values that aren't structs", func(t *testing.T) {
+		var decoded any
+		require.NoError(t, v.Validate(ctx, &decoded))
+		require.NoError(t, v.Validate(ctx, new(string)))
+	})
+}
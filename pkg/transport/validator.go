@@ -11,6 +11,11 @@ type Validator interface {
 // ValidatorFunc is a functional implementation to the Validator interface
 type ValidatorFunc func(ctx context.Context, decoded any) error
 
+// Validate implements Validator
+func (v ValidatorFunc) Validate(ctx context.Context, decoded any) error {
+	return v(ctx, decoded)
+}
+
 // PayloadValidator is an alternative to Validator.
 // If the return value of a Decoder implements PayloadValidator, it will be called.
 // It can be used in lieu of or in tandem with Validator
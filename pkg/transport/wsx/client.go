@@ -0,0 +1,82 @@
+package wsx
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/kibu-sh/kibu/pkg/request"
+	"github.com/kibu-sh/kibu/pkg/transport/httpx"
+	"github.com/pkg/errors"
+	"strconv"
+	"sync"
+)
+
+// Client calls a Handler over a single connection
+// calls are serialized, each one waits for the reply to its frame before the next is sent
+type Client struct {
+	mtx  sync.Mutex
+	conn *httpx.WebSocketConn
+	seq  int
+}
+
+// Dial opens a connection to the Handler served at the URL of client
+func Dial(ctx context.Context, client *request.Client) (*Client, error) {
+	conn, err := httpx.DialWebSocket(ctx, client, httpx.DefaultWebSocketOptions)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn}, nil
+}
+
+// Call sends req as the body of a frame and decodes the body of the reply into res
+// replies with an error status are decoded as httpx.DefaultJSONError
+func (c *Client) Call(ctx context.Context, req any, res any) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode request")
+	}
+
+	c.seq++
+	id := strconv.Itoa(c.seq)
+	if err = c.conn.Send(ctx, Frame{ID: id, Body: body}); err != nil {
+		return err
+	}
+
+	var reply Frame
+	if err = c.conn.Receive(ctx, &reply); err != nil {
+		return err
+	}
+
+	if reply.ID != id {
+		return errors.Errorf("expected reply to frame %s but got %s", id, reply.ID)
+	}
+
+	if reply.Status >= 400 {
+		errRes := httpx.DefaultJSONError{Status: reply.Status}
+		_ = json.Unmarshal(reply.Body, &errRes)
+		return errRes
+	}
+
+	if res == nil || len(reply.Body) == 0 {
+		return nil
+	}
+	return json.Unmarshal(reply.Body, res)
+}
+
+// Close ends the connection normally
+func (c *Client) Close() error {
+	return c.conn.Close(nil)
+}
+
+// Call opens a connection, makes a single call and closes it
+// it is used by generated service clients for operations declared with transport=ws
+func Call(ctx context.Context, client *request.Client, req any, res any) error {
+	c, err := Dial(ctx, client)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	return c.Call(ctx, req, res)
+}
@@ -0,0 +1,137 @@
+package wsx
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/kibu-sh/kibu/pkg/transport/httpx"
+	"io"
+	"net/http"
+)
+
+var _ transport.Context = (*Context)(nil)
+
+// Context is the transport.Context of a single message received on a WebSocket
+// its request inherits the URL, headers and path params of the upgrade request and reads the message as its body
+type Context struct {
+	req   *httpx.Request
+	res   *Response
+	codec transport.Codec
+	conn  transport.Conn
+}
+
+func newContext(codec transport.Codec, conn transport.Conn, upgrade *http.Request, body json.RawMessage) *Context {
+	r := upgrade.Clone(upgrade.Context())
+	// messages carry a JSON body, so they are bound like the body of a POST
+	r.Method = http.MethodPost
+	r.Header.Set("Content-Type", "application/json")
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+
+	req := httpx.NewRequest(r)
+	tctx := &Context{
+		req:   req,
+		res:   NewResponse(conn),
+		codec: codec,
+		conn:  conn,
+	}
+
+	// encoders reach the transport context through the request context
+	req.WithContext(transport.ContextStore.Save(r.Context(), tctx))
+	return tctx
+}
+
+func (c *Context) Codec() transport.Codec {
+	return c.codec
+}
+
+func (c *Context) Request() transport.Request {
+	return c.req
+}
+
+func (c *Context) Response() transport.Response {
+	return c.res
+}
+
+// Conn returns the connection the message was received on
+// handlers may use it to push additional messages to the client
+func (c *Context) Conn() transport.Conn {
+	return c.conn
+}
+
+var _ transport.Response = (*Response)(nil)
+
+// Response buffers what a handler writes so it can be sent as a single Frame
+type Response struct {
+	conn    transport.Conn
+	headers http.Header
+	status  int
+	body    *bytes.Buffer
+}
+
+func NewResponse(conn transport.Conn) *Response {
+	return &Response{
+		conn:    conn,
+		headers: http.Header{},
+		body:    new(bytes.Buffer),
+	}
+}
+
+func (r *Response) Write(b []byte) (int, error) {
+	// if the status code has not been set, default to 200
+	// this is implied on the first write of the response
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(b)
+}
+
+func (r *Response) Headers() http.Header {
+	return r.headers
+}
+
+func (r *Response) SetStatusCode(code int) {
+	r.status = code
+}
+
+// GetStatusCode returns 204 for handlers that neither set a status nor wrote a body
+func (r *Response) GetStatusCode() int {
+	if r.status == 0 {
+		return http.StatusNoContent
+	}
+	return r.status
+}
+
+func (r *Response) BytesWritten() int64 {
+	return int64(r.body.Len())
+}
+
+// DelCookie is a no-op, cookies can't be changed once a connection has been upgraded
+func (r *Response) DelCookie(cookie http.Cookie) transport.Response {
+	return r
+}
+
+// DelCookieByName is a no-op, cookies can't be changed once a connection has been upgraded
+func (r *Response) DelCookieByName(name string) transport.Response {
+	return r
+}
+
+// SetCookie is a no-op, cookies can't be changed once a connection has been upgraded
+func (r *Response) SetCookie(cookie http.Cookie) transport.Response {
+	return r
+}
+
+// Redirect reports the location in the headers, clients decide whether to follow it
+func (r *Response) Redirect(req transport.Request, url string, code int) {
+	r.headers.Set("Location", url)
+	r.SetStatusCode(code)
+}
+
+func (r *Response) BodyBuffer() *bytes.Buffer {
+	return r.body
+}
+
+// Underlying returns the transport.Conn of the message
+func (r *Response) Underlying() any {
+	return r.conn
+}
@@ -0,0 +1,131 @@
+// Package wsx serves kibu endpoints over WebSockets
+// every message received on a connection is dispatched to a transport.Handler as if it were a request
+// and whatever the handler writes to its transport.Response is sent back as a single message
+package wsx
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+)
+
+// Frame is the envelope of every message exchanged by a Handler
+// inbound frames carry the request body, outbound frames echo the ID and carry the status and response body
+//
+//	→ {"id":"1","body":{"name":"kibu"}}
+//	← {"id":"1","status":200,"body":{"greeting":"hello kibu"}}
+type Frame struct {
+	ID     string          `json:"id,omitempty"`
+	Status int             `json:"status,omitempty"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+var _ transport.Handler = (*Handler)(nil)
+
+// Handler upgrades a request to a WebSocket and dispatches every inbound Frame to a transport.Handler
+// it is served like any other endpoint, so the upgrade request passes through the middleware of the route
+//
+//	httpx.NewHandler("/greet", wsx.NewHandler(transport.NewEndpoint(svc.Greet)))
+type Handler struct {
+	Handler    transport.Handler
+	Middleware []transport.Middleware
+}
+
+func NewHandler(handler transport.Handler) *Handler {
+	return &Handler{
+		Handler: handler,
+	}
+}
+
+// WithMiddleware sets middleware that runs once per connection before it is upgraded
+// middleware of the wrapped handler runs once per message
+func (h *Handler) WithMiddleware(middleware ...transport.Middleware) *Handler {
+	h.Middleware = middleware
+	return h
+}
+
+// Serve implements transport.Handler
+func (h *Handler) Serve(tctx transport.Context) error {
+	return transport.ApplyMiddleware(transport.HandlerFunc(h.serveConn), h.Middleware...).Serve(tctx)
+}
+
+// serveConn reads frames until the client closes the connection
+// frames are handled in the order they arrive, so responses are sent in the same order
+func (h *Handler) serveConn(tctx transport.Context) error {
+	upgrade, ok := tctx.Request().Underlying().(*http.Request)
+	if !ok {
+		return transport.ErrChannelsNotSupported
+	}
+
+	channeler, ok := tctx.(transport.Channeler)
+	if !ok {
+		return transport.ErrChannelsNotSupported
+	}
+
+	conn, err := channeler.OpenChannel()
+	if errors.Is(err, transport.ErrResponseIntercepted) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	ctx := upgrade.Context()
+	for {
+		var frame Frame
+		err = conn.Receive(ctx, &frame)
+		if errors.Is(err, io.EOF) {
+			_ = conn.Close(nil)
+			return nil
+		}
+
+		var reply Frame
+		var bindingErr *transport.BindingError
+		switch {
+		case errors.As(err, &bindingErr):
+			// a malformed frame is answered like a request with a malformed body
+			reply = h.reply(newContext(tctx.Codec(), conn, upgrade, nil), frame.ID, err)
+		case err != nil:
+			// once upgraded, errors can only be reported by closing the connection
+			_ = conn.Close(err)
+			return nil
+		default:
+			mctx := newContext(tctx.Codec(), conn, upgrade, frame.Body)
+			reply = h.reply(mctx, frame.ID, h.Handler.Serve(mctx))
+		}
+
+		if err = conn.Send(ctx, reply); err != nil {
+			_ = conn.Close(err)
+			return nil
+		}
+	}
+}
+
+// reply captures the response of a message, encoding err the same way httpx.Handler does
+func (h *Handler) reply(mctx *Context, id string, err error) Frame {
+	if err != nil {
+		rawCtx := mctx.Request().Context()
+		if encodeErr := mctx.Codec().EncodeError(rawCtx, mctx.res, err); encodeErr != nil {
+			mctx.res = NewResponse(mctx.conn)
+			mctx.res.SetStatusCode(http.StatusInternalServerError)
+		}
+	}
+	return mctx.res.frame(id)
+}
+
+// frame wraps the body written by a handler, bodies that are not JSON are sent as a string
+func (r *Response) frame(id string) Frame {
+	body := bytes.TrimSpace(r.BodyBuffer().Bytes())
+	if len(body) > 0 && !json.Valid(body) {
+		body, _ = json.Marshal(string(body))
+	}
+
+	return Frame{
+		ID:     id,
+		Status: r.GetStatusCode(),
+		Body:   body,
+	}
+}
@@ -0,0 +1,92 @@
+package wsx
+
+import (
+	"context"
+	"github.com/kibu-sh/kibu/pkg/request"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/kibu-sh/kibu/pkg/transport/httpx"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type greetReq struct {
+	Lang string `query:"lang" json:"-"`
+	Name string `json:"name"`
+}
+
+func (r greetReq) Validate() error {
+	if r.Name == "" {
+		return transport.FieldErrors{{Field: "name", Location: transport.LocationBody, Reason: "required"}}
+	}
+	return nil
+}
+
+type greetRes struct {
+	Greeting string `json:"greeting"`
+}
+
+func greet(ctx context.Context, req greetReq) (res greetRes, err error) {
+	hello := "hello"
+	if req.Lang == "es" {
+		hello = "hola"
+	}
+	return greetRes{Greeting: hello + " " + req.Name}, nil
+}
+
+func TestHandler(t *testing.T) {
+	var connections int
+	countConnections := transport.NewMiddleware(func(tctx transport.Context, next transport.Handler) error {
+		connections++
+		return next.Serve(tctx)
+	})
+
+	handler := NewHandler(transport.NewEndpoint(greet)).WithMiddleware(countConnections)
+	server := httptest.NewServer(httpx.NewHandler("/", handler))
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+
+	base, err := request.ParseURL(server.URL)
+	require.NoError(t, err)
+
+	rc, err := httpx.NewClientRequest(base, http.MethodGet, "/", greetReq{Lang: "es"})
+	require.NoError(t, err)
+
+	client, err := Dial(ctx, rc)
+	require.NoError(t, err)
+	defer client.Close()
+
+	t.Run("should dispatch every frame with the params of the upgrade request", func(t *testing.T) {
+		for _, name := range []string{"kibu", "gopher"} {
+			var res greetRes
+			require.NoError(t, client.Call(ctx, greetReq{Name: name}, &res))
+			require.Equal(t, "hola "+name, res.Greeting)
+		}
+	})
+
+	t.Run("should reply with the status of an error and keep the connection open", func(t *testing.T) {
+		err := client.Call(ctx, greetReq{}, new(greetRes))
+		var errRes httpx.DefaultJSONError
+		require.ErrorAs(t, err, &errRes)
+		require.Equal(t, http.StatusUnprocessableEntity, errRes.Status)
+		require.Len(t, errRes.Errors, 1)
+
+		var res greetRes
+		require.NoError(t, client.Call(ctx, greetReq{Name: "again"}, &res))
+		require.Equal(t, "hola again", res.Greeting)
+	})
+
+	t.Run("should run connection middleware once", func(t *testing.T) {
+		require.Equal(t, 1, connections)
+	})
+
+	t.Run("should make single calls", func(t *testing.T) {
+		var res greetRes
+		require.NoError(t, Call(ctx, base, greetReq{Name: "once"}, &res))
+		require.Equal(t, "hello once", res.Greeting)
+	})
+}
@@ -5,18 +5,30 @@ import (
 	"fmt"
 	"github.com/google/wire"
 	"github.com/kibu-sh/kibu/pkg/appcontext"
+	"github.com/kibu-sh/kibu/pkg/auth"
 	"github.com/kibu-sh/kibu/pkg/config"
+	"github.com/kibu-sh/kibu/pkg/ctxutil"
 	"github.com/kibu-sh/kibu/pkg/foreman"
+	"github.com/kibu-sh/kibu/pkg/metrics"
+	"github.com/kibu-sh/kibu/pkg/requestctx"
+	"github.com/kibu-sh/kibu/pkg/tracing"
+	"github.com/kibu-sh/kibu/pkg/transport"
 	"github.com/kibu-sh/kibu/pkg/transport/httpx"
+	"github.com/kibu-sh/kibu/pkg/transport/idempotency"
 	"github.com/kibu-sh/kibu/pkg/transport/middleware"
+	"github.com/kibu-sh/kibu/pkg/transport/ratelimit"
 	"github.com/kibu-sh/kibu/pkg/transport/temporal"
 	"github.com/kibu-sh/kibu/pkg/workspace"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/worker"
 	"log/slog"
 	"net"
 	"net/http"
+	"os"
+	"time"
 )
 
 func ProvideServerAddress() httpx.ListenAddr {
@@ -24,6 +36,43 @@ func ProvideServerAddress() httpx.ListenAddr {
 	return httpx.ListenAddr(net.JoinHostPort("127.0.0.1", "6387"))
 }
 
+// AdminListenAddr is the address of the admin server, apart from the listeners of the application
+type AdminListenAddr string
+
+func ProvideAdminAddress() AdminListenAddr {
+	return AdminListenAddr(net.JoinHostPort("127.0.0.1", "6388"))
+}
+
+// AdminServer serves operational endpoints apart from the application (i.e. metrics.Path)
+// it only listens on the loopback interface by default, so its endpoints aren't authenticated
+type AdminServer struct {
+	Listener net.Listener
+	Server   *http.Server
+
+	// Mux serves the endpoints of Server, more can be mounted before it starts (i.e. httpx.RoutesPath)
+	Mux *http.ServeMux
+}
+
+// NewAdminServer listens on addr and serves the metrics of reg
+func NewAdminServer(addr AdminListenAddr, reg *prometheus.Registry) (admin *AdminServer, err error) {
+	listener, err := httpx.NewTCPListener(httpx.ListenAddr(addr))
+	if err != nil {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(metrics.Path, metrics.NewHandler(reg))
+	admin = &AdminServer{
+		Listener: listener,
+		Server: &http.Server{
+			Handler:           mux,
+			ReadHeaderTimeout: 5 * time.Second,
+		},
+		Mux: mux,
+	}
+	return
+}
+
 func NewListeners(
 	ctx context.Context,
 	addr httpx.ListenAddr,
@@ -37,16 +86,37 @@ func NewListeners(
 	return
 }
 
+// NewTemporalOptions reads the "temporal" key of the config store
+// the values of ctxutil.Propagators (i.e. the trace context and principal of callers) are carried through workflows into their activities
 func NewTemporalOptions(ctx context.Context, store config.Store) (opts client.Options, err error) {
-	_, err = store.GetByKey(ctx, "temporal", &opts)
+	if _, err = store.GetByKey(ctx, "temporal", &opts); err != nil {
+		return
+	}
+
+	opts.ContextPropagators = append(opts.ContextPropagators, ctxutil.Propagators.List()...)
+	opts.Interceptors = append(opts.Interceptors, tracing.NewInterceptor())
+	return
+}
+
+// NewTelemetryConfig reads the "telemetry" key of the config store
+// spans aren't exported when the key doesn't exist
+func NewTelemetryConfig(ctx context.Context, store config.Store) (cfg tracing.Config, err error) {
+	cfg = tracing.DefaultConfig
+	_, err = store.GetByKey(ctx, "telemetry", &cfg)
+	if errors.Is(err, os.ErrNotExist) {
+		err = nil
+	}
 	return
 }
 
+// NewTemporalClient connects to temporal, the metrics of the client and its workers are registered on reg
 func NewTemporalClient(
 	opts client.Options,
 	log *slog.Logger,
+	reg *prometheus.Registry,
 ) (c client.Client, err error) {
 	opts.Logger = log
+	opts.MetricsHandler = metrics.NewTemporalHandler(reg)
 
 	c, err = client.Dial(opts)
 	if err != nil {
@@ -70,9 +140,24 @@ func NewForeman(
 	server *http.Server,
 	listeners []net.Listener,
 	workers []worker.Worker,
+	tracerProvider *sdktrace.TracerProvider,
+	admin *AdminServer,
+	states *metrics.ProcessStates,
 	logger *slog.Logger,
 ) (m *foreman.Manager, err error) {
-	m = foreman.NewManager(ctx, foreman.WithLogger(logger))
+	m = foreman.NewManager(ctx,
+		foreman.WithLogger(logger),
+		foreman.WithStateObserver(states.Observe),
+	)
+	if err = m.Register(foreman.NewProcess("tracer provider", startTracerProvider(tracerProvider, logger))); err != nil {
+		return
+	}
+
+	name := fmt.Sprintf("admin server %s", admin.Listener.Addr().String())
+	if err = m.Register(foreman.NewProcess(name, startListener(admin.Listener, admin.Server, logger))); err != nil {
+		return
+	}
+
 	for i, wrk := range workers {
 		err = m.Register(foreman.NewProcess(
 			fmt.Sprintf("temporal-worker-%d", i), startWorker(wrk, logger),
@@ -126,13 +211,157 @@ func startWorker(wrk worker.Worker, logger *slog.Logger) foreman.StartFunc {
 	}
 }
 
-func BindHTTPHandlers(factories []httpx.HandlerFactory, reg *middleware.Registry) (httpxHandlers []*httpx.Handler) {
+// startTracerProvider flushes the spans buffered by the provider once the manager shuts down
+func startTracerProvider(provider *sdktrace.TracerProvider, logger *slog.Logger) foreman.StartFunc {
+	return func(ctx context.Context, ready func()) (err error) {
+		ready()
+		<-ctx.Done()
+
+		logger.Debug("flushing traces")
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		err = provider.Shutdown(shutdownCtx)
+		return
+	}
+}
+
+// NewAuthConfig reads the "auth" key of the config store
+// no authenticator is enabled when the key doesn't exist, so only public operations can be called
+func NewAuthConfig(ctx context.Context, store config.Store) (cfg auth.Config, err error) {
+	_, err = store.GetByKey(ctx, "auth", &cfg)
+	if errors.Is(err, os.ErrNotExist) {
+		err = nil
+	}
+	return
+}
+
+// NewAuthorizer checks the permissions of generated operations against the roles of the "auth" config
+// it is injected into generated controllers, services with their own policy provide another transport.Authorizer
+func NewAuthorizer(cfg auth.Config) transport.Authorizer {
+	roles := auth.NewRoleTable()
+	for role, permissions := range cfg.Roles {
+		roles.Grant(role, permissions...)
+	}
+	return roles
+}
+
+// NewIdempotencyConfig reads the "idempotency" key of the config store
+// the defaults of idempotency.NewReplayer are used when the key doesn't exist
+func NewIdempotencyConfig(ctx context.Context, store config.Store) (cfg idempotency.Config, err error) {
+	_, err = store.GetByKey(ctx, "idempotency", &cfg)
+	if errors.Is(err, os.ErrNotExist) {
+		err = nil
+	}
+	return
+}
+
+// NewIdempotencyStore keeps the responses of idempotent operations in the memory of each instance
+// services running more than one instance provide a shared idempotency.Store instead
+func NewIdempotencyStore() idempotency.Store {
+	return idempotency.NewMemoryStore()
+}
+
+// NewIdempotency replays the responses of idempotent operations from store
+// it is injected into generated controllers
+func NewIdempotency(cfg idempotency.Config, store idempotency.Store) (transport.Idempotency, error) {
+	return cfg.Replayer(store)
+}
+
+// NewRateLimitConfig reads the "rate_limit" key of the config store
+// requests aren't limited when the key doesn't exist
+func NewRateLimitConfig(ctx context.Context, store config.Store) (cfg *ratelimit.Config, err error) {
+	cfg = new(ratelimit.Config)
+	_, err = store.GetByKey(ctx, "rate_limit", cfg)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return
+}
+
+// NewMiddlewareRegistry returns the registry of the middleware applied to generated handlers
+// every request continues the trace of its caller with tracing.Middleware
+// and has its request ID and locale saved by requestctx.Middleware for the workflows it starts
+// operations that aren't public are authenticated by the authenticators enabled in the "auth" config,
+// and are answered with 401 Unauthorized when none is enabled
+// the "rate_limit" config throttles callers in the memory of each instance
+func NewMiddlewareRegistry(
+	ctx context.Context,
+	store config.Store,
+	authConfig auth.Config,
+	rateLimitConfig *ratelimit.Config,
+) (reg *middleware.Registry, err error) {
+	reg = middleware.NewRegistry()
+	reg.Register(middleware.RegistryItem{
+		Tags:       []string{"global"},
+		Middleware: tracing.Middleware(),
+	})
+	reg.Register(middleware.RegistryItem{
+		Tags:       []string{"global"},
+		Middleware: requestctx.Middleware(),
+	})
+
+	authenticators, err := auth.NewAuthenticators(ctx, store, authConfig)
+	if err != nil {
+		return
+	}
+
+	// without authenticators every request to an operation that isn't public is unauthenticated,
+	// a missing "auth" config must not open the operations
+	reg.Register(middleware.RegistryItem{
+		Tags:       []string{"auth"},
+		Middleware: auth.Middleware(authenticators...),
+	})
+
+	if rateLimitConfig != nil {
+		var limiter *ratelimit.Limiter
+		if limiter, err = rateLimitConfig.Limiter(ratelimit.NewMemoryStore()); err != nil {
+			return
+		}
+
+		// after_auth runs inside the auth middleware, so callers can be keyed by their principal
+		reg.Register(middleware.RegistryItem{
+			Tags:       []string{"after_auth"},
+			Middleware: ratelimit.Middleware(limiter),
+		})
+	}
+	return
+}
+
+// BindHTTPHandlers collects the handlers of every factory
+// the services registry is listed at httpx.RoutesPath of the admin server for `kibu routes`
+// every request is reported to observer
+func BindHTTPHandlers(
+	factories []httpx.HandlerFactory,
+	reg *middleware.Registry,
+	services transport.Registry,
+	observer httpx.Observer,
+	admin *AdminServer,
+) (httpxHandlers []*httpx.Handler) {
 	for _, factory := range factories {
 		httpxHandlers = append(httpxHandlers, factory.HTTPHandlerFactory(reg)...)
 	}
+
+	// the listing of every operation isn't served to the public listeners
+	admin.Mux.Handle(httpx.RoutesPath, httpx.NewRoutesHandler(services))
+
+	for _, handler := range httpxHandlers {
+		handler.WithObserver(observer)
+	}
 	return
 }
 
+// NewServiceRegistry registers the services of every factory generated by kibugenv2
+// factories that don't implement httpx.ServiceRegistrar are skipped
+func NewServiceRegistry(factories []httpx.HandlerFactory, reg *middleware.Registry) transport.Registry {
+	services := transport.NewRegistry()
+	for _, factory := range factories {
+		if registrar, ok := factory.(httpx.ServiceRegistrar); ok {
+			registrar.RegisterService(services, reg)
+		}
+	}
+	return services
+}
+
 func BindWorkers(factories []temporal.WorkerFactory) (workers []worker.Worker) {
 	for _, factory := range factories {
 		workers = append(workers, factory.Build())
@@ -145,6 +374,13 @@ var Required = wire.NewSet(
 	NewConfigStore,
 	NewForeman,
 	NewLogger,
+	NewTelemetryConfig,
+	tracing.NewTracerProvider,
+	ProvideAdminAddress,
+	NewAdminServer,
+	metrics.NewRegistry,
+	metrics.NewProcessStates,
+	wire.Bind(new(prometheus.Registerer), new(*prometheus.Registry)),
 )
 
 var Temporal = wire.NewSet(
@@ -157,7 +393,12 @@ var HTTPServeMux = wire.NewSet(
 	NewListeners,
 	ProvideServerAddress,
 	BindHTTPHandlers,
-	middleware.NewRegistry,
+	NewServiceRegistry,
+	NewMiddlewareRegistry,
+	NewAuthConfig,
+	NewRateLimitConfig,
+	metrics.NewHTTPObserver,
+	wire.Bind(new(httpx.Observer), new(*metrics.HTTPObserver)),
 	httpx.NewServer,
 	httpx.NewTCPListener,
 	httpx.NewStdLibMux,
@@ -165,8 +406,29 @@ var HTTPServeMux = wire.NewSet(
 	wire.Struct(new(httpx.NewServerParams), "*"),
 )
 
+// Authorization provides the transport.Authorizer of generated controllers
+// leave it out of an injector to provide a custom policy
+var Authorization = wire.NewSet(
+	NewAuthorizer,
+)
+
+// Idempotency provides the transport.Idempotency of generated controllers
+var Idempotency = wire.NewSet(
+	NewIdempotencyConfig,
+	NewIdempotency,
+)
+
+// IdempotencyStore provides the idempotency.Store of Idempotency
+// leave it out of an injector to provide a shared store
+var IdempotencyStore = wire.NewSet(
+	NewIdempotencyStore,
+)
+
 var DefaultSet = wire.NewSet(
 	Required,
 	Temporal,
 	HTTPServeMux,
+	Authorization,
+	Idempotency,
+	IdempotencyStore,
 )
@@ -0,0 +1,153 @@
+package wireset
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/kibu-sh/kibu/pkg/auth"
+	"github.com/kibu-sh/kibu/pkg/config"
+	"github.com/kibu-sh/kibu/pkg/transport"
+	"github.com/kibu-sh/kibu/pkg/transport/httpx"
+	"github.com/kibu-sh/kibu/pkg/transport/idempotency"
+	"github.com/kibu-sh/kibu/pkg/transport/middleware"
+	"github.com/kibu-sh/kibu/pkg/transport/ratelimit"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+var _ config.Store = mapStore{}
+
+// mapStore serves GetByKey from a map of JSON values, the other methods aren't used by wireset
+type mapStore map[string]any
+
+func (s mapStore) Get(ctx context.Context, params config.GetParams) (*config.CipherText, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s mapStore) Set(ctx context.Context, params config.SetParams) (*config.CipherText, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s mapStore) List(ctx context.Context, params config.ListParams) (config.Iterator, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s mapStore) GetByKey(ctx context.Context, key string, target any) (*config.CipherText, error) {
+	value, ok := s[key]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return nil, json.Unmarshal(data, target)
+}
+
+func TestMiddlewareRegistry_RateLimitByPrincipal(t *testing.T) {
+	ctx := context.Background()
+	store := mapStore{
+		"api_keys": []auth.APIKey{
+			{Name: "alice", SHA256: auth.HashAPIKey("alice-key")},
+			{Name: "bob", SHA256: auth.HashAPIKey("bob-key")},
+		},
+		"rate_limit": ratelimit.Config{Requests: 1, Period: "1m", Key: ratelimit.KeyPrincipal},
+	}
+
+	authConfig := auth.Config{APIKeys: &auth.APIKeyConfig{StoreKey: "api_keys"}}
+	rateLimitConfig, err := NewRateLimitConfig(ctx, store)
+	require.NoError(t, err)
+	require.NotNil(t, rateLimitConfig)
+
+	reg, err := NewMiddlewareRegistry(ctx, store, authConfig, rateLimitConfig)
+	require.NoError(t, err)
+
+	endpoint := transport.NewEndpoint(func(ctx context.Context, req struct{}) (res struct{}, err error) {
+		return
+	}).WithMiddleware(reg.Get(middleware.GetParams{})...)
+	h := httpx.NewHandler("/ping", endpoint).WithMethods(http.MethodGet)
+
+	get := func(key, remoteAddr string) int {
+		r := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		r.RemoteAddr = remoteAddr + ":5000"
+		r.Header.Set(auth.DefaultAPIKeyHeader, key)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusOK, get("alice-key", "203.0.113.1"))
+	require.Equal(t, http.StatusTooManyRequests, get("alice-key", "203.0.113.2"),
+		"the principal should be limited whatever address it comes from")
+	require.Equal(t, http.StatusOK, get("bob-key", "203.0.113.1"),
+		"other principals should have their own limit")
+	require.Equal(t, http.StatusUnauthorized, get("unknown-key", "203.0.113.3"))
+}
+
+func TestNewAuthorizer(t *testing.T) {
+	authorizer := NewAuthorizer(auth.Config{Roles: map[string][]string{"viewer": {"billing.read"}}})
+	ctx := auth.PrincipalStore.Save(context.Background(), &auth.Principal{Subject: "alice", Roles: []string{"viewer"}})
+
+	require.NoError(t, authorizer.Authorize(ctx, transport.AuthorizationCheck{Permissions: []string{"billing.read"}}))
+	require.ErrorIs(t, authorizer.Authorize(ctx, transport.AuthorizationCheck{Permissions: []string{"billing.write"}}), transport.ErrForbidden)
+
+	// roles are granted to the authorizer only, not to a table shared by every other authorizer
+	other := NewAuthorizer(auth.Config{})
+	require.ErrorIs(t, other.Authorize(ctx, transport.AuthorizationCheck{Permissions: []string{"billing.read"}}), transport.ErrForbidden)
+}
+
+func TestNewIdempotency(t *testing.T) {
+	ctx := context.Background()
+	cfg, err := NewIdempotencyConfig(ctx, mapStore{})
+	require.NoError(t, err)
+
+	_, err = NewIdempotency(cfg, NewIdempotencyStore())
+	require.NoError(t, err)
+
+	cfg, err = NewIdempotencyConfig(ctx, mapStore{"idempotency": idempotency.Config{TTL: "never"}})
+	require.NoError(t, err)
+
+	_, err = NewIdempotency(cfg, NewIdempotencyStore())
+	require.Error(t, err)
+}
+
+func TestBindHTTPHandlers_RoutesOnAdminServer(t *testing.T) {
+	admin, err := NewAdminServer("127.0.0.1:0", prometheus.NewRegistry())
+	require.NoError(t, err)
+	defer admin.Listener.Close()
+
+	handlers := BindHTTPHandlers(nil, middleware.NewRegistry(), transport.NewRegistry(), nil, admin)
+	require.Empty(t, handlers, "routes must not be served to the public listeners")
+
+	w := httptest.NewRecorder()
+	admin.Mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, httpx.RoutesPath, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `[]`, w.Body.String())
+}
+
+func TestMiddlewareRegistry_WithoutAuthConfig(t *testing.T) {
+	ctx := context.Background()
+	authConfig, err := NewAuthConfig(ctx, mapStore{})
+	require.NoError(t, err)
+
+	reg, err := NewMiddlewareRegistry(ctx, mapStore{}, authConfig, nil)
+	require.NoError(t, err)
+
+	serve := func(params middleware.GetParams) int {
+		endpoint := transport.NewEndpoint(func(ctx context.Context, req struct{}) (res struct{}, err error) {
+			return
+		}).WithMiddleware(reg.Get(params)...)
+
+		w := httptest.NewRecorder()
+		httpx.NewHandler("/ping", endpoint).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ping", nil))
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusUnauthorized, serve(middleware.GetParams{}), "operations must not be open without authenticators")
+	require.Equal(t, http.StatusOK, serve(middleware.GetParams{ExcludeAuth: true}))
+}
@@ -42,7 +42,27 @@ func (s ConfigStoreSettings) KeyByEnv(env string) (config.EncryptionKey, error)
 }
 
 type CodeGenSettings struct {
-	OutputDir string `json:"output_dir"`
+	OutputDir           string `json:"output_dir"`
+	TypeScriptOutputDir string `json:"typescript_output_dir"`
+	OpenAPIOutputDir    string `json:"openapi_output_dir"`
+}
+
+// TypeScriptDir returns the directory for generated TypeScript clients relative to the workspace root
+// it defaults to a typescript directory inside OutputDir
+func (s CodeGenSettings) TypeScriptDir() string {
+	if s.TypeScriptOutputDir != "" {
+		return s.TypeScriptOutputDir
+	}
+	return filepath.Join(s.OutputDir, "typescript")
+}
+
+// OpenAPIDir returns the directory for generated OpenAPI documents relative to the workspace root
+// it defaults to an openapi directory inside OutputDir
+func (s CodeGenSettings) OpenAPIDir() string {
+	if s.OpenAPIOutputDir != "" {
+		return s.OpenAPIOutputDir
+	}
+	return filepath.Join(s.OutputDir, "openapi")
 }
 
 // Config holds data for configuring a workspace